@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"tts-service/internal/audiobook"
+	"tts-service/internal/config"
+	"tts-service/internal/db"
+	"tts-service/internal/models"
+	"tts-service/internal/storage"
+	"tts-service/internal/tts"
+)
+
+func main() {
+	var (
+		configPath = flag.String("config", "config.yaml", "配置文件路径")
+		input      = flag.String("input", "", "输入文件 (.epub, .md, .txt)")
+		voice      = flag.String("voice", "", "语音名称，默认使用配置中的默认语音")
+		speed      = flag.Float64("speed", 1.0, "语速")
+		rate       = flag.String("rate", "", "语速的百分比或关键字，如 +20%、slow，指定时覆盖 -speed")
+		pitch      = flag.String("pitch", "", "音调，如 +50Hz、-2st、+10%、high")
+		volume     = flag.String("volume", "", "音量，如 -20%、soft")
+		style      = flag.String("style", "", "说话风格，如 cheerful")
+		title      = flag.String("title", "", "书名，默认读取文件元数据")
+		author     = flag.String("author", "", "作者，默认读取文件元数据")
+	)
+	flag.Parse()
+
+	if *input == "" {
+		fmt.Println("需要提供输入文件: -input <book.epub>")
+		os.Exit(1)
+	}
+
+	// 加载配置
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 初始化数据库
+	database, err := db.Init(cfg.Database.Path)
+	if err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer database.Close()
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		log.Fatalf("读取文件失败: %v", err)
+	}
+
+	store, err := storage.New(&cfg.Storage)
+	if err != nil {
+		log.Fatalf("初始化存储失败: %v", err)
+	}
+
+	builder := audiobook.NewBuilder(database, tts.NewTTSService(database, cfg, store), cfg)
+	opts := audiobook.Options{
+		Title:  *title,
+		Author: *author,
+		Request: models.TTSRequest{
+			Voice:  *voice,
+			Speed:  *speed,
+			Rate:   *rate,
+			Pitch:  models.ProsodyValue(*pitch),
+			Volume: models.ProsodyValue(*volume),
+			Style:  *style,
+		},
+	}
+
+	// 命令行生成的有声书不属于任何用户
+	book, resumed, err := builder.Create(0, *input, data, opts, models.JobRunning)
+	if err != nil {
+		log.Fatalf("创建有声书失败: %v", err)
+	}
+	if resumed {
+		fmt.Printf("♻️  继续上次未完成的进度: %d/%d 章\n", book.DoneChapters, book.TotalChapters)
+		if err := database.UpdateAudiobookStatus(book.ID, models.JobRunning, ""); err != nil {
+			log.Fatalf("更新状态失败: %v", err)
+		}
+	}
+	fmt.Printf("📖 %s，共 %d 章，ID: %s\n", book.Title, book.TotalChapters, book.ID)
+
+	// Ctrl+C 中断后保留已完成的章节，再次运行同一命令即可继续
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = builder.Run(ctx, book, func(chapter models.AudiobookChapter, done, total int) {
+		fmt.Printf("✅ [%d/%d] %s (%.1f秒)\n", done, total, chapter.Title, chapter.Duration)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Println("⏸️  已中断，再次运行同一命令即可继续")
+		}
+		if err := database.UpdateAudiobookStatus(book.ID, models.JobFailed, err.Error()); err != nil {
+			log.Printf("更新状态失败: %v", err)
+		}
+		log.Fatalf("生成有声书失败: %v", err)
+	}
+
+	if err := database.UpdateAudiobookStatus(book.ID, models.JobSucceeded, ""); err != nil {
+		log.Fatalf("更新状态失败: %v", err)
+	}
+	fmt.Printf("🎉 生成完成: %s\n", audiobook.FileKey(book.ID, audiobook.BookFile))
+}
@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Edge TTS 输出的音频参数
+const (
+	SampleRate    = 24000 // 采样率
+	BitsPerSample = 16    // 位深
+	Channels      = 1     // 声道数
+
+	mp3FrameSize    = 144 // MPEG-2 Layer III 24kHz 48kbps 单帧字节数
+	mp3FrameSamples = 576 // 每帧采样数
+)
+
+// WAVHeader 生成44字节的RIFF/WAVE头
+func WAVHeader(dataLen uint32, sampleRate uint32, channels, bitsPerSample uint16) []byte {
+	blockAlign := channels * bitsPerSample / 8
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], 36+dataLen)
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:24], channels)
+	binary.LittleEndian.PutUint32(header[24:28], sampleRate)
+	binary.LittleEndian.PutUint32(header[28:32], sampleRate*uint32(blockAlign))
+	binary.LittleEndian.PutUint16(header[32:34], blockAlign)
+	binary.LittleEndian.PutUint16(header[34:36], bitsPerSample)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], dataLen)
+	return header
+}
+
+// wavUnknownSize 流式输出的WAV头中RIFF和data块的长度，表示长度未知，读取时以实际数据为准
+const wavUnknownSize = 0xFFFFFFFF
+
+// wavStreamHeader 生成长度未知的WAV头，用于无法回写头部的流式输出
+func wavStreamHeader(sampleRate uint32, channels, bitsPerSample uint16) []byte {
+	header := WAVHeader(0, sampleRate, channels, bitsPerSample)
+	binary.LittleEndian.PutUint32(header[4:8], wavUnknownSize)
+	binary.LittleEndian.PutUint32(header[40:44], wavUnknownSize)
+	return header
+}
+
+// PCMBytes 指定时长的16位单声道PCM数据字节数
+func PCMBytes(d time.Duration) int {
+	samples := int(d * SampleRate / time.Second)
+	return samples * Channels * BitsPerSample / 8
+}
+
+// MP3SilenceFrames 生成指定时长的静音MP3帧（MPEG-2 Layer III，24kHz，48kbps，单声道）
+// 帧头之后的side info全为0，解码结果为静音
+func MP3SilenceFrames(d time.Duration) []byte {
+	frames := int((d*SampleRate/time.Second + mp3FrameSamples - 1) / mp3FrameSamples)
+	data := make([]byte, frames*mp3FrameSize)
+	for i := 0; i < frames; i++ {
+		copy(data[i*mp3FrameSize:], []byte{0xFF, 0xF3, 0x64, 0xC0})
+	}
+	return data
+}
@@ -0,0 +1,395 @@
+package audio
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// FLAC编码参数
+const (
+	flacBlockSize      = 4096 // 每帧采样数
+	flacMaxFixedOrder  = 4    // 固定预测器的最高阶数
+	flacMaxRiceParam   = 14   // 4位Rice参数，15保留为转义
+	flacMaxPartitionOr = 8    // 残差分区的最高阶数
+)
+
+// EncodeFLAC 把16位小端PCM编码为FLAC
+// 每个声道独立编码，子帧在常量、原样和0-4阶固定预测器中选择最短的一种，残差使用分区Rice编码
+func EncodeFLAC(w io.Writer, pcm []byte, sampleRate uint32, channels uint16) error {
+	if channels == 0 || channels > 8 {
+		return fmt.Errorf("FLAC不支持 %d 个声道", channels)
+	}
+	frameBytes := int(channels) * 2
+	totalSamples := len(pcm) / frameBytes
+	pcm = pcm[:totalSamples*frameBytes]
+
+	// 先编码所有帧，STREAMINFO需要记录帧长度的范围
+	var frames []byte
+	minFrame, maxFrame := 0, 0
+	samples := make([][]int32, channels)
+	for frame := 0; frame*flacBlockSize < totalSamples; frame++ {
+		start := frame * flacBlockSize
+		n := totalSamples - start
+		if n > flacBlockSize {
+			n = flacBlockSize
+		}
+		for ch := range samples {
+			samples[ch] = samples[ch][:0]
+			for i := 0; i < n; i++ {
+				offset := (start+i)*frameBytes + ch*2
+				samples[ch] = append(samples[ch], int32(int16(binary.LittleEndian.Uint16(pcm[offset:]))))
+			}
+		}
+
+		encoded := encodeFLACFrame(uint64(frame), sampleRate, samples)
+		if minFrame == 0 || len(encoded) < minFrame {
+			minFrame = len(encoded)
+		}
+		if len(encoded) > maxFrame {
+			maxFrame = len(encoded)
+		}
+		frames = append(frames, encoded...)
+	}
+
+	blockSize := flacBlockSize
+	if totalSamples < blockSize {
+		blockSize = totalSamples
+	}
+	if blockSize < 16 {
+		blockSize = 16
+	}
+
+	var info bitWriter
+	info.write(uint64(blockSize), 16)
+	info.write(uint64(blockSize), 16)
+	info.write(uint64(minFrame), 24)
+	info.write(uint64(maxFrame), 24)
+	info.write(uint64(sampleRate), 20)
+	info.write(uint64(channels-1), 3)
+	info.write(15, 5) // 16位
+	info.write(uint64(totalSamples), 36)
+	sum := md5.Sum(pcm)
+
+	header := []byte("fLaC")
+	header = append(header, 0x80, 0, 0, 34) // 最后一个元数据块，类型0(STREAMINFO)，长度34
+	header = append(header, info.bytes()...)
+	header = append(header, sum[:]...)
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(frames)
+	return err
+}
+
+// flacDuration 从STREAMINFO读取总采样数计算时长
+func flacDuration(data []byte) time.Duration {
+	if len(data) < 42 || string(data[:4]) != "fLaC" || data[4]&0x7F != 0 {
+		return 0
+	}
+	info := data[8:42]
+	sampleRate := uint64(info[10])<<12 | uint64(info[11])<<4 | uint64(info[12])>>4
+	total := uint64(info[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(info[14:18]))
+	if sampleRate == 0 {
+		return 0
+	}
+	return time.Duration(total * uint64(time.Second) / sampleRate)
+}
+
+// encodeFLACFrame 编码一帧，帧头使用固定块大小策略，帧号为UTF-8编码
+func encodeFLACFrame(number uint64, sampleRate uint32, samples [][]int32) []byte {
+	n := len(samples[0])
+
+	var b bitWriter
+	b.write(0x3FFE, 14) // 同步码
+	b.write(0, 1)
+	b.write(0, 1)   // 固定块大小
+	b.write(0x7, 4) // 块大小在帧头末尾以16位给出
+	b.write(uint64(flacRateCode(sampleRate)), 4)
+	b.write(uint64(len(samples)-1), 4) // 各声道独立编码
+	b.write(0x4, 3)                    // 16位
+	b.write(0, 1)
+	for _, c := range utf8Number(number) {
+		b.write(uint64(c), 8)
+	}
+	b.write(uint64(n-1), 16)
+	b.write(uint64(crc8(b.bytes())), 8)
+
+	for _, channel := range samples {
+		encodeSubframe(&b, channel)
+	}
+	b.align()
+
+	frame := b.bytes()
+	crc := crc16(frame)
+	return append(frame, byte(crc>>8), byte(crc))
+}
+
+// flacRateCode 常见采样率的帧头编码，其他采样率从STREAMINFO读取
+func flacRateCode(rate uint32) int {
+	switch rate {
+	case 8000:
+		return 4
+	case 16000:
+		return 5
+	case 22050:
+		return 6
+	case 24000:
+		return 7
+	case 32000:
+		return 8
+	case 44100:
+		return 9
+	case 48000:
+		return 10
+	case 96000:
+		return 11
+	}
+	return 0
+}
+
+// encodeSubframe 选择编码后最短的子帧类型
+func encodeSubframe(b *bitWriter, samples []int32) {
+	constant := true
+	for _, s := range samples[1:] {
+		if s != samples[0] {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		b.write(0, 8) // 子帧头: 填充位0，类型000000，无wasted bits
+		b.write(uint64(uint16(samples[0])), 16)
+		return
+	}
+
+	bestOrder, bestBits := -1, len(samples)*16
+	var bestPlan riceCoding
+	residual := make([]int32, len(samples))
+	for order := 0; order <= flacMaxFixedOrder && order < len(samples); order++ {
+		fixedResidual(samples, order, residual)
+		plan := planRice(residual[order:], len(samples), order)
+		bits := order*16 + plan.bits
+		if bits < bestBits {
+			bestOrder, bestBits, bestPlan = order, bits, plan
+		}
+	}
+
+	if bestOrder < 0 {
+		b.write(0x02, 8) // 类型000001: 原样
+		for _, s := range samples {
+			b.write(uint64(uint16(s)), 16)
+		}
+		return
+	}
+
+	b.write(uint64(0x08|bestOrder)<<1, 8) // 类型001xxx: 固定预测器
+	for _, s := range samples[:bestOrder] {
+		b.write(uint64(uint16(s)), 16)
+	}
+	fixedResidual(samples, bestOrder, residual)
+	writeRice(b, residual[bestOrder:], len(samples), bestOrder, bestPlan)
+}
+
+// fixedResidual 计算固定预测器的残差，前order个位置为预热采样不使用
+func fixedResidual(s []int32, order int, out []int32) {
+	for i := order; i < len(s); i++ {
+		switch order {
+		case 0:
+			out[i] = s[i]
+		case 1:
+			out[i] = s[i] - s[i-1]
+		case 2:
+			out[i] = s[i] - 2*s[i-1] + s[i-2]
+		case 3:
+			out[i] = s[i] - 3*s[i-1] + 3*s[i-2] - s[i-3]
+		case 4:
+			out[i] = s[i] - 4*s[i-1] + 6*s[i-2] - 4*s[i-3] + s[i-4]
+		}
+	}
+}
+
+// riceCoding 残差的分区方式和每个分区的Rice参数
+type riceCoding struct {
+	order  int
+	params []int
+	bits   int
+}
+
+// planRice 选择编码长度最短的分区阶数和Rice参数
+// 第一个分区要减去预热采样，因此分区大小必须大于预测阶数
+func planRice(residual []int32, blockSize, predictorOrder int) riceCoding {
+	best := riceCoding{bits: math.MaxInt}
+	for order := 0; order <= flacMaxPartitionOr; order++ {
+		partitions := 1 << order
+		if blockSize%partitions != 0 || blockSize/partitions <= predictorOrder {
+			break
+		}
+
+		plan := riceCoding{order: order, bits: 2 + 4}
+		start := 0
+		for p := 0; p < partitions; p++ {
+			size := blockSize / partitions
+			if p == 0 {
+				size -= predictorOrder
+			}
+			param, bits := bestRiceParam(residual[start : start+size])
+			plan.params = append(plan.params, param)
+			plan.bits += 4 + bits
+			start += size
+		}
+		if plan.bits < best.bits {
+			best = plan
+		}
+	}
+	return best
+}
+
+// bestRiceParam 分区的最佳Rice参数和编码长度
+func bestRiceParam(residual []int32) (int, int) {
+	var sum uint64
+	for _, r := range residual {
+		sum += uint64(zigzag(r))
+	}
+
+	bestParam, bestBits := 0, math.MaxInt
+	for k := 0; k <= flacMaxRiceParam; k++ {
+		var bits uint64
+		for _, r := range residual {
+			bits += uint64(zigzag(r) >> uint(k))
+		}
+		bits += uint64(len(residual)) * uint64(k+1)
+		if int(bits) < bestBits {
+			bestParam, bestBits = k, int(bits)
+		}
+		// 参数再增大只会更长
+		if sum>>uint(k) == 0 {
+			break
+		}
+	}
+	return bestParam, bestBits
+}
+
+// writeRice 按分区写出Rice编码的残差
+func writeRice(b *bitWriter, residual []int32, blockSize, predictorOrder int, plan riceCoding) {
+	b.write(0, 2) // 4位Rice参数
+	b.write(uint64(plan.order), 4)
+	partitions := 1 << plan.order
+	start := 0
+	for p := 0; p < partitions; p++ {
+		size := blockSize / partitions
+		if p == 0 {
+			size -= predictorOrder
+		}
+		k := uint(plan.params[p])
+		b.write(uint64(k), 4)
+		for _, r := range residual[start : start+size] {
+			u := zigzag(r)
+			b.unary(int(u >> k))
+			b.write(uint64(u)&(1<<k-1), k)
+		}
+		start += size
+	}
+}
+
+func zigzag(r int32) uint32 {
+	return uint32(r<<1) ^ uint32(r>>31)
+}
+
+// utf8Number FLAC帧号的类UTF-8编码
+func utf8Number(n uint64) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var out []byte
+	for bytes := 2; bytes <= 7; bytes++ {
+		if n < 1<<(5*bytes+1) {
+			out = make([]byte, bytes)
+			for i := bytes - 1; i > 0; i-- {
+				out[i] = 0x80 | byte(n&0x3F)
+				n >>= 6
+			}
+			out[0] = byte(0xFF<<(8-bytes)) | byte(n)
+			return out
+		}
+	}
+	return out
+}
+
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// bitWriter 按高位在前的顺序写入比特
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (b *bitWriter) write(v uint64, n uint) {
+	for n > 0 {
+		take := n
+		if take > 56-b.nbits {
+			take = 56 - b.nbits
+		}
+		n -= take
+		b.acc = b.acc<<take | (v>>n)&(1<<take-1)
+		b.nbits += take
+		for b.nbits >= 8 {
+			b.nbits -= 8
+			b.buf = append(b.buf, byte(b.acc>>b.nbits))
+		}
+	}
+}
+
+// unary 写出n个0和一个1
+func (b *bitWriter) unary(n int) {
+	for n >= 32 {
+		b.write(0, 32)
+		n -= 32
+	}
+	b.write(1, uint(n+1))
+}
+
+// align 用0补齐到字节边界
+func (b *bitWriter) align() {
+	if b.nbits > 0 {
+		b.write(0, 8-b.nbits)
+	}
+}
+
+// bytes 已写出的完整字节
+func (b *bitWriter) bytes() []byte {
+	return b.buf
+}
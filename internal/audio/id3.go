@@ -0,0 +1,125 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+	"unicode/utf16"
+)
+
+// ctocMaxEntries CTOC帧的条目数只占一个字节
+const ctocMaxEntries = 255
+
+// ID3Chapter ID3v2章节（CHAP帧）
+type ID3Chapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// ID3Tag ID3v2.3标签，章节信息按ID3v2 Chapter Frame Addendum写入CHAP和CTOC帧
+type ID3Tag struct {
+	Title      string
+	Artist     string
+	Album      string
+	Track      int
+	TrackTotal int
+	Chapters   []ID3Chapter
+}
+
+// Bytes 生成完整的标签数据，写在MP3数据之前
+func (t *ID3Tag) Bytes() []byte {
+	var frames bytes.Buffer
+	writeTextFrame(&frames, "TIT2", t.Title)
+	writeTextFrame(&frames, "TPE1", t.Artist)
+	writeTextFrame(&frames, "TALB", t.Album)
+	if t.Track > 0 {
+		track := fmt.Sprintf("%d", t.Track)
+		if t.TrackTotal > 0 {
+			track = fmt.Sprintf("%d/%d", t.Track, t.TrackTotal)
+		}
+		writeTextFrame(&frames, "TRCK", track)
+	}
+
+	if len(t.Chapters) > 0 {
+		// 目录帧放在章节帧之前，方便播放器读取
+		var toc bytes.Buffer
+		toc.WriteString("toc\x00")
+		toc.WriteByte(0x03) // 顶层目录，条目有序
+		entries := len(t.Chapters)
+		if entries > ctocMaxEntries {
+			entries = ctocMaxEntries
+		}
+		toc.WriteByte(byte(entries))
+		for i := 0; i < entries; i++ {
+			toc.WriteString(chapterElementID(i) + "\x00")
+		}
+		writeTextFrame(&toc, "TIT2", t.Album)
+		writeFrame(&frames, "CTOC", toc.Bytes())
+
+		for i, chapter := range t.Chapters {
+			var chap bytes.Buffer
+			chap.WriteString(chapterElementID(i) + "\x00")
+			binary.Write(&chap, binary.BigEndian, uint32(chapter.Start/time.Millisecond))
+			binary.Write(&chap, binary.BigEndian, uint32(chapter.End/time.Millisecond))
+			// 不使用字节偏移
+			binary.Write(&chap, binary.BigEndian, uint32(0xFFFFFFFF))
+			binary.Write(&chap, binary.BigEndian, uint32(0xFFFFFFFF))
+			writeTextFrame(&chap, "TIT2", chapter.Title)
+			writeFrame(&frames, "CHAP", chap.Bytes())
+		}
+	}
+
+	size := frames.Len()
+	header := []byte{'I', 'D', '3', 0x03, 0x00, 0x00,
+		byte(size>>21) & 0x7F, byte(size>>14) & 0x7F, byte(size>>7) & 0x7F, byte(size) & 0x7F}
+	return append(header, frames.Bytes()...)
+}
+
+// StripID3v2 去掉数据开头的ID3v2标签
+func StripID3v2(data []byte) []byte {
+	return skipID3v2(data)
+}
+
+// chapterElementID 章节帧的元素ID
+func chapterElementID(i int) string {
+	return fmt.Sprintf("chp%d", i+1)
+}
+
+// writeTextFrame 写入文本帧，纯ASCII使用ISO-8859-1，其余使用带BOM的UTF-16
+func writeTextFrame(buf *bytes.Buffer, id, text string) {
+	if text == "" {
+		return
+	}
+
+	var data bytes.Buffer
+	if isASCII(text) {
+		data.WriteByte(0x00)
+		data.WriteString(text)
+	} else {
+		data.WriteByte(0x01)
+		data.Write([]byte{0xFF, 0xFE})
+		for _, unit := range utf16.Encode([]rune(text)) {
+			binary.Write(&data, binary.LittleEndian, unit)
+		}
+	}
+	writeFrame(buf, id, data.Bytes())
+}
+
+// writeFrame 写入ID3v2.3帧，帧长度不使用syncsafe编码
+func writeFrame(buf *bytes.Buffer, id string, data []byte) {
+	buf.WriteString(id)
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.Write([]byte{0x00, 0x00})
+	buf.Write(data)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
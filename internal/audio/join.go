@@ -0,0 +1,333 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Duration 计算音频数据时长，无法识别的格式返回0
+func Duration(format string, data []byte) time.Duration {
+	switch format {
+	case "mp3":
+		return mp3Duration(data)
+	case "wav":
+		return wavDuration(data)
+	case "pcm":
+		return pcmDuration(data)
+	case "ogg":
+		return oggDuration(data)
+	case "flac":
+		return flacDuration(data)
+	case "aac":
+		return aacDuration(data)
+	default:
+		return 0
+	}
+}
+
+// FileDuration 计算音频文件时长
+func FileDuration(format, path string) (time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return Duration(format, data), nil
+}
+
+// Joiner 将多段同格式的音频拼接为一个合法的音频文件
+// MP3和PCM按帧直接拼接；Ogg重写序列号、页序号和granule position，去掉后续各段开头的pre-skip；
+// WAV在第一段写入头部，之后直接输出PCM数据，w可以Seek时在Close时回写实际长度，否则头部使用未知长度
+type Joiner struct {
+	format string
+	w      io.Writer
+	parts  int
+
+	// WAV
+	wavFmt     wavFormat
+	wavStarted bool
+	wavSize    int64          // 已输出的PCM字节数
+	wavSeeker  io.WriteSeeker // 为nil时w不能Seek，头部使用未知长度
+	wavStart   int64          // 头部在w中的位置
+
+	// Ogg
+	oggSerial  uint32
+	oggSeq     uint32
+	oggOffset  uint64
+	oggPending *oggPage
+}
+
+// JoinFormats 支持拼接的音频格式
+var JoinFormats = []string{"mp3", "wav", "pcm", "ogg"}
+
+// CanJoin 判断格式是否支持拼接
+func CanJoin(format string) bool {
+	for _, f := range JoinFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// NewJoiner 创建音频拼接器
+func NewJoiner(format string, w io.Writer) (*Joiner, error) {
+	if !CanJoin(format) {
+		return nil, fmt.Errorf("不支持拼接的音频格式: %s", format)
+	}
+	return &Joiner{format: format, w: w}, nil
+}
+
+// Append 追加一段完整的音频，返回该段在拼接结果中占用的时长
+func (j *Joiner) Append(part []byte) (time.Duration, error) {
+	defer func() { j.parts++ }()
+
+	switch j.format {
+	case "mp3":
+		if j.parts > 0 {
+			part = skipID3v2(part)
+		}
+		_, err := j.w.Write(part)
+		return mp3Duration(part), err
+	case "pcm":
+		_, err := j.w.Write(part)
+		return pcmDuration(part), err
+	case "wav":
+		return j.appendWAV(part)
+	default:
+		return j.appendOgg(part)
+	}
+}
+
+// AppendSilence 追加一段静音
+func (j *Joiner) AppendSilence(d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	switch j.format {
+	case "mp3":
+		_, err := j.w.Write(MP3SilenceFrames(d))
+		return err
+	case "pcm":
+		_, err := j.w.Write(make([]byte, PCMBytes(d)))
+		return err
+	case "wav":
+		if !j.wavStarted {
+			if err := j.startWAV(wavFormat{channels: Channels, sampleRate: SampleRate, bitsPerSample: BitsPerSample}); err != nil {
+				return err
+			}
+		}
+		format := j.wavFmt
+		samples := int(d * time.Duration(format.sampleRate) / time.Second)
+		return j.writeWAV(make([]byte, samples*int(format.channels)*int(format.bitsPerSample)/8))
+	default:
+		return j.appendOggSilence(d)
+	}
+}
+
+// Close 输出剩余数据，完成拼接
+func (j *Joiner) Close() error {
+	switch j.format {
+	case "wav":
+		if !j.wavStarted {
+			return fmt.Errorf("没有可拼接的音频")
+		}
+		return j.finishWAV()
+	case "ogg":
+		if j.oggPending == nil {
+			return nil
+		}
+		j.oggPending.setFlags(j.oggPending.headerType()&oggBOS | oggEOS)
+		return j.flushOggPending()
+	default:
+		return nil
+	}
+}
+
+// appendWAV 输出一段的PCM数据，各段格式必须一致
+func (j *Joiner) appendWAV(part []byte) (time.Duration, error) {
+	format, pcm, err := parseWAV(part)
+	if err != nil {
+		return 0, err
+	}
+	if !j.wavStarted {
+		if err := j.startWAV(format); err != nil {
+			return 0, err
+		}
+	} else if format != j.wavFmt {
+		return 0, fmt.Errorf("WAV格式不一致，无法拼接")
+	}
+	if err := j.writeWAV(pcm); err != nil {
+		return 0, err
+	}
+	if format.byteRate() == 0 {
+		return 0, nil
+	}
+	return time.Duration(len(pcm)) * time.Second / time.Duration(format.byteRate()), nil
+}
+
+// startWAV 输出WAV头，w可以Seek时先写入长度为0的头部，Close时回写
+func (j *Joiner) startWAV(format wavFormat) error {
+	j.wavFmt = format
+	j.wavStarted = true
+
+	header := wavStreamHeader(format.sampleRate, format.channels, format.bitsPerSample)
+	if seeker, ok := j.w.(io.WriteSeeker); ok {
+		// 管道等实现了Seek但不支持定位的输出同样按流式处理
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			j.wavSeeker = seeker
+			j.wavStart = start
+			header = WAVHeader(0, format.sampleRate, format.channels, format.bitsPerSample)
+		}
+	}
+	_, err := j.w.Write(header)
+	return err
+}
+
+// writeWAV 输出PCM数据
+func (j *Joiner) writeWAV(pcm []byte) error {
+	n, err := j.w.Write(pcm)
+	j.wavSize += int64(n)
+	return err
+}
+
+// finishWAV 回写头部中的RIFF和data块长度，超过4GB或w不能Seek时保留未知长度
+func (j *Joiner) finishWAV() error {
+	if j.wavSeeker == nil || j.wavSize > wavUnknownSize-36 {
+		return nil
+	}
+
+	header := WAVHeader(uint32(j.wavSize), j.wavFmt.sampleRate, j.wavFmt.channels, j.wavFmt.bitsPerSample)
+	for _, field := range []int64{4, 40} {
+		if _, err := j.wavSeeker.Seek(j.wavStart+field, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := j.wavSeeker.Write(header[field : field+4]); err != nil {
+			return err
+		}
+	}
+	_, err := j.wavSeeker.Seek(j.wavStart+int64(len(header))+j.wavSize, io.SeekStart)
+	return err
+}
+
+// appendOgg 追加一段Ogg Opus流
+// 第一段保留OpusHead/OpusTags头页，之后各段跳过头页，统一使用第一段的序列号并重新编号
+// 解码器只在流的开头丢弃pre-skip，之后各段开头pre-skip范围内的完整包直接去掉，避免段之间出现空隙
+func (j *Joiner) appendOgg(part []byte) (time.Duration, error) {
+	pages, err := parseOggPages(part)
+	if err != nil {
+		return 0, err
+	}
+	if len(pages) == 0 {
+		return 0, nil
+	}
+
+	first := j.oggSeq == 0 && j.oggPending == nil
+	if first {
+		j.oggSerial = pages[0].serial()
+	}
+	preSkip := opusPreSkip(pages)
+
+	// 开头granule为0的页是OpusHead/OpusTags头页
+	headers := 0
+	for headers < len(pages) && (pages[headers].headerType()&oggBOS != 0 || pages[headers].granule() == 0) {
+		headers++
+	}
+
+	var trimmed uint64
+	if !first {
+		var audio []oggPage
+		audio, trimmed = trimOpusPackets(pages[headers:], preSkip)
+		pages = append(pages[:headers:headers], audio...)
+	}
+
+	var last uint64
+	for i, page := range pages {
+		if i < headers && !first {
+			continue
+		}
+
+		if granule := page.granule(); granule != oggNoGranule && i >= headers && granule >= trimmed {
+			last = granule - trimmed
+			page.setGranule(last + j.oggOffset)
+		}
+		flags := byte(0)
+		if first && i == 0 {
+			flags = oggBOS
+		}
+		page.setFlags(flags)
+
+		if err := j.pushOggPage(page); err != nil {
+			return 0, err
+		}
+	}
+
+	j.oggOffset += last
+	if first && last > preSkip {
+		last -= preSkip
+	}
+	return time.Duration(last) * time.Second / opusRate, nil
+}
+
+// appendOggSilence 追加Opus静音包，每个包20ms
+func (j *Joiner) appendOggSilence(d time.Duration) error {
+	if j.oggPending == nil && j.oggSeq == 0 {
+		return fmt.Errorf("Ogg静音不能出现在第一段之前")
+	}
+
+	// TOC 0xF8: CELT全频带20ms单帧，0xFF 0xFE 解码为静音
+	packet := []byte{0xF8, 0xFF, 0xFE}
+	const samplesPerPacket = opusRate / 50
+
+	packets := int((d + 20*time.Millisecond - 1) / (20 * time.Millisecond))
+	for packets > 0 {
+		n := packets
+		if n > 255 {
+			n = 255
+		}
+		raw := make([]byte, oggHeaderSize+n, oggHeaderSize+n+n*len(packet))
+		copy(raw, "OggS")
+		raw[26] = byte(n)
+		for i := 0; i < n; i++ {
+			raw[oggHeaderSize+i] = byte(len(packet))
+			raw = append(raw, packet...)
+		}
+		j.oggOffset += uint64(n * samplesPerPacket)
+
+		page := oggPage{raw: raw}
+		page.setGranule(j.oggOffset)
+		if err := j.pushOggPage(page); err != nil {
+			return err
+		}
+		packets -= n
+	}
+	return nil
+}
+
+// pushOggPage 暂存一页，先输出上一页，保证最后一页可以在Close时打上EOS标志
+func (j *Joiner) pushOggPage(page oggPage) error {
+	if err := j.flushOggPending(); err != nil {
+		return err
+	}
+	j.oggPending = &page
+	return nil
+}
+
+// flushOggPending 输出暂存页
+func (j *Joiner) flushOggPending() error {
+	if j.oggPending == nil {
+		return nil
+	}
+	page := j.oggPending
+	j.oggPending = nil
+
+	page.setSerial(j.oggSerial)
+	page.setSequence(j.oggSeq)
+	j.oggSeq++
+	page.updateCRC()
+
+	_, err := j.w.Write(page.raw)
+	return err
+}
@@ -0,0 +1,300 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testWAV 生成pcmLen字节静音PCM的WAV数据
+func testWAV(pcmLen int) []byte {
+	return append(WAVHeader(uint32(pcmLen), SampleRate, Channels, BitsPerSample), make([]byte, pcmLen)...)
+}
+
+// wavSizes 读取WAV头中的RIFF和data块长度
+func wavSizes(data []byte) (riff, dataLen uint32) {
+	return binary.LittleEndian.Uint32(data[4:8]), binary.LittleEndian.Uint32(data[40:44])
+}
+
+func TestJoinWAVHeaderSizes(t *testing.T) {
+	const pcmLen = 4800 // 100ms
+	silence := PCMBytes(50 * time.Millisecond)
+	wantData := uint32(2*pcmLen + silence)
+
+	join := func(t *testing.T, j *Joiner) {
+		t.Helper()
+		if _, err := j.Append(testWAV(pcmLen)); err != nil {
+			t.Fatalf("拼接第一段失败: %v", err)
+		}
+		if err := j.AppendSilence(50 * time.Millisecond); err != nil {
+			t.Fatalf("插入静音失败: %v", err)
+		}
+		if _, err := j.Append(testWAV(pcmLen)); err != nil {
+			t.Fatalf("拼接第二段失败: %v", err)
+		}
+		if err := j.Close(); err != nil {
+			t.Fatalf("结束拼接失败: %v", err)
+		}
+	}
+
+	t.Run("seekable", func(t *testing.T) {
+		f, err := os.Create(filepath.Join(t.TempDir(), "joined.wav"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		// 头部不在文件开头时同样回写到正确的位置
+		f.WriteString("pad!")
+
+		j, err := NewJoiner("wav", f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		join(t, j)
+		f.WriteString("tail")
+
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = data[4 : len(data)-4]
+		riff, dataLen := wavSizes(data)
+		if riff != 36+wantData || dataLen != wantData || len(data) != 44+int(wantData) {
+			t.Fatalf("RIFF=%d data=%d 文件长度=%d, 期望 RIFF=%d data=%d", riff, dataLen, len(data), 36+wantData, wantData)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var buf bytes.Buffer
+		j, err := NewJoiner("wav", &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		join(t, j)
+
+		data := buf.Bytes()
+		if riff, dataLen := wavSizes(data); riff != wavUnknownSize || dataLen != wavUnknownSize {
+			t.Fatalf("流式输出的RIFF=%#x data=%#x, 期望未知长度", riff, dataLen)
+		}
+		if got := wavDuration(data); got != 250*time.Millisecond {
+			t.Fatalf("时长 %v, 期望 250ms", got)
+		}
+
+		// 保存到缓存前按实际长度修正
+		path := filepath.Join(t.TempDir(), "cached.wav")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := FixWAVHeader(f, int64(len(data))); err != nil {
+			t.Fatalf("修正WAV头失败: %v", err)
+		}
+		fixed, _ := os.ReadFile(path)
+		if riff, dataLen := wavSizes(fixed); riff != 36+wantData || dataLen != wantData {
+			t.Fatalf("修正后RIFF=%d data=%d, 期望 RIFF=%d data=%d", riff, dataLen, 36+wantData, wantData)
+		}
+	})
+}
+
+func TestJoinMP3(t *testing.T) {
+	frames := MP3SilenceFrames(100 * time.Millisecond)
+	tag := (&ID3Tag{Title: "测试"}).Bytes()
+	gap := MP3SilenceFrames(50 * time.Millisecond)
+
+	tests := []struct {
+		name          string
+		first, second []byte
+	}{
+		{name: "plain", first: frames, second: frames},
+		{name: "id3 on both", first: append(tag, frames...), second: append(tag, frames...)},
+		{name: "id3 on second", first: frames, second: append(tag, frames...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			j, err := NewJoiner("mp3", &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, part := range [][]byte{tt.first, tt.second} {
+				if i > 0 {
+					if err := j.AppendSilence(50 * time.Millisecond); err != nil {
+						t.Fatal(err)
+					}
+				}
+				d, err := j.Append(part)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := mp3Duration(frames); d != want {
+					t.Fatalf("第%d段时长 %v, 期望 %v", i+1, d, want)
+				}
+			}
+			if err := j.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// 只保留第一段的ID3标签，后面的段只拼接音频帧
+			want := append(append(append([]byte{}, tt.first...), gap...), frames...)
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Fatalf("拼接结果 %d 字节, 期望 %d 字节", buf.Len(), len(want))
+			}
+			if got, want := mp3Duration(buf.Bytes()), 2*mp3Duration(frames)+mp3Duration(gap); got != want {
+				t.Fatalf("总时长 %v, 期望 %v", got, want)
+			}
+		})
+	}
+}
+
+// testOggPage 生成一个Ogg页，每个包不超过255字节
+func testOggPage(flags byte, granule uint64, serial, seq uint32, packets ...[]byte) []byte {
+	raw := make([]byte, oggHeaderSize, oggHeaderSize+len(packets))
+	copy(raw, "OggS")
+	raw[26] = byte(len(packets))
+	for _, packet := range packets {
+		raw = append(raw, byte(len(packet)))
+	}
+	for _, packet := range packets {
+		raw = append(raw, packet...)
+	}
+	page := oggPage{raw: raw}
+	raw[5] = flags
+	page.setGranule(granule)
+	page.setSerial(serial)
+	page.setSequence(seq)
+	page.updateCRC()
+	return raw
+}
+
+// testOpus 生成包含OpusHead、OpusTags和n个20ms静音包的Ogg Opus流，音频分两页
+func testOpus(serial uint32, preSkip uint16, n int) []byte {
+	head := []byte("OpusHead\x01\x01\x00\x00\x80\xbb\x00\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint16(head[10:12], preSkip)
+	tags := []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00")
+
+	packet := []byte{0xF8, 0xFF, 0xFE}
+	var first, second [][]byte
+	for i := 0; i < n; i++ {
+		if i < n/2 {
+			first = append(first, packet)
+		} else {
+			second = append(second, packet)
+		}
+	}
+
+	var data []byte
+	data = append(data, testOggPage(oggBOS, 0, serial, 0, head)...)
+	data = append(data, testOggPage(0, 0, serial, 1, tags)...)
+	data = append(data, testOggPage(0, uint64(len(first)*960), serial, 2, first...)...)
+	data = append(data, testOggPage(oggEOS, uint64(n*960), serial, 3, second...)...)
+	return data
+}
+
+func TestJoinOgg(t *testing.T) {
+	const packets = 10 // 每段200ms
+
+	tests := []struct {
+		name    string
+		preSkip uint16
+		trimmed int // 第二段开头去掉的包数
+	}{
+		{name: "no pre-skip", preSkip: 0, trimmed: 0},
+		{name: "pre-skip shorter than a packet", preSkip: 312, trimmed: 0},
+		{name: "pre-skip of two packets", preSkip: 1920, trimmed: 2},
+		{name: "pre-skip between packets", preSkip: 2500, trimmed: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			j, err := NewJoiner("ogg", &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d1, err := j.Append(testOpus(1, tt.preSkip, packets))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := j.AppendSilence(40 * time.Millisecond); err != nil {
+				t.Fatal(err)
+			}
+			d2, err := j.Append(testOpus(2, tt.preSkip, packets))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := j.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			samples := func(n int) time.Duration { return time.Duration(n) * time.Second / opusRate }
+			if want := samples(packets*960 - int(tt.preSkip)); d1 != want {
+				t.Errorf("第一段时长 %v, 期望 %v", d1, want)
+			}
+			if want := samples((packets - tt.trimmed) * 960); d2 != want {
+				t.Errorf("第二段时长 %v, 期望 %v", d2, want)
+			}
+
+			pages, err := parseOggPages(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			audioPackets, heads := 0, 0
+			var granule uint64
+			for i, page := range pages {
+				if page.serial() != 1 {
+					t.Errorf("第%d页序列号 %d, 期望 1", i, page.serial())
+				}
+				if seq := binary.LittleEndian.Uint32(page.raw[18:22]); seq != uint32(i) {
+					t.Errorf("第%d页序号 %d", i, seq)
+				}
+				wantFlags := byte(0)
+				if i == 0 {
+					wantFlags = oggBOS
+				}
+				if i == len(pages)-1 {
+					wantFlags |= oggEOS
+				}
+				if page.headerType() != wantFlags {
+					t.Errorf("第%d页标志 %#x, 期望 %#x", i, page.headerType(), wantFlags)
+				}
+
+				check := oggPage{raw: append([]byte{}, page.raw...)}
+				check.updateCRC()
+				if !bytes.Equal(check.raw[22:26], page.raw[22:26]) {
+					t.Errorf("第%d页校验和错误", i)
+				}
+
+				if g := page.granule(); g != 0 {
+					if g < granule {
+						t.Errorf("第%d页granule %d 小于前一页 %d", i, g, granule)
+					}
+					granule = g
+					audioPackets += int(page.raw[26])
+				} else if bytes.HasPrefix(page.payload(), []byte("OpusHead")) {
+					heads++
+				}
+			}
+
+			if heads != 1 {
+				t.Errorf("OpusHead出现 %d 次, 期望 1 次", heads)
+			}
+			if want := 2*packets - tt.trimmed + 2; audioPackets != want {
+				t.Errorf("音频包 %d 个, 期望 %d 个", audioPackets, want)
+			}
+			if want := uint64((2*packets - tt.trimmed + 2) * 960); granule != want {
+				t.Errorf("最后的granule %d, 期望 %d", granule, want)
+			}
+			if got, want := oggDuration(buf.Bytes()), d1+40*time.Millisecond+d2; got != want {
+				t.Errorf("总时长 %v, 期望 %v", got, want)
+			}
+		})
+	}
+}
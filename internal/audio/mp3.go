@@ -0,0 +1,90 @@
+package audio
+
+import "time"
+
+var (
+	mp3BitratesV1  = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitratesV2  = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+	mp3SampleRates = map[int][3]int{
+		3: {44100, 48000, 32000}, // MPEG-1
+		2: {22050, 24000, 16000}, // MPEG-2
+		0: {11025, 12000, 8000},  // MPEG-2.5
+	}
+)
+
+// mp3Frame MPEG Layer III 帧信息
+type mp3Frame struct {
+	length     int
+	samples    int
+	sampleRate int
+}
+
+// parseMP3Frame 解析帧头，不是合法的Layer III帧时返回false
+func parseMP3Frame(header []byte) (mp3Frame, bool) {
+	if len(header) < 4 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+
+	version := int(header[1]>>3) & 0x03
+	layer := int(header[1]>>1) & 0x03
+	bitrateIdx := int(header[2] >> 4)
+	rateIdx := int(header[2]>>2) & 0x03
+	padding := int(header[2]>>1) & 0x01
+
+	rates, ok := mp3SampleRates[version]
+	if !ok || layer != 1 || rateIdx == 3 {
+		return mp3Frame{}, false
+	}
+	sampleRate := rates[rateIdx]
+
+	frame := mp3Frame{sampleRate: sampleRate}
+	if version == 3 {
+		bitrate := mp3BitratesV1[bitrateIdx] * 1000
+		frame.length = 144*bitrate/sampleRate + padding
+		frame.samples = 1152
+	} else {
+		bitrate := mp3BitratesV2[bitrateIdx] * 1000
+		frame.length = 72*bitrate/sampleRate + padding
+		frame.samples = 576
+	}
+	if frame.length <= 4 {
+		return mp3Frame{}, false
+	}
+	return frame, true
+}
+
+// skipID3v2 跳过开头的ID3v2标签
+func skipID3v2(data []byte) []byte {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return data
+	}
+	size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+	if data[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	if 10+size > len(data) {
+		return nil
+	}
+	return data[10+size:]
+}
+
+// mp3Duration 逐帧累加计算MP3时长
+func mp3Duration(data []byte) time.Duration {
+	data = skipID3v2(data)
+
+	var total time.Duration
+	for len(data) >= 4 {
+		frame, ok := parseMP3Frame(data)
+		if !ok {
+			// 跳过无法识别的字节，继续寻找帧同步
+			data = data[1:]
+			continue
+		}
+		total += time.Duration(frame.samples) * time.Second / time.Duration(frame.sampleRate)
+		if frame.length > len(data) {
+			break
+		}
+		data = data[frame.length:]
+	}
+	return total
+}
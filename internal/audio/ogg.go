@@ -0,0 +1,209 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Ogg页头标志
+const (
+	oggContinued = 0x01
+	oggBOS       = 0x02
+	oggEOS       = 0x04
+
+	oggHeaderSize = 27
+	opusRate      = 48000 // Opus的granule position固定以48kHz计
+)
+
+// oggNoGranule 页中没有结束的包时granule position为-1
+const oggNoGranule = ^uint64(0)
+
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04C11DB7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// oggPage Ogg页，raw包含完整的页头和数据
+type oggPage struct {
+	raw []byte
+}
+
+func (p oggPage) headerType() byte    { return p.raw[5] }
+func (p oggPage) granule() uint64     { return binary.LittleEndian.Uint64(p.raw[6:14]) }
+func (p oggPage) setGranule(g uint64) { binary.LittleEndian.PutUint64(p.raw[6:14], g) }
+func (p oggPage) serial() uint32      { return binary.LittleEndian.Uint32(p.raw[14:18]) }
+func (p oggPage) setSerial(s uint32)  { binary.LittleEndian.PutUint32(p.raw[14:18], s) }
+func (p oggPage) setSequence(n uint32) {
+	binary.LittleEndian.PutUint32(p.raw[18:22], n)
+}
+
+// setFlags 设置页头标志
+func (p oggPage) setFlags(flags byte) {
+	p.raw[5] = p.raw[5]&oggContinued | flags
+}
+
+// payload 页数据部分
+func (p oggPage) payload() []byte {
+	segments := int(p.raw[26])
+	return p.raw[oggHeaderSize+segments:]
+}
+
+// updateCRC 重新计算页校验和
+func (p oggPage) updateCRC() {
+	binary.LittleEndian.PutUint32(p.raw[22:26], 0)
+	var crc uint32
+	for _, b := range p.raw {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	binary.LittleEndian.PutUint32(p.raw[22:26], crc)
+}
+
+// parseOggPages 将数据拆分为Ogg页
+func parseOggPages(data []byte) ([]oggPage, error) {
+	var pages []oggPage
+	for len(data) > 0 {
+		if len(data) < oggHeaderSize || string(data[:4]) != "OggS" {
+			return nil, fmt.Errorf("无效的Ogg页")
+		}
+		segments := int(data[26])
+		if len(data) < oggHeaderSize+segments {
+			return nil, fmt.Errorf("Ogg页头不完整")
+		}
+		size := oggHeaderSize + segments
+		for _, lacing := range data[oggHeaderSize : oggHeaderSize+segments] {
+			size += int(lacing)
+		}
+		if len(data) < size {
+			return nil, fmt.Errorf("Ogg页数据不完整")
+		}
+
+		raw := make([]byte, size)
+		copy(raw, data[:size])
+		pages = append(pages, oggPage{raw: raw})
+		data = data[size:]
+	}
+	return pages, nil
+}
+
+// opusPreSkip 从OpusHead页中读取pre-skip采样数
+func opusPreSkip(pages []oggPage) uint64 {
+	for _, page := range pages {
+		payload := page.payload()
+		if len(payload) >= 12 && string(payload[:8]) == "OpusHead" {
+			return uint64(binary.LittleEndian.Uint16(payload[10:12]))
+		}
+	}
+	return 0
+}
+
+// opusPacketSamples 按TOC字节计算Opus包的采样数(48kHz)，无法识别时返回0
+func opusPacketSamples(packet []byte) int {
+	if len(packet) == 0 {
+		return 0
+	}
+	toc := packet[0]
+	config := toc >> 3
+
+	var frame int
+	switch {
+	case config < 12: // SILK 10/20/40/60ms
+		frame = []int{480, 960, 1920, 2880}[config&3]
+	case config < 16: // Hybrid 10/20ms
+		frame = []int{480, 960}[config&1]
+	default: // CELT 2.5/5/10/20ms
+		frame = []int{120, 240, 480, 960}[config&3]
+	}
+
+	switch toc & 3 {
+	case 0:
+		return frame
+	case 1, 2:
+		return 2 * frame
+	default:
+		if len(packet) < 2 {
+			return 0
+		}
+		return int(packet[1]&0x3F) * frame
+	}
+}
+
+// trimOpusPackets 从音频页开头去掉采样数合计不超过samples的完整Opus包，返回剩余的页和去掉的采样数
+// 包跨页时停止，不足一个包的部分无法去掉
+func trimOpusPackets(pages []oggPage, samples uint64) ([]oggPage, uint64) {
+	var trimmed uint64
+	for len(pages) > 0 && pages[0].headerType()&oggContinued == 0 {
+		page := pages[0]
+		segments := int(page.raw[26])
+		lacing := page.raw[oggHeaderSize : oggHeaderSize+segments]
+		payload := page.payload()
+
+		// 逐个去掉页开头的完整包
+		seg, offset := 0, 0
+		for seg < segments {
+			end, size := seg, 0
+			for end < segments {
+				size += int(lacing[end])
+				end++
+				if lacing[end-1] < 255 {
+					break
+				}
+			}
+			if lacing[end-1] == 255 {
+				// 包在下一页继续
+				break
+			}
+			n := uint64(opusPacketSamples(payload[offset : offset+size]))
+			if n == 0 || trimmed+n > samples {
+				break
+			}
+			trimmed += n
+			seg, offset = end, offset+size
+		}
+
+		if seg == segments {
+			pages = pages[1:]
+			continue
+		}
+		if seg > 0 {
+			raw := make([]byte, 0, len(page.raw)-seg-offset)
+			raw = append(raw, page.raw[:oggHeaderSize]...)
+			raw[26] = byte(segments - seg)
+			raw = append(raw, lacing[seg:]...)
+			raw = append(raw, payload[offset:]...)
+			pages[0] = oggPage{raw: raw}
+		}
+		break
+	}
+	return pages, trimmed
+}
+
+// oggDuration 计算Ogg Opus时长
+func oggDuration(data []byte) time.Duration {
+	pages, err := parseOggPages(data)
+	if err != nil {
+		return 0
+	}
+
+	var last uint64
+	for _, page := range pages {
+		if g := page.granule(); g != oggNoGranule {
+			last = g
+		}
+	}
+	if preSkip := opusPreSkip(pages); last > preSkip {
+		last -= preSkip
+	}
+	return time.Duration(last) * time.Second / opusRate
+}
@@ -0,0 +1,246 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// aacFrameSamples AAC-LC每帧采样数
+const aacFrameSamples = 1024
+
+// ffmpegPath 查找ffmpeg的结果，AAC编码依赖ffmpeg
+var ffmpegPath = sync.OnceValue(func() string {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return ""
+	}
+	return path
+})
+
+// CanTranscode 判断格式能否由WAV转码得到
+// pcm去掉WAV头，flac使用内置编码器，aac需要系统中安装ffmpeg
+func CanTranscode(format string) bool {
+	switch format {
+	case "pcm", "flac":
+		return true
+	case "aac":
+		return ffmpegPath() != ""
+	default:
+		return false
+	}
+}
+
+// Transcoder 把写入的WAV数据转码为目标格式后写入w
+// pcm和aac边收边输出；flac的STREAMINFO需要总采样数和MD5，在Close时统一输出
+type Transcoder struct {
+	format string
+	out    *countingWriter
+
+	header  []byte // 尚未找到data块时缓存的WAV头
+	wavFmt  wavFormat
+	started bool
+
+	// FLAC
+	pcm []byte
+
+	// AAC
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+}
+
+// NewTranscoder 创建转码器
+func NewTranscoder(format string, w io.Writer) (*Transcoder, error) {
+	if !CanTranscode(format) {
+		return nil, fmt.Errorf("不支持转码的音频格式: %s", format)
+	}
+	return &Transcoder{format: format, out: &countingWriter{w: w}}, nil
+}
+
+// Write 写入WAV数据，头部可以分多次写入
+func (t *Transcoder) Write(p []byte) (int, error) {
+	n := len(p)
+	if !t.started {
+		t.header = append(t.header, p...)
+		format, offset, ok, err := wavDataOffset(t.header)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return n, nil
+		}
+		if format.bitsPerSample != BitsPerSample {
+			return 0, fmt.Errorf("只支持转码16位PCM，实际为 %d 位", format.bitsPerSample)
+		}
+		t.wavFmt = format
+		t.started = true
+		if err := t.start(); err != nil {
+			return 0, err
+		}
+		p = t.header[offset:]
+		t.header = nil
+	}
+
+	if err := t.writePCM(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// start 开始输出，aac启动ffmpeg进程
+func (t *Transcoder) start() error {
+	if t.format != "aac" {
+		return nil
+	}
+
+	t.cmd = exec.Command(ffmpegPath(), "-hide_banner", "-loglevel", "error",
+		"-f", "s16le", "-ar", strconv.Itoa(int(t.wavFmt.sampleRate)), "-ac", strconv.Itoa(int(t.wavFmt.channels)),
+		"-i", "pipe:0", "-c:a", "aac", "-b:a", "64k", "-f", "adts", "pipe:1")
+	t.cmd.Stdout = t.out
+	t.cmd.Stderr = &t.stderr
+
+	stdin, err := t.cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("启动ffmpeg失败: %w", err)
+	}
+	if err := t.cmd.Start(); err != nil {
+		return fmt.Errorf("启动ffmpeg失败: %w", err)
+	}
+	t.stdin = stdin
+	return nil
+}
+
+func (t *Transcoder) writePCM(p []byte) error {
+	if len(p) == 0 {
+		return nil
+	}
+	switch t.format {
+	case "pcm":
+		_, err := t.out.Write(p)
+		return err
+	case "flac":
+		t.pcm = append(t.pcm, p...)
+		return nil
+	default:
+		if _, err := t.stdin.Write(p); err != nil {
+			return fmt.Errorf("AAC编码失败: %w", err)
+		}
+		return nil
+	}
+}
+
+// Close 输出剩余数据，完成转码
+func (t *Transcoder) Close() error {
+	if !t.started {
+		return fmt.Errorf("WAV缺少data块")
+	}
+
+	switch t.format {
+	case "flac":
+		return EncodeFLAC(t.out, t.pcm, t.wavFmt.sampleRate, t.wavFmt.channels)
+	case "aac":
+		t.stdin.Close()
+		if err := t.cmd.Wait(); err != nil {
+			return fmt.Errorf("AAC编码失败: %w: %s", err, bytes.TrimSpace(t.stderr.Bytes()))
+		}
+	}
+	return nil
+}
+
+// Abort 合成失败时放弃转码，结束ffmpeg进程
+func (t *Transcoder) Abort() {
+	if t.cmd != nil && t.cmd.Process != nil {
+		t.stdin.Close()
+		t.cmd.Process.Kill()
+		t.cmd.Wait()
+	}
+	t.pcm = nil
+}
+
+// Size 已输出的字节数
+func (t *Transcoder) Size() int64 {
+	return t.out.n
+}
+
+// countingWriter 记录写入的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// wavDataOffset 在WAV头中查找data块，返回PCM数据的起始位置
+// 数据不足时ok为false；流式WAV的data长度不可靠，data块之后的内容都视为PCM
+func wavDataOffset(data []byte) (format wavFormat, offset int, ok bool, err error) {
+	if len(data) < 12 {
+		return format, 0, false, nil
+	}
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return format, 0, false, fmt.Errorf("不是有效的WAV数据")
+	}
+
+	hasFormat := false
+	pos := 12
+	for pos+8 <= len(data) {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8:]
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return format, 0, false, nil
+			}
+			format.channels = binary.LittleEndian.Uint16(body[2:4])
+			format.sampleRate = binary.LittleEndian.Uint32(body[4:8])
+			format.bitsPerSample = binary.LittleEndian.Uint16(body[14:16])
+			hasFormat = true
+		case "data":
+			if !hasFormat {
+				return format, 0, false, fmt.Errorf("WAV缺少fmt块")
+			}
+			return format, pos + 8, true, nil
+		}
+
+		pos += 8 + size + size%2
+	}
+	return format, 0, false, nil
+}
+
+// aacDuration 按ADTS帧数计算AAC时长
+func aacDuration(data []byte) time.Duration {
+	rates := []int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+	var samples, sampleRate int
+	for pos := 0; pos+7 <= len(data); {
+		if data[pos] != 0xFF || data[pos+1]&0xF6 != 0xF0 {
+			break
+		}
+		index := int(data[pos+2]>>2) & 0x0F
+		if index >= len(rates) {
+			break
+		}
+		sampleRate = rates[index]
+		length := int(data[pos+3]&0x03)<<11 | int(data[pos+4])<<3 | int(data[pos+5])>>5
+		if length < 7 {
+			break
+		}
+		samples += (int(data[pos+6]&0x03) + 1) * aacFrameSamples
+		pos += length
+	}
+	if sampleRate == 0 {
+		return 0
+	}
+	return time.Duration(samples) * time.Second / time.Duration(sampleRate)
+}
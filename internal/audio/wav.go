@@ -0,0 +1,101 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// wavFormat WAV fmt块中的关键信息
+type wavFormat struct {
+	channels      uint16
+	sampleRate    uint32
+	bitsPerSample uint16
+}
+
+func (f wavFormat) byteRate() uint32 {
+	return f.sampleRate * uint32(f.channels) * uint32(f.bitsPerSample) / 8
+}
+
+// parseWAV 解析RIFF/WAVE数据，返回格式信息和PCM数据
+func parseWAV(data []byte) (wavFormat, []byte, error) {
+	var format wavFormat
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return format, nil, fmt.Errorf("不是有效的WAV数据")
+	}
+
+	hasFormat := false
+	pos := 12
+	for pos+8 <= len(data) {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8:]
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return format, nil, fmt.Errorf("WAV fmt块不完整")
+			}
+			format.channels = binary.LittleEndian.Uint16(body[2:4])
+			format.sampleRate = binary.LittleEndian.Uint32(body[4:8])
+			format.bitsPerSample = binary.LittleEndian.Uint16(body[14:16])
+			hasFormat = true
+		case "data":
+			if !hasFormat {
+				return format, nil, fmt.Errorf("WAV缺少fmt块")
+			}
+			// 流式生成的WAV中data长度可能为占位值，以实际数据为准
+			if size > len(body) || size == 0 {
+				size = len(body)
+			}
+			return format, body[:size], nil
+		}
+
+		pos += 8 + size + size%2
+	}
+
+	return format, nil, fmt.Errorf("WAV缺少data块")
+}
+
+// FixWAVHeader 按文件的实际长度回写44字节标准头中的RIFF和data块长度
+// 流式写入(如分段拼接后同时输出给客户端)的WAV头中长度未知，保存到缓存前修正；不是标准头或超过4GB时不处理
+func FixWAVHeader(f interface {
+	io.ReaderAt
+	io.WriterAt
+}, size int64) error {
+	header := make([]byte, 44)
+	if size < int64(len(header)) {
+		return nil
+	}
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" || string(header[36:40]) != "data" {
+		return nil
+	}
+
+	dataLen := size - int64(len(header))
+	if dataLen > wavUnknownSize-36 {
+		return nil
+	}
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+dataLen))
+	binary.LittleEndian.PutUint32(header[40:44], uint32(dataLen))
+	_, err := f.WriteAt(header[4:44], 4)
+	return err
+}
+
+// wavDuration 计算WAV时长
+func wavDuration(data []byte) time.Duration {
+	format, pcm, err := parseWAV(data)
+	if err != nil || format.byteRate() == 0 {
+		return 0
+	}
+	return time.Duration(len(pcm)) * time.Second / time.Duration(format.byteRate())
+}
+
+// pcmDuration 计算24kHz 16位单声道裸PCM时长
+func pcmDuration(data []byte) time.Duration {
+	byteRate := SampleRate * Channels * BitsPerSample / 8
+	return time.Duration(len(data)) * time.Second / time.Duration(byteRate)
+}
@@ -0,0 +1,347 @@
+package audiobook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"tts-service/internal/audio"
+	"tts-service/internal/config"
+	"tts-service/internal/db"
+	"tts-service/internal/models"
+	"tts-service/internal/storage"
+	"tts-service/internal/tts"
+	"tts-service/internal/utils"
+)
+
+const (
+	// bookFormat 有声书输出格式，章节标记写在ID3标签中
+	bookFormat = "mp3"
+	// BookFile 整本书的文件名
+	BookFile = "book.mp3"
+	// chapterGap 整本书中章节之间的静音
+	chapterGap = time.Second
+)
+
+// Options 创建有声书的参数，Title和Author为空时使用文件中的元数据
+type Options struct {
+	Title   string
+	Author  string
+	Request models.TTSRequest
+}
+
+// Builder 有声书生成器
+// 每章通过TTSService合成并写入独立的对象，完成后记录到数据库，中断后从未完成的章节继续
+// 文件与缓存音频保存在同一个存储后端中，键为 audiobooks/<ID>/<文件名>
+type Builder struct {
+	db         *db.DB
+	ttsService *tts.TTSService
+	store      storage.Storage
+	tmpDir     string
+}
+
+// NewBuilder 创建有声书生成器
+func NewBuilder(database *db.DB, ttsService *tts.TTSService, cfg *config.Config) *Builder {
+	return &Builder{
+		db:         database,
+		ttsService: ttsService,
+		store:      ttsService.Storage(),
+		tmpDir:     cfg.Storage.Path,
+	}
+}
+
+// Create 解析文件并保存章节，返回有声书和是否为已有的未完成记录
+// 同一用户以相同参数再次提交同一文件时返回已有记录，已完成的章节不会重新合成
+func (b *Builder) Create(userID int, filename string, data []byte, opts Options, status string) (*models.Audiobook, bool, error) {
+	req := opts.Request
+	req.Text = ""
+	req.Stream = false
+	if req.Format != "" && req.Format != bookFormat {
+		return nil, false, fmt.Errorf("有声书只支持 %s 格式", bookFormat)
+	}
+	req.Format = bookFormat
+	req.UserID = userID
+	// 未指定语音时只用默认语音校验其他参数，合成时每章按自己的文本检测语言和选择语音
+	pinned := req.Voice != "" || req.SSML
+	if err := b.ttsService.NormalizeRequest(&req); err != nil {
+		return nil, false, err
+	}
+	if !pinned {
+		req.Voice, req.Engine = "", opts.Request.Engine
+	}
+
+	sum := sha256.Sum256(data)
+	sourceHash := hex.EncodeToString(sum[:])
+	existing, err := b.db.FindResumableAudiobook(userID, sourceHash, &req)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return existing, true, nil
+	}
+
+	parsed, err := Parse(filename, data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	book := &models.Audiobook{
+		ID:         utils.GenerateRequestID(),
+		UserID:     userID,
+		Title:      parsed.Title,
+		Author:     parsed.Author,
+		Source:     filepath.Base(filename),
+		SourceHash: sourceHash,
+		Request:    req,
+		Status:     status,
+	}
+	if opts.Title != "" {
+		book.Title = opts.Title
+	}
+	if opts.Author != "" {
+		book.Author = opts.Author
+	}
+
+	chapters := make([]models.AudiobookChapter, len(parsed.Chapters))
+	for i, chapter := range parsed.Chapters {
+		chapters[i] = models.AudiobookChapter{
+			Index: i + 1,
+			Title: chapter.Title,
+			Text:  chapter.Text,
+		}
+	}
+
+	if err := b.db.CreateAudiobook(book, chapters); err != nil {
+		return nil, false, err
+	}
+	return book, false, nil
+}
+
+// Get 获取有声书及章节信息，不存在时返回nil
+func (b *Builder) Get(id string) (*models.Audiobook, error) {
+	book, err := b.db.GetAudiobook(id)
+	if err != nil || book == nil {
+		return book, err
+	}
+
+	chapters, err := b.db.ListAudiobookChapters(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chapters {
+		if chapters[i].Status == models.JobSucceeded {
+			chapters[i].AudioURL = b.fileURL(id, path.Base(chapterKey(id, &chapters[i])))
+		}
+	}
+	book.Chapters = chapters
+	b.decorate(book)
+	return book, nil
+}
+
+// List 获取用户的有声书列表，不包含章节
+func (b *Builder) List(userID int) ([]*models.Audiobook, error) {
+	books, err := b.db.ListAudiobooks(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, book := range books {
+		b.decorate(book)
+	}
+	return books, nil
+}
+
+// FileKey 有声书文件的对象键
+func FileKey(id, name string) string {
+	return storage.AudiobookPrefix + utils.SanitizeFileName(id) + "/" + utils.SanitizeFileName(name)
+}
+
+// chapterKey 章节文件的对象键，兼容旧版本保存在数据库中的本地文件路径
+func chapterKey(id string, chapter *models.AudiobookChapter) string {
+	return FileKey(id, path.Base(filepath.ToSlash(chapter.AudioPath)))
+}
+
+// Run 合成所有未完成的章节并生成整本书，progress在每章完成后调用
+func (b *Builder) Run(ctx context.Context, book *models.Audiobook, progress func(chapter models.AudiobookChapter, done, total int)) error {
+	chapters, err := b.db.ListAudiobookChapters(book.ID)
+	if err != nil {
+		return err
+	}
+
+	// 已完成但文件丢失的章节重新合成
+	completed := make([]bool, len(chapters))
+	done := 0
+	for i := range chapters {
+		if chapters[i].Status != models.JobSucceeded || chapters[i].AudioPath == "" {
+			continue
+		}
+		if _, err := b.store.Stat(ctx, chapterKey(book.ID, &chapters[i])); err == nil {
+			completed[i] = true
+			done++
+		} else if !errors.Is(err, storage.ErrNotExist) {
+			return fmt.Errorf("读取第 %d 章失败: %w", chapters[i].Index, err)
+		}
+	}
+
+	for i := range chapters {
+		chapter := &chapters[i]
+		if completed[i] {
+			continue
+		}
+
+		key, duration, err := b.synthesizeChapter(ctx, book, chapter, len(chapters))
+		if err != nil {
+			return fmt.Errorf("第 %d 章合成失败: %w", chapter.Index, err)
+		}
+		if err := b.db.CompleteAudiobookChapter(book.ID, chapter.Index, key, duration); err != nil {
+			return err
+		}
+		chapter.Status = models.JobSucceeded
+		chapter.AudioPath = key
+		chapter.Duration = duration
+
+		done++
+		if progress != nil {
+			progress(*chapter, done, len(chapters))
+		}
+	}
+
+	return b.writeBook(ctx, book, chapters)
+}
+
+// synthesizeChapter 合成单章并写入带ID3标签的章节文件，返回对象键和时长（秒）
+func (b *Builder) synthesizeChapter(ctx context.Context, book *models.Audiobook, chapter *models.AudiobookChapter, total int) (string, float64, error) {
+	// 有声书参数已在创建时校验，章节文本还需要规范化；有声书未指定语音时按本章文本选择语音
+	req := book.Request
+	req.Text = chapterText(chapter)
+	req.UserID = book.UserID
+	req.Normalized = false
+
+	result, err := b.ttsService.StreamTTSRequest(ctx, &req, nil)
+	if err != nil {
+		return "", 0, err
+	}
+
+	data, err := b.ttsService.ReadAudio(ctx, path.Base(result.AudioURL))
+	if err != nil {
+		return "", 0, fmt.Errorf("读取音频失败: %w", err)
+	}
+	data = audio.StripID3v2(data)
+
+	tag := &audio.ID3Tag{
+		Title:      chapter.Title,
+		Artist:     book.Author,
+		Album:      book.Title,
+		Track:      chapter.Index,
+		TrackTotal: total,
+	}
+	key := FileKey(book.ID, fmt.Sprintf("%03d.%s", chapter.Index, bookFormat))
+	header := tag.Bytes()
+	body := io.MultiReader(bytes.NewReader(header), bytes.NewReader(data))
+	if err := b.store.Put(ctx, key, body, int64(len(header)+len(data))); err != nil {
+		return "", 0, fmt.Errorf("保存章节文件失败: %w", err)
+	}
+
+	return key, audio.Duration(bookFormat, data).Seconds(), nil
+}
+
+// writeBook 拼接所有章节，生成带CHAP/CTOC章节标记的整本书
+func (b *Builder) writeBook(ctx context.Context, book *models.Audiobook, chapters []models.AudiobookChapter) error {
+	tag := &audio.ID3Tag{
+		Title:  book.Title,
+		Artist: book.Author,
+		Album:  book.Title,
+	}
+
+	var offset time.Duration
+	for i, chapter := range chapters {
+		length := time.Duration(chapter.Duration * float64(time.Second))
+		if i < len(chapters)-1 {
+			length += chapterGap
+		}
+		tag.Chapters = append(tag.Chapters, audio.ID3Chapter{
+			Title: chapter.Title,
+			Start: offset,
+			End:   offset + length,
+		})
+		offset += length
+	}
+
+	return b.putFile(ctx, FileKey(book.ID, BookFile), func(w io.Writer) error {
+		if _, err := w.Write(tag.Bytes()); err != nil {
+			return err
+		}
+
+		joiner, err := audio.NewJoiner(bookFormat, w)
+		if err != nil {
+			return err
+		}
+		for i, chapter := range chapters {
+			if i > 0 {
+				if err := joiner.AppendSilence(chapterGap); err != nil {
+					return err
+				}
+			}
+			data, err := storage.ReadAll(ctx, b.store, chapterKey(book.ID, &chapter))
+			if err != nil {
+				return fmt.Errorf("读取第 %d 章失败: %w", chapter.Index, err)
+			}
+			if _, err := joiner.Append(audio.StripID3v2(data)); err != nil {
+				return fmt.Errorf("拼接第 %d 章失败: %w", chapter.Index, err)
+			}
+		}
+		return joiner.Close()
+	})
+}
+
+// decorate 填充整本书的下载地址
+func (b *Builder) decorate(book *models.Audiobook) {
+	if book.Status == models.JobSucceeded {
+		book.BookURL = b.fileURL(book.ID, BookFile)
+	}
+}
+
+func (b *Builder) fileURL(id, name string) string {
+	return fmt.Sprintf("/api/v1/audiobooks/%s/files/%s", id, name)
+}
+
+// chapterText 章节朗读文本，正文不以标题开头时先读标题
+func chapterText(chapter *models.AudiobookChapter) string {
+	if strings.HasPrefix(chapter.Text, chapter.Title) {
+		return chapter.Text
+	}
+	return chapter.Title + "\n" + chapter.Text
+}
+
+// putFile 先写入 storage.path 下的临时文件，完成后写入存储，整本书不需要全部放在内存中
+// 中断时不会留下不完整的对象
+func (b *Builder) putFile(ctx context.Context, key string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(b.tmpDir, 0755); err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	file, err := os.CreateTemp(b.tmpDir, path.Base(key)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	tmpPath := file.Name()
+	defer os.Remove(tmpPath)
+
+	if err := write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := storage.PutFile(ctx, b.store, key, tmpPath); err != nil {
+		return fmt.Errorf("保存文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package audiobook
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// epubContainer META-INF/container.xml
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage OPF文件中需要的部分
+type epubPackage struct {
+	Title    []string `xml:"metadata>title"`
+	Creator  []string `xml:"metadata>creator"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef  string `xml:"idref,attr"`
+		Linear string `xml:"linear,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// 块级元素结束时换行
+var htmlBlockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "blockquote": true,
+	"section": true, "article": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "pre": true, "dd": true, "dt": true,
+}
+
+// 内容不朗读的元素
+var htmlSkipElements = map[string]bool{
+	"head": true, "script": true, "style": true, "rt": true, "rp": true,
+}
+
+// parseEPUB 按spine顺序读取EPUB正文，每个文档作为一章
+func parseEPUB(data []byte) (*Book, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("读取EPUB失败: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var container epubContainer
+	if err := readXML(files, "META-INF/container.xml", &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, fmt.Errorf("EPUB缺少rootfile")
+	}
+
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := readXML(files, opfPath, &pkg); err != nil {
+		return nil, err
+	}
+
+	book := &Book{}
+	if len(pkg.Title) > 0 {
+		book.Title = strings.TrimSpace(pkg.Title[0])
+	}
+	if len(pkg.Creator) > 0 {
+		book.Author = strings.TrimSpace(pkg.Creator[0])
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		if strings.Contains(item.MediaType, "html") {
+			hrefs[item.ID] = item.Href
+		}
+	}
+
+	base := path.Dir(opfPath)
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok || ref.Linear == "no" {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		name := path.Join(base, href)
+
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("EPUB缺少文件: %s", name)
+		}
+		content, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		chapter, err := extractHTML(content)
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", name, err)
+		}
+		// 很多EPUB每个文档的<title>都是书名，不能作为章节名
+		if chapter.Title == book.Title {
+			chapter.Title = ""
+		}
+		book.Chapters = append(book.Chapters, chapter)
+	}
+
+	return book, nil
+}
+
+// extractHTML 提取XHTML正文，第一个标题作为章节名，没有标题时使用<title>
+func extractHTML(content []byte) (Chapter, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var chapter Chapter
+	var body, heading, title strings.Builder
+	skip := 0
+	inHeading := 0
+	inTitle := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return chapter, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "title":
+				inTitle = true
+			case htmlSkipElements[name]:
+				skip++
+			case name == "h1" || name == "h2" || name == "h3":
+				inHeading++
+			}
+			if name == "br" {
+				body.WriteString("\n")
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "title":
+				inTitle = false
+			case htmlSkipElements[name]:
+				skip--
+			case name == "h1" || name == "h2" || name == "h3":
+				inHeading--
+				if chapter.Title == "" {
+					chapter.Title = collapseSpace(heading.String())
+				}
+			}
+			if htmlBlockElements[name] {
+				body.WriteString("\n")
+			}
+		case xml.CharData:
+			if inTitle {
+				title.Write(t)
+			}
+			if skip > 0 {
+				continue
+			}
+			body.Write(t)
+			if inHeading > 0 && chapter.Title == "" {
+				heading.Write(t)
+			}
+		}
+	}
+
+	if chapter.Title == "" {
+		chapter.Title = collapseSpace(title.String())
+	}
+
+	var lines []string
+	for _, line := range strings.Split(body.String(), "\n") {
+		if line = collapseSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	chapter.Text = strings.Join(lines, "\n")
+	return chapter, nil
+}
+
+// collapseSpace 合并连续空白
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func readXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("EPUB缺少文件: %s", name)
+	}
+	content, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("解析 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", f.Name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
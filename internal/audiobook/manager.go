@@ -0,0 +1,142 @@
+package audiobook
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"tts-service/internal/db"
+	"tts-service/internal/models"
+)
+
+// 默认工作协程数，有声书章节多，同时只处理一本避免占满引擎
+const defaultWorkers = 1
+
+// pollInterval 没有收到新任务通知时的轮询间隔
+const pollInterval = 5 * time.Second
+
+// Manager 有声书任务管理器
+// 与异步任务相同，排队状态保存在SQLite中，服务重启后未完成的有声书会继续合成
+type Manager struct {
+	db      *db.DB
+	builder *Builder
+	workers int
+
+	notify chan struct{}
+}
+
+// NewManager 创建有声书任务管理器
+func NewManager(database *db.DB, builder *Builder, workers int) *Manager {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	return &Manager{
+		db:      database,
+		builder: builder,
+		workers: workers,
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// Start 恢复中断的有声书并启动工作协程
+func (m *Manager) Start(ctx context.Context) error {
+	requeued, err := m.db.RequeueRunningAudiobooks()
+	if err != nil {
+		return err
+	}
+	if requeued > 0 {
+		fmt.Printf("重新排队 %d 本中断的有声书\n", requeued)
+	}
+
+	for i := 0; i < m.workers; i++ {
+		go m.worker(ctx)
+	}
+	return nil
+}
+
+// Submit 提交有声书，同一文件的失败记录会重新排队并从未完成的章节继续
+func (m *Manager) Submit(userID int, filename string, data []byte, opts Options) (*models.Audiobook, error) {
+	book, resumed, err := m.builder.Create(userID, filename, data, opts, models.JobQueued)
+	if err != nil {
+		return nil, err
+	}
+
+	if resumed && book.Status == models.JobFailed {
+		if _, err := m.db.RequeueAudiobook(book.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	m.wake()
+	return m.builder.Get(book.ID)
+}
+
+// Resume 将失败的有声书重新排队，不在失败状态时返回false
+func (m *Manager) Resume(id string) (bool, error) {
+	requeued, err := m.db.RequeueAudiobook(id)
+	if err == nil && requeued {
+		m.wake()
+	}
+	return requeued, err
+}
+
+// Get 获取有声书及章节信息，不存在时返回nil
+func (m *Manager) Get(id string) (*models.Audiobook, error) {
+	return m.builder.Get(id)
+}
+
+// List 获取用户的有声书列表
+func (m *Manager) List(userID int) ([]*models.Audiobook, error) {
+	return m.builder.List(userID)
+}
+
+// wake 通知工作协程有新任务
+func (m *Manager) wake() {
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+}
+
+// worker 循环领取并生成有声书
+func (m *Manager) worker(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		book, err := m.db.ClaimNextAudiobook()
+		if err != nil {
+			fmt.Printf("领取有声书失败: %v\n", err)
+		}
+		if book != nil {
+			m.run(ctx, book)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// run 生成单本有声书
+func (m *Manager) run(ctx context.Context, book *models.Audiobook) {
+	err := m.builder.Run(ctx, book, nil)
+
+	switch {
+	case err == nil:
+		err = m.db.UpdateAudiobookStatus(book.ID, models.JobSucceeded, "")
+	case ctx.Err() != nil:
+		// 服务关闭，保持运行中状态，重启后重新排队
+		return
+	default:
+		fmt.Printf("有声书 %s 生成失败: %v\n", book.ID, err)
+		err = m.db.UpdateAudiobookStatus(book.ID, models.JobFailed, err.Error())
+	}
+
+	if err != nil {
+		fmt.Printf("更新有声书 %s 状态失败: %v\n", book.ID, err)
+	}
+}
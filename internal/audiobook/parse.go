@@ -0,0 +1,264 @@
+package audiobook
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxHeadingRunes 纯文本中超过该长度的行不视为章节标题
+const maxHeadingRunes = 50
+
+// Book 解析后的书籍
+type Book struct {
+	Title    string
+	Author   string
+	Chapters []Chapter
+}
+
+// Chapter 书籍章节
+type Chapter struct {
+	Title string
+	Text  string
+}
+
+var (
+	// 纯文本章节标题：第一章、第12回、Chapter 3、序章等
+	textHeadingPattern = regexp.MustCompile(`^(第[0-9０-９一二三四五六七八九十百千万零〇两]+[章节回卷部篇集]|(?i:chapter)\s+[0-9ivxlc]+\b|序章|序言|楔子|引子|尾声|后记)`)
+
+	mdHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	mdImagePattern   = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	mdLinkPattern    = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdHTMLPattern    = regexp.MustCompile(`<[^>]+>`)
+	mdListPattern    = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+	mdRulePattern    = regexp.MustCompile(`^\s*([-*_]\s*){3,}$`)
+	mdEmphasisChars  = strings.NewReplacer("**", "", "__", "", "~~", "", "`", "", "*", "")
+)
+
+// Parse 根据文件扩展名解析EPUB、Markdown或纯文本
+func Parse(filename string, data []byte) (*Book, error) {
+	var book *Book
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".epub":
+		book, err = parseEPUB(data)
+	case ".md", ".markdown":
+		book = parseMarkdown(decodeText(data))
+	case ".txt", ".text":
+		book = parseText(decodeText(data))
+	default:
+		return nil, fmt.Errorf("不支持的文件类型: %s", filepath.Ext(filename))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if book.Title == "" {
+		book.Title = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+
+	// 去掉没有正文的章节
+	chapters := book.Chapters[:0]
+	for _, chapter := range book.Chapters {
+		chapter.Text = strings.TrimSpace(chapter.Text)
+		if chapter.Text == "" {
+			continue
+		}
+		if chapter.Title == "" {
+			chapter.Title = fmt.Sprintf("第%d章", len(chapters)+1)
+		}
+		chapters = append(chapters, chapter)
+	}
+	book.Chapters = chapters
+
+	if len(book.Chapters) == 0 {
+		return nil, fmt.Errorf("没有可朗读的内容")
+	}
+	return book, nil
+}
+
+// decodeText 去掉UTF-8 BOM，统一换行符
+func decodeText(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	text := string(data)
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "")
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+// parseText 按“第X章”等标题行切分纯文本，没有标题时整本作为一章
+func parseText(text string) *Book {
+	book := &Book{}
+	current := &Chapter{}
+	var body strings.Builder
+
+	flush := func() {
+		current.Text = body.String()
+		book.Chapters = append(book.Chapters, *current)
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && utf8.RuneCountInString(trimmed) <= maxHeadingRunes && textHeadingPattern.MatchString(trimmed) {
+			flush()
+			current = &Chapter{Title: trimmed}
+			continue
+		}
+		body.WriteString(trimmed)
+		body.WriteString("\n")
+	}
+	flush()
+
+	// 第一个标题之前的内容作为前言
+	if book.Chapters[0].Title == "" && len(book.Chapters) > 1 {
+		book.Chapters[0].Title = "前言"
+	}
+	return book
+}
+
+// parseMarkdown 按标题切分Markdown
+// 只有一个最高级标题且位于开头时作为书名，章节按下一级标题切分
+func parseMarkdown(text string) *Book {
+	book := &Book{}
+	lines := strings.Split(text, "\n")
+	lines = parseFrontMatter(lines, book)
+
+	// 统计各级标题，跳过代码块
+	counts := make(map[int]int)
+	firstLevel := 0
+	inCode := false
+	for _, line := range lines {
+		if isCodeFence(line) {
+			inCode = !inCode
+			continue
+		}
+		if m := mdHeadingPattern.FindStringSubmatch(line); m != nil && !inCode {
+			counts[len(m[1])]++
+			if firstLevel == 0 {
+				firstLevel = len(m[1])
+			}
+		}
+	}
+
+	minLevel := 0
+	for level := 1; level <= 6; level++ {
+		if counts[level] > 0 {
+			minLevel = level
+			break
+		}
+	}
+
+	chapterLevel := minLevel
+	titleLevel := 0
+	if minLevel > 0 && counts[minLevel] == 1 && firstLevel == minLevel {
+		for level := minLevel + 1; level <= 6; level++ {
+			if counts[level] > 0 {
+				titleLevel = minLevel
+				chapterLevel = level
+				break
+			}
+		}
+	}
+
+	current := &Chapter{}
+	var body strings.Builder
+	flush := func() {
+		current.Text = body.String()
+		book.Chapters = append(book.Chapters, *current)
+		body.Reset()
+	}
+
+	inCode = false
+	for _, line := range lines {
+		// 代码块不适合朗读
+		if isCodeFence(line) {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+
+		if m := mdHeadingPattern.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			title := cleanMarkdownInline(m[2])
+			switch {
+			case level == titleLevel:
+				if book.Title == "" {
+					book.Title = title
+				}
+				continue
+			case level == chapterLevel:
+				flush()
+				current = &Chapter{Title: title}
+				continue
+			}
+			body.WriteString(title)
+			body.WriteString("\n")
+			continue
+		}
+
+		if mdRulePattern.MatchString(line) {
+			continue
+		}
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "> ")
+		line = mdListPattern.ReplaceAllString(line, "")
+		line = strings.ReplaceAll(line, "|", " ")
+		body.WriteString(cleanMarkdownInline(line))
+		body.WriteString("\n")
+	}
+	flush()
+
+	if book.Chapters[0].Title == "" && len(book.Chapters) > 1 {
+		book.Chapters[0].Title = "前言"
+	}
+	return book
+}
+
+// parseFrontMatter 读取YAML front matter中的title和author，返回剩余内容
+func parseFrontMatter(lines []string, book *Book) []string {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return lines
+	}
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "---" || line == "..." {
+			return lines[i+1:]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "title":
+			book.Title = value
+		case "author":
+			book.Author = value
+		}
+	}
+	// 没有结束标记，不是front matter
+	book.Title, book.Author = "", ""
+	return lines
+}
+
+func isCodeFence(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~")
+}
+
+// cleanMarkdownInline 去掉行内Markdown标记，链接保留文字
+func cleanMarkdownInline(text string) string {
+	text = mdImagePattern.ReplaceAllString(text, "")
+	text = mdLinkPattern.ReplaceAllString(text, "$1")
+	text = mdHTMLPattern.ReplaceAllString(text, "")
+	text = mdEmphasisChars.Replace(text)
+	return strings.TrimSpace(text)
+}
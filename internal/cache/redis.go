@@ -58,6 +58,39 @@ func (r *RedisClient) Get(key string) (string, error) {
 	return val, nil
 }
 
+// SetNX 键不存在时设置缓存，返回是否设置成功
+func (r *RedisClient) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(r.ctx, key, value, expiration).Result()
+	if err != nil {
+		return false, fmt.Errorf("设置Redis缓存失败: %w", err)
+	}
+	return ok, nil
+}
+
+// 只在键的值与预期一致时删除或续期，避免误操作其他持有者的锁
+var (
+	deleteIfValueScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+	expireIfValueScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`)
+)
+
+// DeleteIfValue 键的值等于value时删除，返回是否删除
+func (r *RedisClient) DeleteIfValue(key, value string) (bool, error) {
+	n, err := deleteIfValueScript.Run(r.ctx, r.client, []string{key}, value).Int()
+	if err != nil {
+		return false, fmt.Errorf("删除Redis缓存失败: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ExpireIfValue 键的值等于value时重新设置过期时间，返回是否设置
+func (r *RedisClient) ExpireIfValue(key, value string, expiration time.Duration) (bool, error) {
+	n, err := expireIfValueScript.Run(r.ctx, r.client, []string{key}, value, expiration.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("设置Redis过期时间失败: %w", err)
+	}
+	return n > 0, nil
+}
+
 // Exists 检查键是否存在
 func (r *RedisClient) Exists(key string) (bool, error) {
 	val, err := r.client.Exists(r.ctx, key).Result()
@@ -146,4 +179,4 @@ func (r *RedisClient) GetStats() (map[string]string, error) {
 	stats["info"] = info
 
 	return stats, nil
-}
\ No newline at end of file
+}
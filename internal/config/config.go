@@ -1,23 +1,29 @@
 package config
 
 import (
-	"os"
 	"gopkg.in/yaml.v3"
+	"os"
 )
 
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Redis    RedisConfig    `yaml:"redis"`
-	Storage  StorageConfig  `yaml:"storage"`
-	TTS      TTSConfig      `yaml:"tts"`
-	EdgeTTS  EdgeTTSConfig  `yaml:"edge_tts"`
-	Logging  LoggingConfig  `yaml:"logging"`
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Redis     RedisConfig     `yaml:"redis"`
+	Storage   StorageConfig   `yaml:"storage"`
+	TTS       TTSConfig       `yaml:"tts"`
+	EdgeTTS   EdgeTTSConfig   `yaml:"edge_tts"`
+	Logging   LoggingConfig   `yaml:"logging"`
+	Jobs      JobsConfig      `yaml:"jobs"`
+	Webhook   WebhookConfig   `yaml:"webhook"`
+	Audiobook AudiobookConfig `yaml:"audiobook"`
+	OpenAI    OpenAIConfig    `yaml:"openai"`
 }
 
 type ServerConfig struct {
-	Port int    `yaml:"port"`
-	Host string `yaml:"host"`
+	Port      int      `yaml:"port"`
+	Host      string   `yaml:"host"`
+	PublicURL string   `yaml:"public_url"`
+	AdminKeys []string `yaml:"admin_keys"` // 可以访问管理接口的API Key
 }
 
 type DatabaseConfig struct {
@@ -31,20 +37,118 @@ type RedisConfig struct {
 }
 
 type StorageConfig struct {
-	Path         string `yaml:"path"`
-	MaxSize      string `yaml:"max_size"`
-	CleanupHours int    `yaml:"cleanup_hours"`
+	Path         string   `yaml:"path"`
+	MaxSize      string   `yaml:"max_size"`
+	CleanupHours int      `yaml:"cleanup_hours"`
+	Backend      string   `yaml:"backend"` // 缓存音频的存储后端: fs(默认，保存在path目录) 或 s3
+	S3           S3Config `yaml:"s3"`
+}
+
+// S3Config S3兼容对象存储配置，path仍用作合成时的临时目录和有声书目录
+type S3Config struct {
+	Endpoint       string `yaml:"endpoint"` // 如 https://s3.amazonaws.com、http://127.0.0.1:9000
+	Region         string `yaml:"region"`   // 默认 us-east-1
+	Bucket         string `yaml:"bucket"`
+	AccessKey      string `yaml:"access_key"`
+	SecretKey      string `yaml:"secret_key"`
+	Prefix         string `yaml:"prefix"`          // 对象键前缀，如 "tts/"
+	PathStyle      bool   `yaml:"path_style"`      // 使用 endpoint/bucket/key 形式的地址，MinIO等需要开启
+	Redirect       bool   `yaml:"redirect"`        // 下载音频时重定向到预签名URL，否则由服务转发
+	PresignSeconds int    `yaml:"presign_seconds"` // 预签名URL有效期，默认3600秒
 }
 
 type TTSConfig struct {
-	Engines       []string `yaml:"engines"`
-	DefaultVoice  string   `yaml:"default_voice"`
-	DefaultFormat string   `yaml:"default_format"`
+	Engines       []string        `yaml:"engines"`
+	DefaultVoice  string          `yaml:"default_voice"`
+	DefaultFormat string          `yaml:"default_format"`
+	Subtitle      SubtitleConfig  `yaml:"subtitle"`
+	Breaker       BreakerConfig   `yaml:"breaker"`
+	Chunk         ChunkConfig     `yaml:"chunk"`
+	Batch         BatchConfig     `yaml:"batch"`
+	Language      LanguageConfig  `yaml:"language"`
+	Normalize     NormalizeConfig `yaml:"normalize"`
+}
+
+// NormalizeConfig 合成前的文本规范化，请求中的 normalize 参数可以覆盖
+type NormalizeConfig struct {
+	Enable bool            `yaml:"enable"` // 开启全部规则，默认关闭，原文直接交给引擎
+	Rules  map[string]bool `yaml:"rules"`  // 按名称开关单条规则，未列出的规则跟随enable
+	URL    string          `yaml:"url"`    // 网址处理方式: strip、replace、keep(默认)
+	Emoji  string          `yaml:"emoji"`  // 表情处理方式: strip、replace、keep(默认)
+}
+
+// LanguageConfig 未指定语音时按文本语言选择语音
+type LanguageConfig struct {
+	Voices            map[string]string `yaml:"voices"`             // 语言代码(zh、en、ja…)对应的默认语音，未配置的语言使用内置映射
+	MultilingualVoice string            `yaml:"multilingual_voice"` // 混合语言模式使用的多语言语音，为空时每段切换为对应语言的语音
+}
+
+type BatchConfig struct {
+	MaxItems    int `yaml:"max_items"`
+	Concurrency int `yaml:"concurrency"`
+}
+
+type ChunkConfig struct {
+	MaxChars    int `yaml:"max_chars"`
+	Concurrency int `yaml:"concurrency"`
+}
+
+type BreakerConfig struct {
+	FailureThreshold int `yaml:"failure_threshold"`
+	CooldownSeconds  int `yaml:"cooldown_seconds"`
+	HalfOpenRequests int `yaml:"half_open_requests"`
+}
+
+type SubtitleConfig struct {
+	MaxLineLength int `yaml:"max_line_length"`
+	MaxDurationMs int `yaml:"max_duration_ms"`
 }
 
 type EdgeTTSConfig struct {
-	Endpoint  string `yaml:"endpoint"`
-	UserAgent string `yaml:"user_agent"`
+	Endpoint  string             `yaml:"endpoint"`
+	UserAgent string             `yaml:"user_agent"`
+	Voices    VoiceCatalogConfig `yaml:"voices"`
+}
+
+// VoiceCatalogConfig Edge语音目录配置
+type VoiceCatalogConfig struct {
+	URL          string `yaml:"url"`           // 语音列表地址，为空时使用Edge默认地址
+	RefreshHours int    `yaml:"refresh_hours"` // 刷新间隔，默认24小时
+	CachePath    string `yaml:"cache_path"`    // 本地缓存文件，默认放在数据库所在目录
+}
+
+// OpenAIConfig OpenAI兼容接口配置
+type OpenAIConfig struct {
+	Voices map[string]VoiceAliasConfig `yaml:"voices"` // 语音别名，配置后替换内置的别名
+}
+
+// VoiceAliasConfig OpenAI语音名称对应的语音和默认韵律
+type VoiceAliasConfig struct {
+	Voice       string            `yaml:"voice"`   // 默认语音
+	Locales     map[string]string `yaml:"locales"` // 按输入文本的语言(zh、en…)选择的语音，优先于voice
+	Speed       float64           `yaml:"speed"`   // 语速倍数，与请求中的speed相乘
+	Pitch       string            `yaml:"pitch"`
+	Volume      string            `yaml:"volume"`
+	Style       string            `yaml:"style"`
+	Description string            `yaml:"description"`
+	Gender      string            `yaml:"gender"`
+}
+
+type AudiobookConfig struct {
+	Workers     int `yaml:"workers"`
+	MaxUploadMB int `yaml:"max_upload_mb"`
+}
+
+type JobsConfig struct {
+	Workers int `yaml:"workers"`
+}
+
+type WebhookConfig struct {
+	MaxAttempts          int  `yaml:"max_attempts"`
+	BaseDelaySeconds     int  `yaml:"base_delay_seconds"`
+	TimeoutSeconds       int  `yaml:"timeout_seconds"`
+	Workers              int  `yaml:"workers"`                // 同时进行的投递数，默认4
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"` // 允许回调本机和内网地址，默认拒绝
 }
 
 type LoggingConfig struct {
@@ -65,4 +169,4 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	return &config, nil
-}
\ No newline at end of file
+}
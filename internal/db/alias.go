@@ -0,0 +1,96 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"tts-service/internal/models"
+)
+
+const voiceAliasColumns = `id, user_id, name, voice, locales, speed, pitch, volume, style, description, gender, created_at, updated_at`
+
+// ListVoiceAliases 获取用户自定义的语音别名，按名称排序
+func (db *DB) ListVoiceAliases(userID int) ([]models.VoiceAlias, error) {
+	rows, err := db.Query(`SELECT `+voiceAliasColumns+` FROM voice_aliases WHERE user_id = ? ORDER BY name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询语音别名失败: %w", err)
+	}
+	return scanVoiceAliases(rows)
+}
+
+// SaveVoiceAlias 创建或替换用户的语音别名
+func (db *DB) SaveVoiceAlias(alias *models.VoiceAlias) error {
+	locales, err := json.Marshal(alias.Locales)
+	if err != nil {
+		return fmt.Errorf("序列化语音别名失败: %w", err)
+	}
+
+	query := `INSERT INTO voice_aliases (user_id, name, voice, locales, speed, pitch, volume, style, description, gender)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT (user_id, name) DO UPDATE
+			  SET voice = excluded.voice, locales = excluded.locales, speed = excluded.speed, pitch = excluded.pitch,
+			      volume = excluded.volume, style = excluded.style, description = excluded.description,
+			      gender = excluded.gender, updated_at = CURRENT_TIMESTAMP`
+	if _, err := db.Exec(query, alias.UserID, alias.Name, alias.Voice, string(locales), alias.Speed,
+		alias.Pitch, alias.Volume, alias.Style, alias.Description, alias.Gender); err != nil {
+		return fmt.Errorf("保存语音别名失败: %w", err)
+	}
+
+	rows, err := db.Query(`SELECT `+voiceAliasColumns+` FROM voice_aliases WHERE user_id = ? AND name = ?`, alias.UserID, alias.Name)
+	if err != nil {
+		return fmt.Errorf("查询语音别名失败: %w", err)
+	}
+	saved, err := scanVoiceAliases(rows)
+	if err != nil {
+		return err
+	}
+	if len(saved) == 0 {
+		return fmt.Errorf("保存语音别名失败: 记录不存在")
+	}
+	*alias = saved[0]
+	return nil
+}
+
+// DeleteVoiceAlias 删除用户的语音别名，返回是否找到该别名
+func (db *DB) DeleteVoiceAlias(userID int, name string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM voice_aliases WHERE user_id = ? AND name = ?`, userID, name)
+	if err != nil {
+		return false, fmt.Errorf("删除语音别名失败: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// scanVoiceAliases 扫描语音别名
+func scanVoiceAliases(rows *sql.Rows) ([]models.VoiceAlias, error) {
+	defer rows.Close()
+
+	aliases := []models.VoiceAlias{}
+	for rows.Next() {
+		var (
+			a                    models.VoiceAlias
+			locales              string
+			createdAt, updatedAt sql.NullTime
+		)
+		err := rows.Scan(&a.ID, &a.UserID, &a.Name, &a.Voice, &locales, &a.Speed, &a.Pitch, &a.Volume,
+			&a.Style, &a.Description, &a.Gender, &createdAt, &updatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("扫描语音别名失败: %w", err)
+		}
+		if err := json.Unmarshal([]byte(locales), &a.Locales); err != nil {
+			return nil, fmt.Errorf("解析语音别名 %s 的语言映射失败: %w", a.Name, err)
+		}
+		if createdAt.Valid {
+			a.CreatedAt = &createdAt.Time
+		}
+		if updatedAt.Valid {
+			a.UpdatedAt = &updatedAt.Time
+		}
+		a.Source = models.VoiceAliasUser
+		aliases = append(aliases, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("扫描语音别名失败: %w", err)
+	}
+	return aliases, nil
+}
@@ -0,0 +1,242 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"tts-service/internal/models"
+)
+
+const audiobookColumns = `id, user_id, title, author, source, source_hash, request, status, total_chapters, done_chapters, error, created_at, updated_at`
+
+// CreateAudiobook 创建有声书及其章节
+func (db *DB) CreateAudiobook(book *models.Audiobook, chapters []models.AudiobookChapter) error {
+	request, err := json.Marshal(book.Request)
+	if err != nil {
+		return fmt.Errorf("序列化合成参数失败: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO audiobooks (id, user_id, title, author, source, source_hash, request, status, total_chapters)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(query, book.ID, book.UserID, book.Title, book.Author, book.Source, book.SourceHash,
+		string(request), book.Status, len(chapters)); err != nil {
+		return fmt.Errorf("创建有声书失败: %w", err)
+	}
+
+	query = `INSERT INTO audiobook_chapters (book_id, idx, title, text, status) VALUES (?, ?, ?, ?, ?)`
+	for _, chapter := range chapters {
+		if _, err := tx.Exec(query, book.ID, chapter.Index, chapter.Title, chapter.Text, models.JobQueued); err != nil {
+			return fmt.Errorf("创建章节失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	created, err := db.GetAudiobook(book.ID)
+	if err != nil {
+		return err
+	}
+	*book = *created
+	return nil
+}
+
+// GetAudiobook 获取有声书，不存在时返回nil
+func (db *DB) GetAudiobook(id string) (*models.Audiobook, error) {
+	query := `SELECT ` + audiobookColumns + ` FROM audiobooks WHERE id = ?`
+	book, err := scanAudiobook(db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询有声书失败: %w", err)
+	}
+	return book, nil
+}
+
+// FindResumableAudiobook 查找同一用户、同一来源和参数的未完成有声书，用于断点续传
+func (db *DB) FindResumableAudiobook(userID int, sourceHash string, req *models.TTSRequest) (*models.Audiobook, error) {
+	request, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("序列化合成参数失败: %w", err)
+	}
+
+	query := `SELECT ` + audiobookColumns + ` FROM audiobooks
+			  WHERE user_id = ? AND source_hash = ? AND request = ? AND status != ?
+			  ORDER BY created_at DESC LIMIT 1`
+	book, err := scanAudiobook(db.QueryRow(query, userID, sourceHash, string(request), models.JobSucceeded))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询有声书失败: %w", err)
+	}
+	return book, nil
+}
+
+// ListAudiobooks 获取用户的有声书列表
+func (db *DB) ListAudiobooks(userID int) ([]*models.Audiobook, error) {
+	query := `SELECT ` + audiobookColumns + ` FROM audiobooks WHERE user_id = ? ORDER BY created_at DESC`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询有声书失败: %w", err)
+	}
+	defer rows.Close()
+
+	var books []*models.Audiobook
+	for rows.Next() {
+		book, err := scanAudiobook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描有声书失败: %w", err)
+		}
+		books = append(books, book)
+	}
+	return books, rows.Err()
+}
+
+// ListAudiobookChapters 获取有声书的全部章节，按顺序排列
+func (db *DB) ListAudiobookChapters(bookID string) ([]models.AudiobookChapter, error) {
+	query := `SELECT idx, title, text, status, duration, audio_path FROM audiobook_chapters
+			  WHERE book_id = ? ORDER BY idx`
+	rows, err := db.Query(query, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("查询章节失败: %w", err)
+	}
+	defer rows.Close()
+
+	var chapters []models.AudiobookChapter
+	for rows.Next() {
+		var chapter models.AudiobookChapter
+		var duration sql.NullFloat64
+		var audioPath sql.NullString
+		if err := rows.Scan(&chapter.Index, &chapter.Title, &chapter.Text, &chapter.Status, &duration, &audioPath); err != nil {
+			return nil, fmt.Errorf("扫描章节失败: %w", err)
+		}
+		chapter.Duration = duration.Float64
+		chapter.AudioPath = audioPath.String
+		chapters = append(chapters, chapter)
+	}
+	return chapters, rows.Err()
+}
+
+// CompleteAudiobookChapter 记录章节完成，并更新有声书进度
+func (db *DB) CompleteAudiobookChapter(bookID string, index int, audioPath string, duration float64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE audiobook_chapters SET status = ?, audio_path = ?, duration = ? WHERE book_id = ? AND idx = ?`
+	if _, err := tx.Exec(query, models.JobSucceeded, audioPath, duration, bookID, index); err != nil {
+		return fmt.Errorf("更新章节状态失败: %w", err)
+	}
+
+	query = `UPDATE audiobooks SET updated_at = CURRENT_TIMESTAMP,
+			  done_chapters = (SELECT COUNT(*) FROM audiobook_chapters WHERE book_id = ? AND status = ?)
+			  WHERE id = ?`
+	if _, err := tx.Exec(query, bookID, models.JobSucceeded, bookID); err != nil {
+		return fmt.Errorf("更新有声书进度失败: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// ClaimNextAudiobook 领取最早的排队有声书并标记为运行中，没有排队时返回nil
+func (db *DB) ClaimNextAudiobook() (*models.Audiobook, error) {
+	query := `UPDATE audiobooks SET status = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = (SELECT id FROM audiobooks WHERE status = ? ORDER BY created_at, rowid LIMIT 1)
+			  AND status = ?
+			  RETURNING ` + audiobookColumns
+	book, err := scanAudiobook(db.QueryRow(query, models.JobRunning, models.JobQueued, models.JobQueued))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("领取有声书失败: %w", err)
+	}
+	return book, nil
+}
+
+// UpdateAudiobookStatus 更新有声书状态
+func (db *DB) UpdateAudiobookStatus(id, status, message string) error {
+	query := `UPDATE audiobooks SET status = ?, error = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := db.Exec(query, status, message, id); err != nil {
+		return fmt.Errorf("更新有声书状态失败: %w", err)
+	}
+	return nil
+}
+
+// RequeueAudiobook 将失败的有声书重新排队，已完成的章节不会重新合成
+func (db *DB) RequeueAudiobook(id string) (bool, error) {
+	query := `UPDATE audiobooks SET status = ?, error = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?`
+	result, err := db.Exec(query, models.JobQueued, id, models.JobFailed)
+	if err != nil {
+		return false, fmt.Errorf("恢复有声书失败: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取影响行数失败: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// RequeueRunningAudiobooks 将运行中的有声书重新放回队列，用于服务重启后恢复
+func (db *DB) RequeueRunningAudiobooks() (int64, error) {
+	query := `UPDATE audiobooks SET status = ? WHERE status = ?`
+	result, err := db.Exec(query, models.JobQueued, models.JobRunning)
+	if err != nil {
+		return 0, fmt.Errorf("恢复有声书失败: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("获取影响行数失败: %w", err)
+	}
+	return affected, nil
+}
+
+// scanAudiobook 扫描有声书记录
+func scanAudiobook(row interface{ Scan(...any) error }) (*models.Audiobook, error) {
+	var book models.Audiobook
+	var request string
+	var author, errMsg sql.NullString
+
+	err := row.Scan(
+		&book.ID,
+		&book.UserID,
+		&book.Title,
+		&author,
+		&book.Source,
+		&book.SourceHash,
+		&request,
+		&book.Status,
+		&book.TotalChapters,
+		&book.DoneChapters,
+		&errMsg,
+		&book.CreatedAt,
+		&book.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(request), &book.Request); err != nil {
+		return nil, fmt.Errorf("解析合成参数失败: %w", err)
+	}
+	book.Author = author.String
+	book.Error = errMsg.String
+
+	return &book, nil
+}
@@ -6,39 +6,32 @@ import (
 	"tts-service/internal/models"
 )
 
-// CreateTTSCache 创建TTS缓存记录
+// cacheColumns 查询缓存记录的列，与scanCache的顺序一致
+const cacheColumns = `id, text_hash, voice, format, COALESCE(audio_path, ''), key_version, hit_count, pinned, created_at, last_accessed_at`
+
+// CreateTTSCache 创建TTS缓存记录，新记录的访问时间为创建时间
+// 相同text_hash的记录已存在时（如其他实例刚写入，或缺少边界元数据后重新合成）更新该记录，保留命中次数和固定状态
 func (db *DB) CreateTTSCache(cache *models.TTSCache) error {
-	query := `INSERT INTO tts_cache (text_hash, voice, format, audio_path) VALUES (?, ?, ?, ?)`
-	result, err := db.Exec(query, cache.TextHash, cache.Voice, cache.Format, cache.AudioPath)
-	if err != nil {
+	query := `INSERT INTO tts_cache (text_hash, voice, format, audio_path, key_version, last_accessed_at) VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+			  ON CONFLICT(text_hash) DO UPDATE SET voice = excluded.voice, format = excluded.format, audio_path = excluded.audio_path,
+			  key_version = excluded.key_version, last_accessed_at = CURRENT_TIMESTAMP
+			  RETURNING id`
+	var id int
+	if err := db.QueryRow(query, cache.TextHash, cache.Voice, cache.Format, cache.AudioPath, cache.KeyVersion).Scan(&id); err != nil {
 		return fmt.Errorf("创建TTS缓存失败: %w", err)
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("获取缓存ID失败: %w", err)
-	}
-
-	cache.ID = int(id)
+	cache.ID = id
 	return nil
 }
 
 // GetTTSCache 获取TTS缓存
 func (db *DB) GetTTSCache(textHash, voice, format string) (*models.TTSCache, error) {
-	query := `SELECT id, text_hash, voice, format, audio_path, created_at 
+	query := `SELECT ` + cacheColumns + ` 
 			  FROM tts_cache 
 			  WHERE text_hash = ? AND voice = ? AND format = ?`
-	
-	var cache models.TTSCache
-	err := db.QueryRow(query, textHash, voice, format).Scan(
-		&cache.ID,
-		&cache.TextHash,
-		&cache.Voice,
-		&cache.Format,
-		&cache.AudioPath,
-		&cache.CreatedAt,
-	)
 
+	cache, err := scanCache(db.QueryRow(query, textHash, voice, format))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // 没有找到缓存，返回nil而不是错误
@@ -46,23 +39,100 @@ func (db *DB) GetTTSCache(textHash, voice, format string) (*models.TTSCache, err
 		return nil, fmt.Errorf("查询TTS缓存失败: %w", err)
 	}
 
-	return &cache, nil
+	return cache, nil
 }
 
-// DeleteExpiredCache 删除过期的缓存记录
-func (db *DB) DeleteExpiredCache(hours int) (int64, error) {
-	query := `DELETE FROM tts_cache WHERE created_at < datetime('now', '-' || ? || ' hours')`
-	result, err := db.Exec(query, hours)
+// FindTTSCache 按缓存键哈希获取缓存记录，不存在时返回nil
+func (db *DB) FindTTSCache(textHash string) (*models.TTSCache, error) {
+	cache, err := scanCache(db.QueryRow(`SELECT `+cacheColumns+` FROM tts_cache WHERE text_hash = ?`, textHash))
 	if err != nil {
-		return 0, fmt.Errorf("删除过期缓存失败: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询TTS缓存失败: %w", err)
 	}
+	return cache, nil
+}
 
+// ListOutdatedCache 获取缓存键版本低于version的缓存记录，最多limit条
+func (db *DB) ListOutdatedCache(version, limit int) ([]*models.TTSCache, error) {
+	rows, err := db.Query(`SELECT `+cacheColumns+` FROM tts_cache WHERE key_version < ? ORDER BY id LIMIT ?`, version, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询旧版缓存失败: %w", err)
+	}
+	return scanCaches(rows)
+}
+
+// ListEvictableCache 按最近访问时间从早到晚获取未固定的缓存记录，最多limit条，exclude为不参与淘汰的音频路径
+// 升级前从未访问过的记录访问时间为空，排在最前
+func (db *DB) ListEvictableCache(exclude string, limit int) ([]*models.TTSCache, error) {
+	rows, err := db.Query(`SELECT `+cacheColumns+` FROM tts_cache
+		WHERE pinned = 0 AND COALESCE(audio_path, '') != ? ORDER BY last_accessed_at, id LIMIT ?`, exclude, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询可淘汰缓存失败: %w", err)
+	}
+	return scanCaches(rows)
+}
+
+// TouchTTSCache 记录一次缓存访问：命中次数加一并更新最近访问时间
+func (db *DB) TouchTTSCache(textHash string) error {
+	_, err := db.Exec(`UPDATE tts_cache SET hit_count = hit_count + 1, last_accessed_at = CURRENT_TIMESTAMP WHERE text_hash = ?`, textHash)
+	if err != nil {
+		return fmt.Errorf("更新缓存访问记录失败: %w", err)
+	}
+	return nil
+}
+
+// SetTTSCachePinned 固定或取消固定缓存记录，返回是否找到该记录
+func (db *DB) SetTTSCachePinned(textHash string, pinned bool) (bool, error) {
+	result, err := db.Exec(`UPDATE tts_cache SET pinned = ? WHERE text_hash = ?`, pinned, textHash)
+	if err != nil {
+		return false, fmt.Errorf("更新缓存固定状态失败: %w", err)
+	}
 	affected, err := result.RowsAffected()
 	if err != nil {
-		return 0, fmt.Errorf("获取影响行数失败: %w", err)
+		return false, fmt.Errorf("获取影响行数失败: %w", err)
 	}
+	return affected > 0, nil
+}
 
-	return affected, nil
+// DeleteTTSCache 删除缓存记录
+func (db *DB) DeleteTTSCache(id int) error {
+	if _, err := db.Exec(`DELETE FROM tts_cache WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("删除TTS缓存失败: %w", err)
+	}
+	return nil
+}
+
+// ListExpiredCache 获取超过hours小时未访问且未固定的缓存记录，最多limit条
+// 从未记录访问时间的旧记录按创建时间计算
+func (db *DB) ListExpiredCache(hours, limit int) ([]*models.TTSCache, error) {
+	rows, err := db.Query(`SELECT `+cacheColumns+` FROM tts_cache
+		WHERE pinned = 0 AND COALESCE(last_accessed_at, created_at) < datetime('now', '-' || ? || ' hours')
+		ORDER BY id LIMIT ?`, hours, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询过期缓存失败: %w", err)
+	}
+	return scanCaches(rows)
+}
+
+// ListCacheHashes 获取全部缓存记录的缓存键哈希，用于查找没有记录的孤立文件
+func (db *DB) ListCacheHashes() (map[string]bool, error) {
+	rows, err := db.Query(`SELECT text_hash FROM tts_cache`)
+	if err != nil {
+		return nil, fmt.Errorf("查询缓存记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("读取缓存记录失败: %w", err)
+		}
+		hashes[hash] = true
+	}
+	return hashes, rows.Err()
 }
 
 // GetCacheStats 获取缓存统计信息
@@ -89,4 +159,46 @@ func (db *DB) GetCacheStats() (map[string]interface{}, error) {
 	}
 
 	return stats, nil
-}
\ No newline at end of file
+}
+
+// scanCaches 扫描多条缓存记录
+func scanCaches(rows *sql.Rows) ([]*models.TTSCache, error) {
+	defer rows.Close()
+
+	var caches []*models.TTSCache
+	for rows.Next() {
+		cache, err := scanCache(rows)
+		if err != nil {
+			return nil, fmt.Errorf("读取缓存记录失败: %w", err)
+		}
+		caches = append(caches, cache)
+	}
+	return caches, rows.Err()
+}
+
+// scanCache 扫描缓存记录
+func scanCache(row interface{ Scan(...any) error }) (*models.TTSCache, error) {
+	var cache models.TTSCache
+	var lastAccessedAt sql.NullTime
+
+	err := row.Scan(
+		&cache.ID,
+		&cache.TextHash,
+		&cache.Voice,
+		&cache.Format,
+		&cache.AudioPath,
+		&cache.KeyVersion,
+		&cache.HitCount,
+		&cache.Pinned,
+		&cache.CreatedAt,
+		&lastAccessedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastAccessedAt.Valid {
+		cache.LastAccessedAt = &lastAccessedAt.Time
+	}
+	return &cache, nil
+}
@@ -13,8 +13,8 @@ type DB struct {
 
 // Init 初始化数据库
 func Init(dbPath string) (*DB, error) {
-	// 连接数据库
-	sqlDB, err := sql.Open("sqlite", dbPath+"?cache=shared&mode=rwc&_journal_mode=WAL&_sync=NORMAL&_cache_size=1000000")
+	// 连接数据库，busy_timeout对连接池中的每个连接生效，并发写入时等待而不是直接返回SQLITE_BUSY
+	sqlDB, err := sql.Open("sqlite", dbPath+"?cache=shared&mode=rwc&_journal_mode=WAL&_sync=NORMAL&_cache_size=1000000&_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("打开数据库失败: %w", err)
 	}
@@ -37,12 +37,12 @@ func Init(dbPath string) (*DB, error) {
 // optimize 优化 SQLite 配置
 func (db *DB) optimize() error {
 	optimizations := []string{
-		"PRAGMA journal_mode = WAL;",        // 写前日志，提高并发
-		"PRAGMA synchronous = NORMAL;",      // 平衡性能和安全
-		"PRAGMA cache_size = 1000000;",      // 1GB缓存
-		"PRAGMA temp_store = memory;",       // 临时数据存内存
-		"PRAGMA mmap_size = 268435456;",     // 256MB内存映射
-		"PRAGMA foreign_keys = ON;",         // 启用外键约束
+		"PRAGMA journal_mode = WAL;",    // 写前日志，提高并发
+		"PRAGMA synchronous = NORMAL;",  // 平衡性能和安全
+		"PRAGMA cache_size = 1000000;",  // 1GB缓存
+		"PRAGMA temp_store = memory;",   // 临时数据存内存
+		"PRAGMA mmap_size = 268435456;", // 256MB内存映射
+		"PRAGMA foreign_keys = ON;",     // 启用外键约束
 	}
 
 	for _, sql := range optimizations {
@@ -76,11 +76,127 @@ func (db *DB) createTables() error {
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);`
 
+	// 异步任务表
+	jobTable := `
+	CREATE TABLE IF NOT EXISTS tts_jobs (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		request TEXT NOT NULL,
+		result TEXT,
+		error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		finished_at DATETIME
+	);`
+
+	// Webhook投递记录表
+	deliveryTable := `
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_status_code INTEGER,
+		last_error TEXT,
+		next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// 有声书表
+	audiobookTable := `
+	CREATE TABLE IF NOT EXISTS audiobooks (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		author TEXT,
+		source TEXT NOT NULL,
+		source_hash TEXT NOT NULL,
+		request TEXT NOT NULL,
+		status TEXT NOT NULL,
+		total_chapters INTEGER NOT NULL DEFAULT 0,
+		done_chapters INTEGER NOT NULL DEFAULT 0,
+		error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// 有声书章节表
+	chapterTable := `
+	CREATE TABLE IF NOT EXISTS audiobook_chapters (
+		book_id TEXT NOT NULL REFERENCES audiobooks(id) ON DELETE CASCADE,
+		idx INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		text TEXT NOT NULL,
+		status TEXT NOT NULL,
+		duration REAL,
+		audio_path TEXT,
+		PRIMARY KEY (book_id, idx)
+	);`
+
+	// 发音词典表，同一用户的同一词语在每种语言下只有一条
+	lexiconTable := `
+	CREATE TABLE IF NOT EXISTS lexicon_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		word TEXT NOT NULL,
+		alias TEXT NOT NULL DEFAULT '',
+		phoneme TEXT NOT NULL DEFAULT '',
+		alphabet TEXT NOT NULL DEFAULT '',
+		language TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, word, language)
+	);`
+
+	// OpenAI语音别名表，用户对内置或配置别名的覆盖
+	voiceAliasTable := `
+	CREATE TABLE IF NOT EXISTS voice_aliases (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		voice TEXT NOT NULL DEFAULT '',
+		locales TEXT NOT NULL DEFAULT '{}',
+		speed REAL NOT NULL DEFAULT 0,
+		pitch TEXT NOT NULL DEFAULT '',
+		volume TEXT NOT NULL DEFAULT '',
+		style TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		gender TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, name)
+	);`
+
 	// 索引
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_text_hash ON tts_cache(text_hash);",
 		"CREATE INDEX IF NOT EXISTS idx_created_at ON tts_cache(created_at);",
+		"CREATE INDEX IF NOT EXISTS idx_cache_key_version ON tts_cache(key_version);",
+		"CREATE INDEX IF NOT EXISTS idx_cache_last_access ON tts_cache(pinned, last_accessed_at);",
 		"CREATE INDEX IF NOT EXISTS idx_api_key ON users(api_key);",
+		"CREATE INDEX IF NOT EXISTS idx_job_status ON tts_jobs(status, created_at);",
+		"CREATE INDEX IF NOT EXISTS idx_delivery_due ON webhook_deliveries(status, next_attempt_at);",
+		"CREATE INDEX IF NOT EXISTS idx_delivery_job ON webhook_deliveries(job_id);",
+		"CREATE INDEX IF NOT EXISTS idx_audiobook_status ON audiobooks(status, created_at);",
+		"CREATE INDEX IF NOT EXISTS idx_audiobook_source ON audiobooks(user_id, source_hash);",
+	}
+
+	// 已有数据库中新增的列
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"users", "webhook_secret", "TEXT"},
+		{"tts_jobs", "callback_url", "TEXT"},
+		// 旧数据库中的缓存记录都是版本1的缓存键
+		{"tts_cache", "key_version", "INTEGER NOT NULL DEFAULT 1"},
+		{"tts_cache", "last_accessed_at", "DATETIME"},
+		{"tts_cache", "hit_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"tts_cache", "pinned", "INTEGER NOT NULL DEFAULT 0"},
 	}
 
 	// 执行创建表语句
@@ -92,6 +208,37 @@ func (db *DB) createTables() error {
 		return fmt.Errorf("创建缓存表失败: %w", err)
 	}
 
+	if _, err := db.Exec(jobTable); err != nil {
+		return fmt.Errorf("创建任务表失败: %w", err)
+	}
+
+	if _, err := db.Exec(deliveryTable); err != nil {
+		return fmt.Errorf("创建Webhook投递表失败: %w", err)
+	}
+
+	if _, err := db.Exec(audiobookTable); err != nil {
+		return fmt.Errorf("创建有声书表失败: %w", err)
+	}
+
+	if _, err := db.Exec(chapterTable); err != nil {
+		return fmt.Errorf("创建有声书章节表失败: %w", err)
+	}
+
+	if _, err := db.Exec(lexiconTable); err != nil {
+		return fmt.Errorf("创建发音词典表失败: %w", err)
+	}
+
+	if _, err := db.Exec(voiceAliasTable); err != nil {
+		return fmt.Errorf("创建语音别名表失败: %w", err)
+	}
+
+	// 补充新增的列
+	for _, col := range columns {
+		if err := db.addColumnIfMissing(col.table, col.column, col.definition); err != nil {
+			return err
+		}
+	}
+
 	// 创建索引
 	for _, idx := range indexes {
 		if _, err := db.Exec(idx); err != nil {
@@ -100,4 +247,34 @@ func (db *DB) createTables() error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// addColumnIfMissing 列不存在时添加，用于升级已有数据库
+func (db *DB) addColumnIfMissing(table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("读取表结构失败 [%s]: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("读取表结构失败 [%s]: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("读取表结构失败 [%s]: %w", table, err)
+	}
+	rows.Close()
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("添加列失败 [%s.%s]: %w", table, column, err)
+	}
+	return nil
+}
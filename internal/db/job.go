@@ -0,0 +1,158 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"tts-service/internal/models"
+)
+
+const jobColumns = `id, user_id, status, request, result, error, callback_url, created_at, started_at, finished_at`
+
+// CreateJob 创建异步任务
+func (db *DB) CreateJob(job *models.Job) error {
+	request, err := json.Marshal(job.Request)
+	if err != nil {
+		return fmt.Errorf("序列化任务请求失败: %w", err)
+	}
+
+	query := `INSERT INTO tts_jobs (id, user_id, status, request, callback_url) VALUES (?, ?, ?, ?, ?)`
+	if _, err := db.Exec(query, job.ID, job.UserID, job.Status, string(request), job.CallbackURL); err != nil {
+		return fmt.Errorf("创建任务失败: %w", err)
+	}
+
+	created, err := db.GetJob(job.ID)
+	if err != nil {
+		return err
+	}
+	*job = *created
+	return nil
+}
+
+// GetJob 获取任务，不存在时返回nil
+func (db *DB) GetJob(id string) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM tts_jobs WHERE id = ?`
+	job, err := scanJob(db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+	return job, nil
+}
+
+// ClaimNextJob 领取最早的排队任务并标记为运行中，没有排队任务时返回nil
+func (db *DB) ClaimNextJob() (*models.Job, error) {
+	query := `UPDATE tts_jobs SET status = ?, started_at = CURRENT_TIMESTAMP
+			  WHERE id = (SELECT id FROM tts_jobs WHERE status = ? ORDER BY created_at, rowid LIMIT 1)
+			  AND status = ?
+			  RETURNING ` + jobColumns
+	job, err := scanJob(db.QueryRow(query, models.JobRunning, models.JobQueued, models.JobQueued))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("领取任务失败: %w", err)
+	}
+	return job, nil
+}
+
+// CompleteJob 标记任务成功并保存结果
+func (db *DB) CompleteJob(id string, result *models.TTSData) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("序列化任务结果失败: %w", err)
+	}
+
+	query := `UPDATE tts_jobs SET status = ?, result = ?, error = NULL, finished_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND status = ?`
+	if _, err := db.Exec(query, models.JobSucceeded, string(data), id, models.JobRunning); err != nil {
+		return fmt.Errorf("更新任务状态失败: %w", err)
+	}
+	return nil
+}
+
+// FinishJob 以失败或取消状态结束运行中的任务
+func (db *DB) FinishJob(id, status, message string) error {
+	query := `UPDATE tts_jobs SET status = ?, error = ?, finished_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND status = ?`
+	if _, err := db.Exec(query, status, message, id, models.JobRunning); err != nil {
+		return fmt.Errorf("更新任务状态失败: %w", err)
+	}
+	return nil
+}
+
+// CancelQueuedJob 取消排队中的任务，任务不在排队状态时返回false
+func (db *DB) CancelQueuedJob(id string) (bool, error) {
+	query := `UPDATE tts_jobs SET status = ?, finished_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?`
+	result, err := db.Exec(query, models.JobCancelled, id, models.JobQueued)
+	if err != nil {
+		return false, fmt.Errorf("取消任务失败: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取影响行数失败: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// RequeueRunningJobs 将运行中的任务重新放回队列，用于服务重启后恢复
+func (db *DB) RequeueRunningJobs() (int64, error) {
+	query := `UPDATE tts_jobs SET status = ?, started_at = NULL WHERE status = ?`
+	result, err := db.Exec(query, models.JobQueued, models.JobRunning)
+	if err != nil {
+		return 0, fmt.Errorf("恢复任务失败: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("获取影响行数失败: %w", err)
+	}
+	return affected, nil
+}
+
+// scanJob 扫描任务记录
+func scanJob(row *sql.Row) (*models.Job, error) {
+	var job models.Job
+	var request string
+	var result, errMsg, callbackURL sql.NullString
+	var startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Status,
+		&request,
+		&result,
+		&errMsg,
+		&callbackURL,
+		&job.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(request), &job.Request); err != nil {
+		return nil, fmt.Errorf("解析任务请求失败: %w", err)
+	}
+	if result.Valid && result.String != "" {
+		job.Result = &models.TTSData{}
+		if err := json.Unmarshal([]byte(result.String), job.Result); err != nil {
+			return nil, fmt.Errorf("解析任务结果失败: %w", err)
+		}
+	}
+	job.Error = errMsg.String
+	job.CallbackURL = callbackURL.String
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"tts-service/internal/models"
+)
+
+const lexiconColumns = `id, user_id, word, alias, phoneme, alphabet, language, created_at, updated_at`
+
+// ErrLexiconConflict 同一词语在同一语言下已有条目
+var ErrLexiconConflict = errors.New("词语已存在")
+
+// ListLexiconEntries 获取用户的全部词典条目，按词语排序
+func (db *DB) ListLexiconEntries(userID int) ([]models.LexiconEntry, error) {
+	rows, err := db.Query(`SELECT `+lexiconColumns+` FROM lexicon_entries WHERE user_id = ? ORDER BY word, language`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询词典失败: %w", err)
+	}
+	return scanLexiconEntries(rows)
+}
+
+// GetLexiconEntry 获取用户的词典条目，不存在时返回nil
+func (db *DB) GetLexiconEntry(userID, id int) (*models.LexiconEntry, error) {
+	rows, err := db.Query(`SELECT `+lexiconColumns+` FROM lexicon_entries WHERE user_id = ? AND id = ?`, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询词典失败: %w", err)
+	}
+	entries, err := scanLexiconEntries(rows)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// CountLexiconEntries 用户的词典条目数
+func (db *DB) CountLexiconEntries(userID int) (int, error) {
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM lexicon_entries WHERE user_id = ?`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("统计词典条目失败: %w", err)
+	}
+	return count, nil
+}
+
+// CreateLexiconEntry 创建词典条目，词语重复时返回ErrLexiconConflict
+func (db *DB) CreateLexiconEntry(entry *models.LexiconEntry) error {
+	query := `INSERT INTO lexicon_entries (user_id, word, alias, phoneme, alphabet, language) VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := db.Exec(query, entry.UserID, entry.Word, entry.Alias, entry.Phoneme, entry.Alphabet, entry.Language)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrLexiconConflict
+		}
+		return fmt.Errorf("创建词典条目失败: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取词典条目ID失败: %w", err)
+	}
+	created, err := db.GetLexiconEntry(entry.UserID, int(id))
+	if err != nil {
+		return err
+	}
+	*entry = *created
+	return nil
+}
+
+// UpdateLexiconEntry 更新词典条目，返回是否找到该条目
+func (db *DB) UpdateLexiconEntry(entry *models.LexiconEntry) (bool, error) {
+	query := `UPDATE lexicon_entries
+			  SET word = ?, alias = ?, phoneme = ?, alphabet = ?, language = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE user_id = ? AND id = ?`
+	result, err := db.Exec(query, entry.Word, entry.Alias, entry.Phoneme, entry.Alphabet, entry.Language, entry.UserID, entry.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return false, ErrLexiconConflict
+		}
+		return false, fmt.Errorf("更新词典条目失败: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	updated, err := db.GetLexiconEntry(entry.UserID, entry.ID)
+	if err != nil {
+		return false, err
+	}
+	*entry = *updated
+	return true, nil
+}
+
+// DeleteLexiconEntry 删除词典条目，返回是否找到该条目
+func (db *DB) DeleteLexiconEntry(userID, id int) (bool, error) {
+	result, err := db.Exec(`DELETE FROM lexicon_entries WHERE user_id = ? AND id = ?`, userID, id)
+	if err != nil {
+		return false, fmt.Errorf("删除词典条目失败: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ImportLexiconEntries 批量导入词典条目，已有的词语覆盖原读法；replace为true时先清空原有词典
+func (db *DB) ImportLexiconEntries(userID int, entries []models.LexiconEntry, replace bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.Exec(`DELETE FROM lexicon_entries WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("清空词典失败: %w", err)
+		}
+	}
+
+	query := `INSERT INTO lexicon_entries (user_id, word, alias, phoneme, alphabet, language) VALUES (?, ?, ?, ?, ?, ?)
+			  ON CONFLICT (user_id, word, language) DO UPDATE
+			  SET alias = excluded.alias, phoneme = excluded.phoneme, alphabet = excluded.alphabet, updated_at = CURRENT_TIMESTAMP`
+	for _, entry := range entries {
+		if _, err := tx.Exec(query, userID, entry.Word, entry.Alias, entry.Phoneme, entry.Alphabet, entry.Language); err != nil {
+			return fmt.Errorf("导入词典条目失败 [%s]: %w", entry.Word, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// scanLexiconEntries 扫描词典条目
+func scanLexiconEntries(rows *sql.Rows) ([]models.LexiconEntry, error) {
+	defer rows.Close()
+
+	entries := []models.LexiconEntry{}
+	for rows.Next() {
+		var e models.LexiconEntry
+		err := rows.Scan(&e.ID, &e.UserID, &e.Word, &e.Alias, &e.Phoneme, &e.Alphabet, &e.Language, &e.CreatedAt, &e.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("扫描词典条目失败: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("扫描词典条目失败: %w", err)
+	}
+	return entries, nil
+}
+
+// isUniqueViolation 是否违反唯一约束
+func isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
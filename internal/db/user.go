@@ -26,7 +26,7 @@ func (db *DB) CreateUser(user *models.User) error {
 // GetUserByAPIKey 通过API Key获取用户
 func (db *DB) GetUserByAPIKey(apiKey string) (*models.User, error) {
 	query := `SELECT id, api_key, name, created_at FROM users WHERE api_key = ?`
-	
+
 	var user models.User
 	err := db.QueryRow(query, apiKey).Scan(
 		&user.ID,
@@ -48,7 +48,7 @@ func (db *DB) GetUserByAPIKey(apiKey string) (*models.User, error) {
 // GetUserByID 通过ID获取用户
 func (db *DB) GetUserByID(id int) (*models.User, error) {
 	query := `SELECT id, api_key, name, created_at FROM users WHERE id = ?`
-	
+
 	var user models.User
 	err := db.QueryRow(query, id).Scan(
 		&user.ID,
@@ -65,4 +65,25 @@ func (db *DB) GetUserByID(id int) (*models.User, error) {
 	}
 
 	return &user, nil
-}
\ No newline at end of file
+}
+
+// GetWebhookSecret 获取用户的Webhook签名密钥，未设置时返回空字符串
+func (db *DB) GetWebhookSecret(userID int) (string, error) {
+	var secret sql.NullString
+	err := db.QueryRow(`SELECT webhook_secret FROM users WHERE id = ?`, userID).Scan(&secret)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("用户不存在")
+		}
+		return "", fmt.Errorf("查询Webhook密钥失败: %w", err)
+	}
+	return secret.String, nil
+}
+
+// SetWebhookSecret 设置用户的Webhook签名密钥
+func (db *DB) SetWebhookSecret(userID int, secret string) error {
+	if _, err := db.Exec(`UPDATE users SET webhook_secret = ? WHERE id = ?`, secret, userID); err != nil {
+		return fmt.Errorf("更新Webhook密钥失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"tts-service/internal/models"
+)
+
+const deliveryColumns = `id, job_id, user_id, url, payload, status, attempts, last_status_code, last_error, next_attempt_at, created_at, updated_at`
+
+// CreateDelivery 创建Webhook投递记录
+func (db *DB) CreateDelivery(delivery *models.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (job_id, user_id, url, payload, status) VALUES (?, ?, ?, ?, ?)`
+	result, err := db.Exec(query, delivery.JobID, delivery.UserID, delivery.URL, delivery.Payload, models.DeliveryPending)
+	if err != nil {
+		return fmt.Errorf("创建Webhook投递记录失败: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取投递记录ID失败: %w", err)
+	}
+
+	delivery.ID = int(id)
+	delivery.Status = models.DeliveryPending
+	return nil
+}
+
+// GetDelivery 获取投递记录，不存在时返回nil
+func (db *DB) GetDelivery(id int) (*models.WebhookDelivery, error) {
+	rows, err := db.Query(`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询投递记录失败: %w", err)
+	}
+	deliveries, err := scanDeliveries(rows)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	return deliveries[0], nil
+}
+
+// ListDeliveriesByJob 获取任务的全部投递记录
+func (db *DB) ListDeliveriesByJob(jobID string) ([]*models.WebhookDelivery, error) {
+	rows, err := db.Query(`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE job_id = ? ORDER BY id`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("查询投递记录失败: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// ListDueDeliveries 获取到期需要投递的记录
+func (db *DB) ListDueDeliveries(limit int) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+			  WHERE status = ? AND next_attempt_at <= ?
+			  ORDER BY next_attempt_at LIMIT ?`
+	rows, err := db.Query(query, models.DeliveryPending, sqlTime(time.Now()), limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询待投递记录失败: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// RecordDeliveryAttempt 记录一次投递结果
+// status为pending时表示需要在nextAttempt重试
+func (db *DB) RecordDeliveryAttempt(id int, status string, statusCode int, lastError string, nextAttempt time.Time) error {
+	query := `UPDATE webhook_deliveries
+			  SET status = ?, attempts = attempts + 1, last_status_code = ?, last_error = ?,
+			      next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ?`
+	if _, err := db.Exec(query, status, statusCode, lastError, sqlTime(nextAttempt), id); err != nil {
+		return fmt.Errorf("更新投递记录失败: %w", err)
+	}
+	return nil
+}
+
+// ResetDelivery 重置投递记录以便重新投递
+func (db *DB) ResetDelivery(id int) error {
+	query := `UPDATE webhook_deliveries
+			  SET status = ?, attempts = 0, last_status_code = NULL, last_error = NULL,
+			      next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ?`
+	if _, err := db.Exec(query, models.DeliveryPending, sqlTime(time.Now()), id); err != nil {
+		return fmt.Errorf("重置投递记录失败: %w", err)
+	}
+	return nil
+}
+
+// scanDeliveries 扫描投递记录
+func scanDeliveries(rows *sql.Rows) ([]*models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var statusCode sql.NullInt64
+		var lastError sql.NullString
+		err := rows.Scan(
+			&d.ID,
+			&d.JobID,
+			&d.UserID,
+			&d.URL,
+			&d.Payload,
+			&d.Status,
+			&d.Attempts,
+			&statusCode,
+			&lastError,
+			&d.NextAttemptAt,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描投递记录失败: %w", err)
+		}
+		d.LastStatusCode = int(statusCode.Int64)
+		d.LastError = lastError.String
+		deliveries = append(deliveries, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("扫描投递记录失败: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// sqlTime 转换为与CURRENT_TIMESTAMP一致的UTC时间字符串，保证可以直接比较
+func sqlTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,206 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"tts-service/internal/db"
+	"tts-service/internal/models"
+	"tts-service/internal/tts"
+	"tts-service/internal/utils"
+)
+
+// 默认工作协程数
+const defaultWorkers = 2
+
+// pollInterval 没有收到新任务通知时的轮询间隔
+const pollInterval = 5 * time.Second
+
+// Notifier 任务结束通知
+type Notifier interface {
+	JobFinished(job *models.Job)
+}
+
+// Manager 异步任务管理器
+// 任务持久化在SQLite中，工作协程从数据库领取任务，服务重启后未完成的任务会重新排队
+type Manager struct {
+	db         *db.DB
+	ttsService *tts.TTSService
+	workers    int
+	notifier   Notifier
+
+	notify chan struct{}
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+}
+
+// NewManager 创建任务管理器
+func NewManager(database *db.DB, ttsService *tts.TTSService, workers int) *Manager {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	return &Manager{
+		db:         database,
+		ttsService: ttsService,
+		workers:    workers,
+		notify:     make(chan struct{}, 1),
+		running:    make(map[string]context.CancelFunc),
+	}
+}
+
+// SetNotifier 设置任务结束通知
+func (m *Manager) SetNotifier(notifier Notifier) {
+	m.notifier = notifier
+}
+
+// Start 恢复中断的任务并启动工作协程
+func (m *Manager) Start(ctx context.Context) error {
+	requeued, err := m.db.RequeueRunningJobs()
+	if err != nil {
+		return err
+	}
+	if requeued > 0 {
+		fmt.Printf("重新排队 %d 个中断的任务\n", requeued)
+	}
+
+	for i := 0; i < m.workers; i++ {
+		go m.worker(ctx)
+	}
+	return nil
+}
+
+// Submit 提交任务，callbackURL不为空时任务结束后发送通知
+func (m *Manager) Submit(userID int, req *models.TTSRequest, callbackURL string) (*models.Job, error) {
+	// 提前校验参数，避免无效任务进入队列；任务保存原始请求，由工作协程规范化一次
+	// 规范化会改写文本并附加不随任务保存的词典条目，不能保存规范化后的请求再处理
+	req.UserID = userID
+	checked := *req
+	checked.Contour = append([]models.ContourPoint(nil), req.Contour...)
+	if err := m.ttsService.NormalizeRequest(&checked); err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		ID:          utils.GenerateRequestID(),
+		UserID:      userID,
+		Status:      models.JobQueued,
+		Request:     *req,
+		CallbackURL: callbackURL,
+	}
+	if err := m.db.CreateJob(job); err != nil {
+		return nil, err
+	}
+
+	m.wake()
+	return job, nil
+}
+
+// Get 获取任务
+func (m *Manager) Get(id string) (*models.Job, error) {
+	return m.db.GetJob(id)
+}
+
+// Cancel 取消任务，排队中的任务直接取消，运行中的任务中断合成
+func (m *Manager) Cancel(id string) (*models.Job, error) {
+	cancelled, err := m.db.CancelQueuedJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	if cancel, ok := m.running[id]; ok {
+		cancel()
+	}
+	m.mu.Unlock()
+
+	job, err := m.db.GetJob(id)
+	if err == nil && cancelled {
+		m.finished(job)
+	}
+	return job, err
+}
+
+// finished 通知任务结束
+func (m *Manager) finished(job *models.Job) {
+	if m.notifier != nil && job != nil {
+		m.notifier.JobFinished(job)
+	}
+}
+
+// wake 通知工作协程有新任务
+func (m *Manager) wake() {
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+}
+
+// worker 循环领取并执行任务
+func (m *Manager) worker(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := m.db.ClaimNextJob()
+		if err != nil {
+			fmt.Printf("领取任务失败: %v\n", err)
+		}
+		if job != nil {
+			m.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// run 执行单个任务
+func (m *Manager) run(ctx context.Context, job *models.Job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	m.mu.Lock()
+	m.running[job.ID] = cancel
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, job.ID)
+		m.mu.Unlock()
+	}()
+
+	req := job.Request
+	req.UserID = job.UserID
+	result, err := m.ttsService.StreamTTSRequest(jobCtx, &req, nil)
+
+	switch {
+	case err == nil:
+		result.TaskID = job.ID
+		err = m.db.CompleteJob(job.ID, result)
+	case jobCtx.Err() != nil && ctx.Err() == nil:
+		err = m.db.FinishJob(job.ID, models.JobCancelled, "任务已取消")
+	case ctx.Err() != nil:
+		// 服务关闭，保持运行中状态，重启后重新排队
+		return
+	default:
+		err = m.db.FinishJob(job.ID, models.JobFailed, err.Error())
+	}
+
+	if err != nil {
+		fmt.Printf("更新任务 %s 状态失败: %v\n", job.ID, err)
+		return
+	}
+
+	finished, err := m.db.GetJob(job.ID)
+	if err != nil {
+		fmt.Printf("查询任务 %s 失败: %v\n", job.ID, err)
+		return
+	}
+	m.finished(finished)
+}
@@ -0,0 +1,201 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 文字系统
+const (
+	scriptNone = iota
+	scriptHan
+	scriptKana
+	scriptHangul
+	scriptLatin
+	scriptCyrillic
+	scriptGreek
+	scriptArabic
+	scriptHebrew
+	scriptThai
+	scriptDevanagari
+)
+
+// 非拉丁文字对应的语言，汉字和假名需要一起判断
+var scriptLanguages = map[int]string{
+	scriptHangul:     "ko",
+	scriptGreek:      "el",
+	scriptArabic:     "ar",
+	scriptHebrew:     "he",
+	scriptThai:       "th",
+	scriptDevanagari: "hi",
+}
+
+// 拉丁文字语言的常用词，用于区分英语和其他欧洲语言
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "in", "that", "it", "you", "this", "with", "for", "was", "have", "not", "be", "on", "what", "how"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "un", "du", "que", "pas", "pour", "dans", "ce", "je", "vous", "nous", "avec", "sur", "qui"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "ich", "mit", "sie", "es", "den", "zu", "auf", "für", "von", "wir", "auch", "sind"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "de", "en", "un", "una", "por", "con", "para", "no", "se", "está", "como", "pero", "muy"},
+	"it": {"il", "lo", "gli", "e", "è", "che", "di", "un", "una", "per", "non", "sono", "con", "della", "questo", "come", "anche", "ma", "mi", "ho"},
+	"pt": {"o", "os", "as", "e", "é", "que", "de", "um", "uma", "não", "com", "para", "do", "da", "em", "você", "está", "mas", "muito", "são"},
+	"nl": {"de", "het", "een", "en", "is", "niet", "van", "ik", "je", "dat", "die", "zijn", "op", "met", "voor", "er", "maar", "ook", "wat", "hoe"},
+}
+
+// Run 同一语言的一段连续文本
+type Run struct {
+	Text     string
+	Language string
+}
+
+// Detect 检测文本的主要语言，返回ISO 639-1语言代码，无法判断时返回空字符串
+// 先按文字系统判断，拉丁文字再根据常用词区分具体语言，默认为英语
+func Detect(text string) string {
+	// 表意文字和音节文字按字计数，字母文字按词计数，避免夹杂的外文单词因字母多而占优
+	counts := make(map[int]int)
+	prev := scriptNone
+	for _, r := range text {
+		s := script(r)
+		if s != scriptNone && (!alphabetic(s) || s != prev) {
+			counts[s]++
+		}
+		prev = s
+	}
+
+	best, bestCount := scriptNone, 0
+	for s, count := range counts {
+		// 日文中汉字和假名混用，合并计数
+		if s == scriptHan || s == scriptKana {
+			count = counts[scriptHan] + counts[scriptKana]
+		}
+		if count > bestCount || (count == bestCount && s < best) {
+			best, bestCount = s, count
+		}
+	}
+
+	switch best {
+	case scriptNone:
+		return ""
+	case scriptHan, scriptKana:
+		if counts[scriptKana] > 0 {
+			return "ja"
+		}
+		return "zh"
+	case scriptCyrillic:
+		if strings.ContainsAny(strings.ToLower(text), "іїєґ") {
+			return "uk"
+		}
+		return "ru"
+	case scriptLatin:
+		return detectLatin(text)
+	default:
+		return scriptLanguages[best]
+	}
+}
+
+// Split 按文字系统把文本切分为不同语言的片段，标点、数字和空白归入前一段
+// 相邻的同语言片段会合并，全部是同一语言时只返回一段
+func Split(text string) []Run {
+	type segment struct {
+		text   strings.Builder
+		script int
+	}
+
+	var segments []*segment
+	var current *segment
+	for _, r := range text {
+		s := script(r)
+		// 汉字和假名同属日文，不单独切开
+		if s == scriptKana {
+			s = scriptHan
+		}
+		if current == nil || (s != scriptNone && current.script != scriptNone && s != current.script) {
+			current = &segment{script: s}
+			segments = append(segments, current)
+		}
+		if current.script == scriptNone {
+			current.script = s
+		}
+		current.text.WriteRune(r)
+	}
+
+	var runs []Run
+	for _, seg := range segments {
+		text := seg.text.String()
+		lang := Detect(text)
+		if len(runs) > 0 && (lang == "" || runs[len(runs)-1].Language == lang) {
+			runs[len(runs)-1].Text += text
+			continue
+		}
+		runs = append(runs, Run{Text: text, Language: lang})
+	}
+
+	// 开头只有标点的片段归入下一段
+	if len(runs) > 1 && runs[0].Language == "" {
+		runs[1].Text = runs[0].Text + runs[1].Text
+		runs = runs[1:]
+	}
+	return runs
+}
+
+// detectLatin 根据常用词出现次数判断拉丁文字的语言
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	scores := make(map[string]int)
+	for _, word := range words {
+		for lang, list := range stopwords {
+			for _, stopword := range list {
+				if word == stopword {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestScore := "en", 0
+	for _, lang := range []string{"en", "fr", "de", "es", "it", "pt", "nl"} {
+		if scores[lang] > bestScore {
+			best, bestScore = lang, scores[lang]
+		}
+	}
+	return best
+}
+
+// script 字符所属的文字系统，标点、数字和空白返回scriptNone
+func script(r rune) int {
+	switch {
+	case unicode.Is(unicode.Han, r):
+		return scriptHan
+	case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+		return scriptKana
+	case unicode.Is(unicode.Hangul, r):
+		return scriptHangul
+	case unicode.Is(unicode.Latin, r):
+		return scriptLatin
+	case unicode.Is(unicode.Cyrillic, r):
+		return scriptCyrillic
+	case unicode.Is(unicode.Greek, r):
+		return scriptGreek
+	case unicode.Is(unicode.Arabic, r):
+		return scriptArabic
+	case unicode.Is(unicode.Hebrew, r):
+		return scriptHebrew
+	case unicode.Is(unicode.Thai, r):
+		return scriptThai
+	case unicode.Is(unicode.Devanagari, r):
+		return scriptDevanagari
+	}
+	return scriptNone
+}
+
+// alphabetic 是否为以空格分词的字母文字
+func alphabetic(s int) bool {
+	switch s {
+	case scriptLatin, scriptCyrillic, scriptGreek, scriptArabic, scriptHebrew, scriptDevanagari:
+		return true
+	}
+	return false
+}
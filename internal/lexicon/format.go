@@ -0,0 +1,186 @@
+package lexicon
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"tts-service/internal/models"
+)
+
+// 导入导出格式
+const (
+	FormatCSV = "csv"
+	FormatPLS = "pls"
+)
+
+// Formats 支持的导入导出格式
+var Formats = []string{FormatCSV, FormatPLS}
+
+// csvHeader CSV的列，导入时表头可以省略，省略时按该顺序读取
+var csvHeader = []string{"word", "alias", "phoneme", "alphabet", "language"}
+
+// ParseCSV 解析CSV格式的词典，行号从1开始计算，包含表头
+func ParseCSV(r io.Reader) ([]models.LexiconEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []models.LexiconEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析CSV失败: %w", err)
+		}
+		if line == 1 {
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+			if strings.EqualFold(strings.TrimSpace(record[0]), csvHeader[0]) {
+				continue
+			}
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(record) > len(csvHeader) {
+			return nil, fmt.Errorf("第 %d 行: 列数不能超过 %d", line, len(csvHeader))
+		}
+
+		fields := make([]string, len(csvHeader))
+		copy(fields, record)
+		entry := models.LexiconEntry{Word: fields[0], Alias: fields[1], Phoneme: fields[2], Alphabet: fields[3], Language: fields[4]}
+		if err := Validate(&entry); err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// WriteCSV 以CSV格式写出词典，第一行为表头
+func WriteCSV(w io.Writer, entries []models.LexiconEntry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := writer.Write([]string{e.Word, e.Alias, e.Phoneme, e.Alphabet, e.Language}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// PLS (Pronunciation Lexicon Specification 1.0) 文档结构
+const plsNamespace = "http://www.w3.org/2005/01/pronunciation-lexicon"
+
+type plsLexicon struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/01/pronunciation-lexicon lexicon"`
+	Version  string      `xml:"version,attr"`
+	Alphabet string      `xml:"alphabet,attr,omitempty"`
+	Lang     string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Lexemes  []plsLexeme `xml:"lexeme"`
+}
+
+type plsLexeme struct {
+	Graphemes []string     `xml:"grapheme"`
+	Phonemes  []plsPhoneme `xml:"phoneme"`
+	Aliases   []string     `xml:"alias"`
+}
+
+type plsPhoneme struct {
+	Alphabet string `xml:"alphabet,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+// ParsePLS 解析PLS格式的词典
+// 一个lexeme有多个grapheme时每个词语生成一条条目，有多个读法时使用第一个；
+// 词典的xml:lang作为条目的语言，"und"表示不限语言
+func ParsePLS(r io.Reader) ([]models.LexiconEntry, error) {
+	var doc plsLexicon
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("解析PLS失败: %w", err)
+	}
+	if doc.XMLName.Space != plsNamespace {
+		return nil, fmt.Errorf("解析PLS失败: 根元素必须是 %s 命名空间下的 lexicon", plsNamespace)
+	}
+
+	lang := doc.Lang
+	if strings.EqualFold(lang, "und") {
+		lang = ""
+	}
+
+	var entries []models.LexiconEntry
+	for i, lexeme := range doc.Lexemes {
+		if len(lexeme.Graphemes) == 0 {
+			return nil, fmt.Errorf("第 %d 个lexeme: 缺少grapheme", i+1)
+		}
+		reading := models.LexiconEntry{Language: lang}
+		switch {
+		case len(lexeme.Phonemes) > 0:
+			reading.Phoneme = lexeme.Phonemes[0].Value
+			reading.Alphabet = lexeme.Phonemes[0].Alphabet
+			if reading.Alphabet == "" {
+				reading.Alphabet = doc.Alphabet
+			}
+		case len(lexeme.Aliases) > 0:
+			reading.Alias = lexeme.Aliases[0]
+		default:
+			return nil, fmt.Errorf("第 %d 个lexeme: 缺少phoneme或alias", i+1)
+		}
+
+		for _, grapheme := range lexeme.Graphemes {
+			entry := reading
+			entry.Word = grapheme
+			if err := Validate(&entry); err != nil {
+				return nil, fmt.Errorf("第 %d 个lexeme: %w", i+1, err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// WritePLS 以PLS格式写出词典
+// PLS只能在词典上标注一种语言，条目语言不一致时写为"und"，需要保留语言时使用CSV格式
+func WritePLS(w io.Writer, entries []models.LexiconEntry) error {
+	doc := plsLexicon{Version: "1.0", Alphabet: AlphabetIPA, Lang: "und"}
+	for i, e := range entries {
+		if i == 0 {
+			doc.Lang = e.Language
+		} else if doc.Lang != e.Language {
+			doc.Lang = "und"
+		}
+
+		lexeme := plsLexeme{Graphemes: []string{e.Word}}
+		if e.Alias != "" {
+			lexeme.Aliases = []string{e.Alias}
+		} else {
+			phoneme := plsPhoneme{Value: e.Phoneme}
+			if e.Alphabet != doc.Alphabet {
+				phoneme.Alphabet = e.Alphabet
+			}
+			lexeme.Phonemes = []plsPhoneme{phoneme}
+		}
+		doc.Lexemes = append(doc.Lexemes, lexeme)
+	}
+	if doc.Lang == "" {
+		doc.Lang = "und"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("生成PLS失败: %w", err)
+	}
+	buf.WriteString("\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,186 @@
+package lexicon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"tts-service/internal/models"
+	"tts-service/internal/ssml"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 音标字母表，pinyin为带声调的拼音，合成时转换为sapi
+const (
+	AlphabetIPA    = "ipa"
+	AlphabetSAPI   = "sapi"
+	AlphabetXSAMPA = "x-sampa"
+	AlphabetUPS    = "ups"
+	AlphabetPinyin = "pinyin"
+)
+
+// Alphabets 支持的音标字母表
+var Alphabets = []string{AlphabetIPA, AlphabetSAPI, AlphabetXSAMPA, AlphabetUPS, AlphabetPinyin}
+
+// 词语和读法的最大长度
+const (
+	maxWordLength    = 100
+	maxReadingLength = 500
+)
+
+// Validate 校验并整理条目：去掉首尾空白，音标默认使用IPA，拼音检查能否转换
+func Validate(entry *models.LexiconEntry) error {
+	entry.Word = strings.TrimSpace(entry.Word)
+	entry.Alias = strings.TrimSpace(entry.Alias)
+	entry.Phoneme = strings.TrimSpace(entry.Phoneme)
+	entry.Alphabet = strings.ToLower(strings.TrimSpace(entry.Alphabet))
+	entry.Language = strings.TrimSpace(entry.Language)
+
+	switch {
+	case entry.Word == "":
+		return fmt.Errorf("词语不能为空")
+	case utf8.RuneCountInString(entry.Word) > maxWordLength:
+		return fmt.Errorf("词语不能超过 %d 个字符", maxWordLength)
+	case (entry.Alias == "") == (entry.Phoneme == ""):
+		return fmt.Errorf("词语 %s 需要指定 alias 或 phoneme 中的一个", entry.Word)
+	case utf8.RuneCountInString(entry.Alias+entry.Phoneme) > maxReadingLength:
+		return fmt.Errorf("词语 %s 的读法不能超过 %d 个字符", entry.Word, maxReadingLength)
+	}
+
+	if entry.Alias != "" {
+		entry.Alphabet = ""
+		return nil
+	}
+	if entry.Alphabet == "" {
+		entry.Alphabet = AlphabetIPA
+	}
+	switch entry.Alphabet {
+	case AlphabetIPA, AlphabetSAPI, AlphabetXSAMPA, AlphabetUPS:
+	case AlphabetPinyin:
+		if _, err := PinyinToSAPI(entry.Phoneme); err != nil {
+			return fmt.Errorf("词语 %s 的拼音无效: %w", entry.Word, err)
+		}
+	default:
+		return fmt.Errorf("词语 %s 的音标字母表 %s 无效，可选值: %s", entry.Word, entry.Alphabet, strings.Join(Alphabets, ", "))
+	}
+	return nil
+}
+
+// Applies 条目是否适用于该区域的语音，条目的语言可以是完整区域(zh-CN)或语言(zh)
+func Applies(entry models.LexiconEntry, locale string) bool {
+	if entry.Language == "" {
+		return true
+	}
+	return strings.EqualFold(entry.Language, locale) ||
+		strings.HasPrefix(strings.ToLower(locale), strings.ToLower(entry.Language)+"-")
+}
+
+// Filter 筛选适用于该区域、且在文本中出现的条目
+func Filter(entries []models.LexiconEntry, locale, text string) []models.LexiconEntry {
+	var result []models.LexiconEntry
+	for _, entry := range entries {
+		if Applies(entry, locale) && strings.Contains(text, entry.Word) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// Version 条目内容的摘要，没有条目时为空字符串
+// 只对文本中用到的条目计算，修改无关条目不会使已有缓存失效
+func Version(entries []models.LexiconEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	type key struct {
+		Word, Alias, Phoneme, Alphabet, Language string
+	}
+	keys := make([]key, len(entries))
+	for i, e := range entries {
+		keys[i] = key{e.Word, e.Alias, e.Phoneme, e.Alphabet, e.Language}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Word != keys[j].Word {
+			return keys[i].Word < keys[j].Word
+		}
+		return keys[i].Language < keys[j].Language
+	})
+
+	data, _ := json.Marshal(keys)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Write 把文本写入SSML，词典中的词语写为 <sub alias> 或 <phoneme>，其余文本由构建器转义
+// 优先匹配最长的词语；字母和数字组成的词语要求前后不紧邻字母或数字，避免匹配到单词内部
+func Write(b *ssml.Builder, text string, entries []models.LexiconEntry) {
+	if len(entries) == 0 {
+		b.Text(text)
+		return
+	}
+
+	byFirst := make(map[rune][]models.LexiconEntry)
+	for _, entry := range entries {
+		first, _ := utf8.DecodeRuneInString(entry.Word)
+		byFirst[first] = append(byFirst[first], entry)
+	}
+	for _, list := range byFirst {
+		sort.SliceStable(list, func(i, j int) bool { return len(list[i].Word) > len(list[j].Word) })
+	}
+
+	prev := rune(0)
+	plain := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		matched := false
+		for _, entry := range byFirst[r] {
+			if !strings.HasPrefix(text[i:], entry.Word) {
+				continue
+			}
+			next, _ := utf8.DecodeRuneInString(text[i+len(entry.Word):])
+			last, _ := utf8.DecodeLastRuneInString(entry.Word)
+			if (spaced(r) && spaced(prev)) || (spaced(last) && spaced(next)) {
+				continue
+			}
+
+			b.Text(text[plain:i])
+			writeEntry(b, entry)
+			prev = last
+			i += len(entry.Word)
+			plain = i
+			matched = true
+			break
+		}
+		if !matched {
+			prev = r
+			i += size
+		}
+	}
+	b.Text(text[plain:])
+}
+
+func writeEntry(b *ssml.Builder, entry models.LexiconEntry) {
+	if entry.Alias != "" {
+		b.Element("sub", entry.Word, "alias", entry.Alias)
+		return
+	}
+
+	alphabet, phoneme := entry.Alphabet, entry.Phoneme
+	if alphabet == AlphabetPinyin {
+		alphabet = AlphabetSAPI
+		phoneme, _ = PinyinToSAPI(phoneme)
+	}
+	b.Element("phoneme", entry.Word, "alphabet", alphabet, "ph", phoneme)
+}
+
+// spaced 以空格分词的文字，这类词语需要完整匹配
+func spaced(r rune) bool {
+	if r == 0 || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,69 @@
+package lexicon
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 带声调符号的韵母，转换为不带符号的字母和声调
+var toneMarks = map[rune]struct {
+	letter rune
+	tone   int
+}{
+	'ā': {'a', 1}, 'á': {'a', 2}, 'ǎ': {'a', 3}, 'à': {'a', 4},
+	'ē': {'e', 1}, 'é': {'e', 2}, 'ě': {'e', 3}, 'è': {'e', 4},
+	'ī': {'i', 1}, 'í': {'i', 2}, 'ǐ': {'i', 3}, 'ì': {'i', 4},
+	'ō': {'o', 1}, 'ó': {'o', 2}, 'ǒ': {'o', 3}, 'ò': {'o', 4},
+	'ū': {'u', 1}, 'ú': {'u', 2}, 'ǔ': {'u', 3}, 'ù': {'u', 4},
+	'ǖ': {'v', 1}, 'ǘ': {'v', 2}, 'ǚ': {'v', 3}, 'ǜ': {'v', 4},
+	'ü': {'v', 5},
+}
+
+// PinyinToSAPI 把拼音转换为中文语音使用的SAPI音标，如 "chóng qìng" 或 "chong2 qing4" 转换为 "chong 2 qing 4"
+// 音节之间用空格、连字符或撇号分隔，没有声调的音节按轻声(5)处理
+func PinyinToSAPI(pinyin string) (string, error) {
+	syllables := strings.FieldsFunc(strings.ToLower(pinyin), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-' || r == '\'' || r == '’'
+	})
+	if len(syllables) == 0 {
+		return "", fmt.Errorf("拼音不能为空")
+	}
+
+	parts := make([]string, 0, len(syllables))
+	for _, syllable := range syllables {
+		var letters strings.Builder
+		tone := 0
+		for _, r := range syllable {
+			switch {
+			case r >= 'a' && r <= 'z':
+				letters.WriteRune(r)
+			case r >= '1' && r <= '5':
+				if tone != 0 {
+					return "", fmt.Errorf("音节 %s 有多个声调", syllable)
+				}
+				tone = int(r - '0')
+			default:
+				mark, ok := toneMarks[r]
+				if !ok {
+					return "", fmt.Errorf("音节 %s 包含无效字符 %q", syllable, r)
+				}
+				if mark.tone != 5 {
+					if tone != 0 {
+						return "", fmt.Errorf("音节 %s 有多个声调", syllable)
+					}
+					tone = mark.tone
+				}
+				letters.WriteRune(mark.letter)
+			}
+		}
+		if letters.Len() == 0 {
+			return "", fmt.Errorf("音节 %s 缺少字母", syllable)
+		}
+		if tone == 0 {
+			tone = 5
+		}
+		parts = append(parts, fmt.Sprintf("%s %d", letters.String(), tone))
+	}
+	return strings.Join(parts, " "), nil
+}
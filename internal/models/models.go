@@ -14,32 +14,143 @@ type User struct {
 
 // TTSCache TTS缓存模型
 type TTSCache struct {
-	ID        int       `json:"id" db:"id"`
-	TextHash  string    `json:"text_hash" db:"text_hash"`
-	Voice     string    `json:"voice" db:"voice"`
-	Format    string    `json:"format" db:"format"`
-	AudioPath string    `json:"audio_path" db:"audio_path"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID         int       `json:"id" db:"id"`
+	TextHash   string    `json:"text_hash" db:"text_hash"`
+	Voice      string    `json:"voice" db:"voice"`
+	Format     string    `json:"format" db:"format"`
+	AudioPath  string    `json:"audio_path" db:"audio_path"`
+	KeyVersion int       `json:"key_version" db:"key_version"`
+	HitCount   int       `json:"hit_count" db:"hit_count"`
+	Pinned     bool      `json:"pinned" db:"pinned"` // 固定的缓存不会因容量限制被淘汰
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	// LastAccessedAt 最近一次命中缓存或下载音频的时间，升级前的记录为空
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty" db:"last_accessed_at"`
 }
 
 // TTSRequest TTS请求模型
 type TTSRequest struct {
-	Text   string  `json:"text" binding:"required"`
-	Voice  string  `json:"voice"`
-	Format string  `json:"format"`
-	Speed  float64 `json:"speed"`
-	Pitch  int     `json:"pitch"`
-	Volume float64 `json:"volume"`
-	Style  string  `json:"style"`
-	SSML   bool    `json:"ssml"`
+	Text   string       `json:"text" binding:"required"`
+	Voice  string       `json:"voice"`
+	Format string       `json:"format"`
+	Speed  float64      `json:"speed"`
+	Pitch  ProsodyValue `json:"pitch"`
+	Volume ProsodyValue `json:"volume"`
+	Style  string       `json:"style"`
+	SSML   bool         `json:"ssml"`
+	Engine string       `json:"engine"`
+
+	// 语速的百分比(+20%)或关键字(x-slow…x-fast)写法，指定时覆盖Speed
+	Rate string `json:"rate"`
+	// 音调曲线，按位置排列
+	Contour []ContourPoint `json:"contour,omitempty"`
+
+	// 使用的发音词典版本，由服务端填充并计入缓存键
+	LexiconVersion string `json:"-"`
+	// 请求所属的用户和文本中用到的词典条目，由服务端填充，合成SSML时改写为 <sub>/<phoneme>
+	UserID  int            `json:"-"`
+	Lexicon []LexiconEntry `json:"-"`
+	// 已经过规范化和校验，再次处理时跳过；修改文本后需要重置
+	Normalized bool `json:"-"`
+
+	// 说话风格强度(0.01-2)和角色扮演，与Style一起渲染为 <mstts:express-as>
+	StyleDegree float64 `json:"styledegree"`
+	Role        string  `json:"role"`
+
+	// 混合语言模式，按语言把文本切分成多段，每段使用对应语言的语音朗读
+	MixedLanguage bool `json:"mixed_language"`
+	// 混合语言模式下由服务端按长文本分段生成的SSML文档，每段一个，计入缓存键
+	Documents []string `json:"-"`
+
+	// 合成前的文本规范化选项，为空时使用配置中的默认值
+	Normalize *NormalizeOptions `json:"normalize,omitempty"`
+
+	// 边界元数据选项，开启后返回逐词/逐句的时间信息
+	WordBoundary     bool `json:"word_boundary"`
+	SentenceBoundary bool `json:"sentence_boundary"`
+
+	// 字幕格式，srt或vtt，为空时不生成字幕
+	Subtitles string `json:"subtitles"`
+
+	// 为true时直接以流的方式返回音频数据而不是JSON
+	Stream bool `json:"stream"`
+}
+
+// LexiconEntry 发音词典条目，Alias和Phoneme二选一
+type LexiconEntry struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"-"`
+	Word      string    `json:"word"`
+	Alias     string    `json:"alias,omitempty"`    // 替换读法，渲染为 <sub alias>
+	Phoneme   string    `json:"phoneme,omitempty"`  // 音标，渲染为 <phoneme>
+	Alphabet  string    `json:"alphabet,omitempty"` // 音标字母表: ipa、sapi、x-sampa、ups、pinyin
+	Language  string    `json:"language,omitempty"` // 适用的语言或区域，为空时适用于所有语音
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// VoiceAlias OpenAI兼容接口的语音别名，按输入文本的语言选择语音并附带默认韵律
+type VoiceAlias struct {
+	ID          int               `json:"id,omitempty"`
+	UserID      int               `json:"-"`
+	Name        string            `json:"name"`
+	Voice       string            `json:"voice,omitempty"`   // 默认语音，为空时按语言自动选择
+	Locales     map[string]string `json:"locales,omitempty"` // 语言代码(zh、en…)对应的语音
+	Speed       float64           `json:"speed,omitempty"`   // 语速倍数，与请求中的speed相乘
+	Pitch       string            `json:"pitch,omitempty"`
+	Volume      string            `json:"volume,omitempty"`
+	Style       string            `json:"style,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Gender      string            `json:"gender,omitempty"`
+	Source      string            `json:"source"` // 别名来源: builtin、config、user
+	CreatedAt   *time.Time        `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time        `json:"updated_at,omitempty"`
+}
+
+// 语音别名来源
+const (
+	VoiceAliasBuiltin = "builtin" // 内置别名
+	VoiceAliasConfig  = "config"  // 配置文件 openai.voices
+	VoiceAliasUser    = "user"    // 当前API Key自定义的别名
+)
+
+// NormalizeOptions 文本规范化选项，未指定的项使用配置中的默认值
+type NormalizeOptions struct {
+	Enable  bool            `json:"enable"`          // 开启全部规则
+	Disable bool            `json:"disable"`         // 关闭全部规则，同时指定时以disable为准
+	Rules   map[string]bool `json:"rules,omitempty"` // 按名称开关单条规则，优先于enable和disable
+	URL     string          `json:"url,omitempty"`   // 网址处理方式: strip、replace、keep
+	Emoji   string          `json:"emoji,omitempty"` // 表情处理方式: strip、replace、keep
+}
+
+// NormalizeRequest 文本规范化调试请求
+type NormalizeRequest struct {
+	Text      string            `json:"text" binding:"required"`
+	Voice     string            `json:"voice"`
+	Engine    string            `json:"engine"`
+	Language  string            `json:"language"` // 指定时不再根据语音和文本判断语言
+	Normalize *NormalizeOptions `json:"normalize,omitempty"`
+}
+
+// NormalizeResult 文本规范化结果，Steps按执行顺序记录改变了文本的规则
+type NormalizeResult struct {
+	Language string          `json:"language"`
+	Voice    string          `json:"voice,omitempty"`
+	Text     string          `json:"text"`
+	Steps    []NormalizeStep `json:"steps"`
+}
+
+// NormalizeStep 单条规则执行后的文本
+type NormalizeStep struct {
+	Rule string `json:"rule"`
+	Text string `json:"text"`
 }
 
 // TTSResponse TTS响应模型
 type TTSResponse struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    *TTSData    `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Code    int      `json:"code"`
+	Message string   `json:"message"`
+	Data    *TTSData `json:"data,omitempty"`
+	Error   string   `json:"error,omitempty"`
 }
 
 // TTSData TTS数据模型
@@ -48,20 +159,179 @@ type TTSData struct {
 	Duration float64 `json:"duration,omitempty"`
 	Size     int64   `json:"size,omitempty"`
 	TaskID   string  `json:"task_id"`
+
+	Boundaries  []Boundary `json:"boundaries,omitempty"`
+	SubtitleURL string     `json:"subtitle_url,omitempty"`
+}
+
+// 边界事件类型
+const (
+	WordBoundary     = "WordBoundary"
+	SentenceBoundary = "SentenceBoundary"
+)
+
+// Boundary 词/句边界事件，时间单位为毫秒
+type Boundary struct {
+	Type     string `json:"type"`
+	Offset   int64  `json:"offset"`
+	Duration int64  `json:"duration"`
+	Text     string `json:"text"`
+}
+
+// Voice 语音模型
+type Voice struct {
+	Name        string   `json:"name"`
+	Language    string   `json:"language"`
+	Gender      string   `json:"gender"`
+	Description string   `json:"description"`
+	Engine      string   `json:"engine"`
+	Styles      []string `json:"styles,omitempty"` // 支持的说话风格
+	Roles       []string `json:"roles,omitempty"`  // 支持的角色扮演
+
+	Personalities []string `json:"personalities,omitempty"` // 声音特点，如 Warm、Friendly
+	Formats       []string `json:"formats,omitempty"`       // 支持的音频格式
 }
 
 // OpenAITTSRequest OpenAI兼容的TTS请求模型
+// 必填字段由处理器校验，以便按OpenAI的格式返回缺少的参数名
 type OpenAITTSRequest struct {
-	Model          string  `json:"model" binding:"required"`
-	Input          string  `json:"input" binding:"required"`
-	Voice          string  `json:"voice" binding:"required"`
-	ResponseFormat string  `json:"response_format,omitempty"`
-	Speed          float64 `json:"speed,omitempty"`
+	Model          string   `json:"model"`
+	Input          string   `json:"input"`
+	Voice          string   `json:"voice"`
+	Instructions   string   `json:"instructions,omitempty"`    // 语气和风格描述，映射为说话风格和韵律
+	ResponseFormat string   `json:"response_format,omitempty"` // mp3/opus/aac/flac/wav/pcm，默认mp3
+	Speed          *float64 `json:"speed,omitempty"`           // 0.25-4.0，默认1
+	StreamFormat   string   `json:"stream_format,omitempty"`   // audio或sse，默认audio
+}
+
+// 异步任务状态
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Job 异步合成任务
+type Job struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      int        `json:"-" db:"user_id"`
+	Status      string     `json:"status" db:"status"`
+	Request     TTSRequest `json:"request" db:"request"`
+	Result      *TTSData   `json:"result,omitempty" db:"result"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	CallbackURL string     `json:"callback_url,omitempty" db:"callback_url"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// JobRequest 提交异步任务的请求，callback_url 用于接收完成通知
+type JobRequest struct {
+	TTSRequest
+	CallbackURL string `json:"callback_url"`
+}
+
+// 批量合成输出方式
+const (
+	BatchOutputZip    = "zip"
+	BatchOutputMerged = "merged"
+)
+
+// BatchRequest 批量合成请求
+type BatchRequest struct {
+	Items     []TTSRequest `json:"items" binding:"required"`
+	Output    string       `json:"output"`     // zip 或 merged，默认 zip
+	Format    string       `json:"format"`     // merged 时的输出格式，所有条目统一使用该格式
+	SilenceMs int          `json:"silence_ms"` // merged 时条目之间插入的静音时长
+}
+
+// Audiobook 有声书转换任务，状态沿用异步任务的状态值
+type Audiobook struct {
+	ID            string             `json:"id"`
+	UserID        int                `json:"-"`
+	Title         string             `json:"title"`
+	Author        string             `json:"author"`
+	Source        string             `json:"source"`
+	SourceHash    string             `json:"-"`
+	Request       TTSRequest         `json:"request"` // 章节合成参数，Text为空
+	Status        string             `json:"status"`
+	TotalChapters int                `json:"total_chapters"`
+	DoneChapters  int                `json:"done_chapters"`
+	Error         string             `json:"error,omitempty"`
+	BookURL       string             `json:"book_url,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	Chapters      []AudiobookChapter `json:"chapters,omitempty"`
+}
+
+// AudiobookChapter 有声书章节，完成后保存音频路径，用于断点续传
+type AudiobookChapter struct {
+	Index     int     `json:"index"`
+	Title     string  `json:"title"`
+	Text      string  `json:"-"`
+	Status    string  `json:"status"`
+	Duration  float64 `json:"duration,omitempty"`
+	AudioPath string  `json:"-"`
+	AudioURL  string  `json:"audio_url,omitempty"`
+}
+
+// BatchManifestItem ZIP清单中的单条记录
+type BatchManifestItem struct {
+	Index    int     `json:"index"`
+	File     string  `json:"file"`
+	Text     string  `json:"text"`
+	Voice    string  `json:"voice"`
+	Engine   string  `json:"engine"`
+	Format   string  `json:"format"`
+	Speed    float64 `json:"speed"`
+	Rate     string  `json:"rate,omitempty"`
+	Pitch    string  `json:"pitch"`
+	Volume   string  `json:"volume"`
+	Duration float64 `json:"duration"`
+	Size     int64   `json:"size"`
+	AudioURL string  `json:"audio_url"`
+}
+
+// Webhook投递状态
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery Webhook投递记录
+type WebhookDelivery struct {
+	ID             int       `json:"id" db:"id"`
+	JobID          string    `json:"job_id" db:"job_id"`
+	UserID         int       `json:"-" db:"user_id"`
+	URL            string    `json:"url" db:"url"`
+	Payload        string    `json:"payload" db:"payload"`
+	Status         string    `json:"status" db:"status"`
+	Attempts       int       `json:"attempts" db:"attempts"`
+	LastStatusCode int       `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      string    `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt  time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookPayload 任务完成通知内容
+type WebhookPayload struct {
+	Event    string  `json:"event"`
+	JobID    string  `json:"job_id"`
+	Status   string  `json:"status"`
+	AudioURL string  `json:"audio_url,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+	Size     int64   `json:"size,omitempty"`
+	Error    string  `json:"error,omitempty"`
 }
 
 // ErrorResponse 错误响应模型
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Error   string `json:"error"`
-}
\ No newline at end of file
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Error   string      `json:"error"`
+	Details interface{} `json:"details,omitempty"` // 结构化错误信息，如SSML校验失败的位置
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// ProsodyValue 韵律参数，JSON中可以是数字或字符串
+// 数字保持旧版接口的含义（音调为Hz，音量为倍数），字符串支持百分比、半音和关键字
+type ProsodyValue string
+
+// UnmarshalJSON 同时接受数字和字符串
+func (v *ProsodyValue) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*v = ""
+		return nil
+	}
+
+	var number float64
+	if err := json.Unmarshal(data, &number); err == nil {
+		*v = ProsodyValue(strconv.FormatFloat(number, 'f', -1, 64))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*v = ProsodyValue(s)
+	return nil
+}
+
+// ContourPoint 音调曲线上的点，Position为在文本中的位置(0-100)
+type ContourPoint struct {
+	Position float64      `json:"position"`
+	Pitch    ProsodyValue `json:"pitch"`
+}
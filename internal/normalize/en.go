@@ -0,0 +1,453 @@
+package normalize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	enOnes   = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	enTens   = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	enScales = []string{"", "thousand", "million", "billion", "trillion"}
+	enMonths = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+
+	// 月份全称或三个字母的缩写
+	enMonthPattern = `(Jan(?:uary)?|Feb(?:ruary)?|Mar(?:ch)?|Apr(?:il)?|May|June?|July?|Aug(?:ust)?|Sep(?:t(?:ember)?)?|Oct(?:ober)?|Nov(?:ember)?|Dec(?:ember)?)\.?`
+
+	enISODate   = regexp.MustCompile(`(\d{4})-(\d{1,2})-(\d{1,2})`)
+	enSlashDate = regexp.MustCompile(`(\d{1,2})/(\d{1,2})/(\d{4})`)
+	enMonthDate = regexp.MustCompile(enMonthPattern + `\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+(\d{4}))?`)
+	enDayMonth  = regexp.MustCompile(`(\d{1,2})(?:st|nd|rd|th)?\s+` + enMonthPattern + `(?:,?\s+(\d{4}))?`)
+	enClock     = regexp.MustCompile(`(\d{1,2}):(\d{2})(?::(\d{2}))?(?:\s*([AaPp])\.?[Mm]\.?)?`)
+	enPhone     = regexp.MustCompile(`(?:\+1[-.\s]?)?(?:\((\d{3})\)\s?|(\d{3})[-.\s])(\d{3})[-.\s](\d{4})`)
+	enMoney     = regexp.MustCompile(`([$€£¥])\s?(` + numberPattern + `)(?:\s?(thousand|million|billion|trillion|[kKmMbB])\b)?`)
+	enPercentRe = regexp.MustCompile(`(-?` + numberPattern + `)\s?%`)
+	enUnitRe    = regexp.MustCompile(`(-?` + numberPattern + `)\s?(km/h|mph|km|cm|mm|kg|mg|lbs|lb|oz|ml|mL|ft|mi|kWh|kW|mAh|GHz|MHz|Hz|GB|MB|KB|TB|°C|℃|°F|m|g|L|W|V)`)
+	enOrdinalRe = regexp.MustCompile(`(\d+)(st|nd|rd|th)`)
+	enYearRe    = regexp.MustCompile(`(\d{4})(s?)(\.\d+)?`)
+	enRange     = regexp.MustCompile(`(` + numberPattern + `)[-–~](` + numberPattern + `)`)
+	enNumber    = regexp.MustCompile(`-?` + numberPattern)
+	enAbbrevRe  = regexp.MustCompile(`\b(Dr|Mr|Mrs|Ms|Prof|Jr|Sr|No|vs|etc|approx|dept|e\.g|i\.e)\.(\s*)`)
+)
+
+var enCurrencyNames = map[string][4]string{
+	"$": {"dollar", "dollars", "cent", "cents"},
+	"€": {"euro", "euros", "cent", "cents"},
+	"£": {"pound", "pounds", "penny", "pence"},
+	"¥": {"yuan", "yuan", "fen", "fen"},
+}
+
+// 单位的单数和复数读法
+var enUnitNames = map[string][2]string{
+	"km/h": {"kilometer per hour", "kilometers per hour"}, "mph": {"mile per hour", "miles per hour"},
+	"km": {"kilometer", "kilometers"}, "cm": {"centimeter", "centimeters"}, "mm": {"millimeter", "millimeters"}, "m": {"meter", "meters"},
+	"kg": {"kilogram", "kilograms"}, "mg": {"milligram", "milligrams"}, "g": {"gram", "grams"},
+	"lb": {"pound", "pounds"}, "lbs": {"pound", "pounds"}, "oz": {"ounce", "ounces"},
+	"ml": {"milliliter", "milliliters"}, "mL": {"milliliter", "milliliters"}, "L": {"liter", "liters"},
+	"ft": {"foot", "feet"}, "mi": {"mile", "miles"},
+	"kWh": {"kilowatt hour", "kilowatt hours"}, "kW": {"kilowatt", "kilowatts"}, "W": {"watt", "watts"}, "V": {"volt", "volts"},
+	"mAh": {"milliamp hour", "milliamp hours"},
+	"GHz": {"gigahertz", "gigahertz"}, "MHz": {"megahertz", "megahertz"}, "Hz": {"hertz", "hertz"},
+	"GB": {"gigabyte", "gigabytes"}, "MB": {"megabyte", "megabytes"}, "KB": {"kilobyte", "kilobytes"}, "TB": {"terabyte", "terabytes"},
+	"°C": {"degree Celsius", "degrees Celsius"}, "℃": {"degree Celsius", "degrees Celsius"}, "°F": {"degree Fahrenheit", "degrees Fahrenheit"},
+}
+
+var enAbbreviationNames = map[string]string{
+	"Dr": "Doctor", "Mr": "Mister", "Mrs": "Missus", "Ms": "Miss", "Prof": "Professor",
+	"Jr": "Junior", "Sr": "Senior", "No": "number", "vs": "versus",
+	"etc": "et cetera", "approx": "approximately", "dept": "department", "e.g": "for example", "i.e": "that is",
+}
+
+// enAbbreviations 常见缩写展开，No.只在后接数字时展开
+func enAbbreviations(text string) string {
+	return replaceMatches(enAbbrevRe, text, func(m []string, _, after rune) (string, bool) {
+		if m[1] == "No" {
+			return "number ", isDigit(after)
+		}
+		return enAbbreviationNames[m[1]] + m[2], true
+	})
+}
+
+// enDates 日期读作"March fifth, twenty twenty-four"，斜线日期按美式的月/日/年
+func enDates(text string) string {
+	date := func(month, day int, year string) (string, bool) {
+		if month < 1 || month > 12 || day < 1 || day > 31 {
+			return "", false
+		}
+		spoken := enMonths[month-1] + " " + enOrdinal(day)
+		if year != "" {
+			spoken += ", " + enYear(year)
+		}
+		return spoken, true
+	}
+
+	text = replaceMatches(enISODate, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || continuesWord(after) || isIDJoiner(before) || isIDJoiner(after) {
+			return "", false
+		}
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		return date(month, day, m[1])
+	})
+	text = replaceMatches(enSlashDate, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || continuesWord(after) || before == '/' || after == '/' {
+			return "", false
+		}
+		month, _ := strconv.Atoi(m[1])
+		day, _ := strconv.Atoi(m[2])
+		return date(month, day, m[3])
+	})
+	text = replaceMatches(enMonthDate, text, func(m []string, before, after rune) (string, bool) {
+		if isASCIILetter(before) || continuesWord(after) {
+			return "", false
+		}
+		day, _ := strconv.Atoi(m[2])
+		return date(monthNumber(m[1]), day, m[3])
+	})
+	return replaceMatches(enDayMonth, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || isASCIILetter(after) {
+			return "", false
+		}
+		day, _ := strconv.Atoi(m[1])
+		month := monthNumber(m[2])
+		if month == 0 || day < 1 || day > 31 {
+			return "", false
+		}
+		spoken := "the " + enOrdinal(day) + " of " + enMonths[month-1]
+		if m[3] != "" {
+			spoken += ", " + enYear(m[3])
+		}
+		return spoken, true
+	})
+}
+
+// enTimes 时间：2:05 pm读作"two oh five p m"，整点读作"o'clock"
+func enTimes(text string) string {
+	return replaceMatches(enClock, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || isDigit(after) || after == ':' {
+			return "", false
+		}
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour > 24 || minute > 59 {
+			return "", false
+		}
+
+		spoken := enInteger(strconv.Itoa(hour))
+		switch {
+		case minute == 0 && m[4] == "":
+			spoken += " o'clock"
+		case minute == 0:
+		case minute < 10:
+			spoken += " oh " + enOnes[minute]
+		default:
+			spoken += " " + enInteger(strconv.Itoa(minute))
+		}
+		if m[3] != "" {
+			second, _ := strconv.Atoi(m[3])
+			if second > 59 {
+				return "", false
+			}
+			spoken += " and " + enInteger(strconv.Itoa(second)) + plural(second, " second", " seconds")
+		}
+		if m[4] != "" {
+			spoken += " " + strings.ToLower(m[4]) + " m"
+			// p.m.在句末时同时是句号
+			if strings.HasSuffix(m[0], ".") {
+				spoken += "."
+			}
+		}
+		return spoken, true
+	})
+}
+
+// enPhones 北美电话号码逐位读，分组之间停顿
+func enPhones(text string) string {
+	return replaceMatches(enPhone, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || continuesWord(after) {
+			return "", false
+		}
+		area := m[1] + m[2]
+		var parts []string
+		if strings.HasPrefix(m[0], "+1") {
+			parts = append(parts, "one")
+		}
+		for _, group := range []string{area, m[3], m[4]} {
+			parts = append(parts, enSpellDigits(group))
+		}
+		return strings.Join(parts, ", "), true
+	})
+}
+
+// enCurrency 货币：$12.50读作"twelve dollars and fifty cents"，带数量级时读作"one point five million dollars"
+func enCurrency(text string) string {
+	return replaceMatches(enMoney, text, func(m []string, before, _ rune) (string, bool) {
+		if isWordChar(before) {
+			return "", false
+		}
+		names := enCurrencyNames[m[1]]
+		amount := strings.ReplaceAll(m[2], ",", "")
+
+		if m[3] != "" {
+			scale := map[string]string{"k": "thousand", "m": "million", "b": "billion"}[strings.ToLower(m[3])]
+			if scale == "" {
+				scale = m[3]
+			}
+			return enNumberString(amount) + " " + scale + " " + names[1], true
+		}
+
+		integer, fraction, _ := strings.Cut(amount, ".")
+		whole, _ := strconv.Atoi(strings.TrimLeft(integer, "0"))
+		if len(fraction) > 2 || m[1] == "¥" {
+			return enNumberString(amount) + " " + plural(whole, names[0], names[1]), true
+		}
+
+		spoken := enInteger(integer) + " " + plural(whole, names[0], names[1])
+		if fraction != "" {
+			cents, _ := strconv.Atoi(fraction + strings.Repeat("0", 2-len(fraction)))
+			if cents > 0 {
+				if whole == 0 {
+					return enInteger(strconv.Itoa(cents)) + " " + plural(cents, names[2], names[3]), true
+				}
+				spoken += " and " + enInteger(strconv.Itoa(cents)) + " " + plural(cents, names[2], names[3])
+			}
+		}
+		return spoken, true
+	})
+}
+
+// enPercent 百分数
+func enPercent(text string) string {
+	return replaceMatches(enPercentRe, text, func(m []string, before, _ rune) (string, bool) {
+		if isWordChar(before) {
+			return "", false
+		}
+		return enNumberString(strings.ReplaceAll(m[1], ",", "")) + " percent", true
+	})
+}
+
+// enUnits 数值后的计量单位，数值为1时用单数
+func enUnits(text string) string {
+	return replaceMatches(enUnitRe, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || isASCIILetter(after) {
+			return "", false
+		}
+		number := strings.ReplaceAll(m[1], ",", "")
+		names := enUnitNames[m[2]]
+		if number == "1" || number == "-1" {
+			return enNumberString(number) + " " + names[0], true
+		}
+		return enNumberString(number) + " " + names[1], true
+	})
+}
+
+// enNumbers 剩余的数字：序数、范围、年份和普通数字，以0开头的数字逐位读，版本号和编号保持原样
+func enNumbers(text string) string {
+	return skipProtected(text, enPlainNumbers)
+}
+
+func enPlainNumbers(text string) string {
+	text = replaceMatches(enOrdinalRe, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || isASCIILetter(after) {
+			return "", false
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return "", false
+		}
+		return enOrdinal(n), true
+	})
+	// 连字符两侧有空白时是减号或负号，如"10 -5"，不按范围读
+	text = replaceMatches(enRange, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || continuesWord(after) {
+			return "", false
+		}
+		if isIDJoiner(before) || isIDJoiner(after) {
+			return "", false
+		}
+		if isYear(m[1]) && isYear(m[2]) {
+			return enYear(m[1]) + " to " + enYear(m[2]), true
+		}
+		return enNumberString(strings.ReplaceAll(m[1], ",", "")) + " to " + enNumberString(strings.ReplaceAll(m[2], ",", "")), true
+	})
+	// 单独的四位数年份和年代，如1990读作"nineteen ninety"，1990s读作"nineteen nineties"
+	text = replaceMatches(enYearRe, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || before == ',' || before == '-' || continuesWord(after) || m[3] != "" || !isYear(m[1]) {
+			return "", false
+		}
+		if m[2] == "" {
+			return enYear(m[1]), true
+		}
+		if !strings.HasSuffix(m[1], "0") {
+			return "", false
+		}
+		return enDecade(m[1]), true
+	})
+	return replaceMatches(enNumber, text, func(m []string, before, _ rune) (string, bool) {
+		number := m[0]
+		// 连字符前是字母或数字时是编号的一部分，不是负号
+		if strings.HasPrefix(number, "-") && (isWordChar(before) || before == '-') {
+			return "-" + enNumberString(strings.ReplaceAll(number[1:], ",", "")), true
+		}
+		spoken := enNumberString(strings.ReplaceAll(number, ",", ""))
+		if isDigitID(number) {
+			spoken = enSpellDigits(number)
+		}
+		if isASCIILetter(before) {
+			spoken = " " + spoken
+		}
+		return spoken, true
+	})
+}
+
+// enNumberString 数字字符串的读法，支持负号和小数
+func enNumberString(number string) string {
+	prefix := ""
+	if strings.HasPrefix(number, "-") {
+		prefix, number = "minus ", number[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(number, ".")
+	if !hasFraction && len(integer) > 1 && integer[0] == '0' {
+		return prefix + enSpellDigits(integer)
+	}
+
+	spoken := prefix + enInteger(integer)
+	if hasFraction {
+		spoken += " point " + enSpellDigits(fraction)
+	}
+	return spoken
+}
+
+// enInteger 整数读法，超过15位时逐位读
+func enInteger(digits string) string {
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return enOnes[0]
+	}
+	if len(digits) > 15 {
+		return enSpellDigits(digits)
+	}
+
+	n, _ := strconv.ParseInt(digits, 10, 64)
+	var parts []string
+	for scale := len(enScales) - 1; scale >= 0; scale-- {
+		unit := int64(1)
+		for i := 0; i < scale; i++ {
+			unit *= 1000
+		}
+		group := int(n / unit)
+		n %= unit
+		if group == 0 {
+			continue
+		}
+		part := enBelowThousand(group)
+		if enScales[scale] != "" {
+			part += " " + enScales[scale]
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// enBelowThousand 1000以内的读法
+func enBelowThousand(n int) string {
+	var parts []string
+	if n >= 100 {
+		parts = append(parts, enOnes[n/100]+" hundred")
+		n %= 100
+	}
+	switch {
+	case n == 0:
+	case n < 20:
+		parts = append(parts, enOnes[n])
+	case n%10 == 0:
+		parts = append(parts, enTens[n/10])
+	default:
+		parts = append(parts, enTens[n/10]+"-"+enOnes[n%10])
+	}
+	return strings.Join(parts, " ")
+}
+
+// enOrdinal 序数词，只改写最后一个词
+func enOrdinal(n int) string {
+	cardinal := enInteger(strconv.Itoa(n))
+	cut := strings.LastIndexAny(cardinal, " -") + 1
+	head, last := cardinal[:cut], cardinal[cut:]
+
+	irregular := map[string]string{
+		"one": "first", "two": "second", "three": "third", "five": "fifth",
+		"eight": "eighth", "nine": "ninth", "twelve": "twelfth",
+	}
+	switch {
+	case irregular[last] != "":
+		last = irregular[last]
+	case strings.HasSuffix(last, "y"):
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		last += "th"
+	}
+	return head + last
+}
+
+// enYear 年份按两位一组读，如1999读作"nineteen ninety-nine"，2005读作"two thousand five"
+func enYear(year string) string {
+	y, _ := strconv.Atoi(year)
+	high, low := y/100, y%100
+	switch {
+	case y < 1000 || y%1000 < 10:
+		return enInteger(year)
+	case low == 0:
+		return enBelowThousand(high) + " hundred"
+	case low < 10:
+		return enBelowThousand(high) + " oh " + enOnes[low]
+	}
+	return enBelowThousand(high) + " " + enBelowThousand(low)
+}
+
+// isYear 1000到2099之间、不带千分位的四位数按年份读
+func isYear(number string) bool {
+	if len(number) != 4 {
+		return false
+	}
+	y, err := strconv.Atoi(number)
+	return err == nil && y >= 1000 && y <= 2099
+}
+
+// enDecade 年代读作年份最后一个词的复数，如1990s读作"nineteen nineties"
+func enDecade(year string) string {
+	spoken := enYear(year)
+	if strings.HasSuffix(spoken, "y") {
+		return strings.TrimSuffix(spoken, "y") + "ies"
+	}
+	return spoken + "s"
+}
+
+// enSpellDigits 逐位读数字
+func enSpellDigits(digits string) string {
+	var words []string
+	for _, c := range digits {
+		if isDigit(c) {
+			words = append(words, enOnes[c-'0'])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// monthNumber 月份名称对应的月份，无法识别时返回0
+func monthNumber(name string) int {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for i, month := range enMonths {
+		if strings.HasPrefix(strings.ToLower(month), name) && len(name) >= 3 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
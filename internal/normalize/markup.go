@@ -0,0 +1,174 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	mdFence      = regexp.MustCompile("(?m)^[ \t]*(```|~~~).*$\n?")
+	mdHeading    = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+`)
+	mdQuote      = regexp.MustCompile(`(?m)^[ \t]*>[ \t]?`)
+	mdList       = regexp.MustCompile(`(?m)^[ \t]*(?:[-*+]|\d{1,3}[.)])[ \t]+`)
+	mdRule       = regexp.MustCompile(`(?m)^[ \t]*(?:[-*_][ \t]*){3,}$`)
+	mdTableSep   = regexp.MustCompile(`(?m)^[ \t]*\|?[ \t]*:?-{3,}:?[ \t]*(?:\|[ \t]*:?-{3,}:?[ \t]*)+\|?[ \t]*$\n?`)
+	mdTableRow   = regexp.MustCompile(`(?m)^[ \t]*\|(.*)\|[ \t]*$`)
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdStrong     = regexp.MustCompile(`\*\*([^*\n]+)\*\*|__([^_\n]+)__`)
+	mdEmphasis   = regexp.MustCompile(`\*([^*\s][^*\n]*)\*`)
+	mdStrike     = regexp.MustCompile(`~~([^~\n]+)~~`)
+	mdCode       = regexp.MustCompile("`([^`\n]+)`")
+	mdHTML       = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9]*(?:\s[^<>]*)?/?>`)
+	urlPattern   = regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s<>"'()\[\]{}（）【】「」，。；！？、]+`)
+	extraSpaces  = regexp.MustCompile(`[ \t]{2,}`)
+	spaceNewline = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// stripMarkdown 去掉Markdown标记，保留朗读的文字；链接和图片保留文字和替代文本
+func stripMarkdown(text string, _ *Context) string {
+	text = mdFence.ReplaceAllString(text, "")
+	text = mdTableSep.ReplaceAllString(text, "")
+	text = mdTableRow.ReplaceAllStringFunc(text, func(row string) string {
+		cells := strings.Split(strings.Trim(strings.TrimSpace(row), "|"), "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		return strings.Join(cells, ", ")
+	})
+	text = mdRule.ReplaceAllString(text, "")
+	text = mdHeading.ReplaceAllString(text, "")
+	text = mdQuote.ReplaceAllString(text, "")
+	text = mdList.ReplaceAllString(text, "")
+	text = mdImage.ReplaceAllString(text, "$1")
+	text = mdLink.ReplaceAllString(text, "$1")
+	text = mdStrong.ReplaceAllString(text, "$1$2")
+	text = mdEmphasis.ReplaceAllString(text, "$1")
+	text = mdStrike.ReplaceAllString(text, "$1")
+	text = mdCode.ReplaceAllString(text, "$1")
+	text = mdHTML.ReplaceAllString(text, "")
+	return tidy(text)
+}
+
+// replaceURLs 按策略删除或替换网址，网址末尾的标点不属于网址
+func replaceURLs(text string, ctx *Context) string {
+	if ctx.Options.URL == PolicyKeep {
+		return text
+	}
+	replacement := ""
+	if ctx.Options.URL == PolicyReplace {
+		replacement = map[string]string{"zh": "链接", "en": "link"}[ctx.Language]
+	}
+
+	out := urlPattern.ReplaceAllStringFunc(text, func(url string) string {
+		trimmed := strings.TrimRight(url, ".,;:!?")
+		return replacement + url[len(trimmed):]
+	})
+	if out == text {
+		return text
+	}
+	return tidy(out)
+}
+
+// 常用表情的读法，其他表情在替换模式下直接删除
+var emojiNames = map[rune][2]string{
+	'😀': {"笑脸", "grinning face"},
+	'😂': {"笑哭", "tears of joy"},
+	'😊': {"微笑", "smiling face"},
+	'😍': {"爱心眼", "heart eyes"},
+	'😭': {"大哭", "crying face"},
+	'😅': {"尴尬", "sweat smile"},
+	'😉': {"眨眼", "winking face"},
+	'😎': {"酷", "cool"},
+	'🤔': {"思考", "thinking"},
+	'😢': {"难过", "sad face"},
+	'😡': {"生气", "angry face"},
+	'👍': {"点赞", "thumbs up"},
+	'👎': {"踩", "thumbs down"},
+	'👏': {"鼓掌", "clapping"},
+	'🙏': {"拜托", "folded hands"},
+	'💪': {"加油", "flexed biceps"},
+	'🎉': {"庆祝", "party popper"},
+	'🔥': {"火", "fire"},
+	'❤': {"爱心", "heart"},
+	'⭐': {"星星", "star"},
+	'✅': {"对勾", "check mark"},
+	'❌': {"叉", "cross mark"},
+	'⚠': {"警告", "warning"},
+	'🚀': {"火箭", "rocket"},
+	'💡': {"灯泡", "light bulb"},
+	'📌': {"图钉", "pushpin"},
+}
+
+// replaceEmoji 按策略删除或替换表情，肤色、变体选择符和零宽连接的组合表情作为一个整体处理
+func replaceEmoji(text string, ctx *Context) string {
+	if ctx.Options.Emoji == PolicyKeep {
+		return text
+	}
+
+	var b strings.Builder
+	changed := false
+	inSequence, joined, flag := false, false, false
+	for _, r := range text {
+		switch {
+		case isEmojiModifier(r):
+			if inSequence {
+				changed = true
+				joined = r == 0x200D
+				continue
+			}
+		case isEmoji(r):
+			changed = true
+			regional := r >= 0x1F1E6 && r <= 0x1F1FF
+			// 零宽连接符之后的表情和国旗的第二个字母属于同一个组合
+			if inSequence && (joined || (regional && flag)) {
+				joined, flag = false, false
+				continue
+			}
+			inSequence, joined, flag = true, false, regional
+			if ctx.Options.Emoji == PolicyReplace {
+				if names, ok := emojiNames[r]; ok {
+					switch ctx.Language {
+					case "zh":
+						b.WriteString(names[0])
+					case "en":
+						b.WriteString(" " + names[1] + " ")
+					}
+				}
+			}
+			continue
+		}
+		inSequence = false
+		b.WriteRune(r)
+	}
+	if !changed {
+		return text
+	}
+	return tidy(b.String())
+}
+
+// isEmoji 表情符号、交通和地图符号、杂项符号、装饰符号和国旗字母
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2600 && r <= 0x27BF:
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	}
+	return false
+}
+
+// isEmojiModifier 组合表情中的零宽连接符、变体选择符和肤色修饰符
+func isEmojiModifier(r rune) bool {
+	return r == 0x200D || r == 0xFE0F || r == 0x20E3 || (r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
+// tidy 合并删除内容后留下的多余空白
+func tidy(text string) string {
+	text = extraSpaces.ReplaceAllString(text, " ")
+	text = spaceNewline.ReplaceAllString(text, "\n")
+	return strings.TrimFunc(text, unicode.IsSpace)
+}
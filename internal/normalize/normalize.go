@@ -0,0 +1,235 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 规则名称，按执行顺序排列
+const (
+	RuleMarkdown     = "markdown"
+	RuleURL          = "url"
+	RuleEmoji        = "emoji"
+	RuleAbbreviation = "abbreviation"
+	RuleDate         = "date"
+	RuleTime         = "time"
+	RulePhone        = "phone"
+	RuleCurrency     = "currency"
+	RulePercent      = "percent"
+	RuleUnit         = "unit"
+	RuleNumber       = "number"
+)
+
+// 网址和表情的处理方式
+const (
+	PolicyStrip   = "strip"   // 删除
+	PolicyReplace = "replace" // 替换为朗读用的文字
+	PolicyKeep    = "keep"    // 保留原文
+)
+
+// Policies 支持的处理方式
+var Policies = []string{PolicyStrip, PolicyReplace, PolicyKeep}
+
+// Options 规范化选项
+type Options struct {
+	Disabled map[string]bool // 关闭的规则
+	URL      string          // 网址处理方式，默认保留
+	Emoji    string          // 表情处理方式，默认保留
+}
+
+// Context 规则执行时的上下文
+type Context struct {
+	Language string // ISO 639-1语言代码，目前内置zh和en的读法，其他语言只执行与语言无关的规则
+	Options  Options
+}
+
+// Rule 一条规范化规则，Apply返回处理后的文本
+type Rule struct {
+	Name  string
+	Apply func(text string, ctx *Context) string
+}
+
+// Step 规则执行记录，只记录改变了文本的规则
+type Step struct {
+	Rule string `json:"rule"`
+	Text string `json:"text"`
+}
+
+// Normalizer 按顺序执行规范化规则，把数字、日期、货币等转换成朗读形式
+// 规则的输出不再包含可被其他规则匹配的内容，重复执行结果不变
+type Normalizer struct {
+	rules []Rule
+}
+
+// New 创建包含内置规则的规范化器
+func New() *Normalizer {
+	return &Normalizer{rules: []Rule{
+		{Name: RuleMarkdown, Apply: stripMarkdown},
+		{Name: RuleURL, Apply: replaceURLs},
+		{Name: RuleEmoji, Apply: replaceEmoji},
+		{Name: RuleAbbreviation, Apply: localized(zhAbbreviations, enAbbreviations)},
+		{Name: RuleDate, Apply: localized(zhDates, enDates)},
+		{Name: RuleTime, Apply: localized(zhTimes, enTimes)},
+		{Name: RulePhone, Apply: localized(zhPhones, enPhones)},
+		{Name: RuleCurrency, Apply: localized(zhCurrency, enCurrency)},
+		{Name: RulePercent, Apply: localized(zhPercent, enPercent)},
+		{Name: RuleUnit, Apply: localized(zhUnits, enUnits)},
+		{Name: RuleNumber, Apply: localized(zhNumbers, enNumbers)},
+	}}
+}
+
+// Register 添加规则，同名规则会被替换，新规则在数字规则之前执行
+func (n *Normalizer) Register(rule Rule) {
+	for i := range n.rules {
+		if n.rules[i].Name == rule.Name {
+			n.rules[i] = rule
+			return
+		}
+	}
+	last := len(n.rules)
+	if last > 0 && n.rules[last-1].Name == RuleNumber {
+		last--
+	}
+	n.rules = append(n.rules[:last], append([]Rule{rule}, n.rules[last:]...)...)
+}
+
+// Rules 规则名称列表
+func (n *Normalizer) Rules() []string {
+	names := make([]string, len(n.rules))
+	for i, rule := range n.rules {
+		names[i] = rule.Name
+	}
+	return names
+}
+
+// Normalize 按顺序执行未关闭的规则，返回规范化后的文本和每条规则的执行结果
+func (n *Normalizer) Normalize(text, language string, opts Options) (string, []Step) {
+	ctx := &Context{Language: language, Options: opts}
+	var steps []Step
+	for _, rule := range n.rules {
+		if opts.Disabled[rule.Name] {
+			continue
+		}
+		out := rule.Apply(text, ctx)
+		if out != text {
+			steps = append(steps, Step{Rule: rule.Name, Text: out})
+			text = out
+		}
+	}
+	return text, steps
+}
+
+// protectedToken 版本号、三段以上的编号和字母数字混合的编号，如v1.2.3、2024-03-05-01、555-123-4567、A1234B、A123-45
+// 逐段读成数字、范围或负数都会改变含义，数字规则跳过这些记号，原样交给引擎
+var protectedToken = regexp.MustCompile(`[vV]\d+(?:\.\d+)+|\d+(?:\.\d+){2,}|\d+(?:[-/]\d+){2,}|[A-Za-z]+\d+(?:[A-Za-z][0-9A-Za-z]*|(?:-\d+)+)`)
+
+// maxQuantityDigits 不带千分位时超过这个位数的整数是订单号、卡号等编号，逐位读
+const maxQuantityDigits = 12
+
+// isDigitID 不带千分位和小数点的长串数字按编号读
+func isDigitID(number string) bool {
+	if len(number) <= maxQuantityDigits {
+		return false
+	}
+	for _, c := range number {
+		if !isDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// skipProtected 只对protectedToken之外的文本执行fn
+func skipProtected(text string, fn func(string) string) string {
+	matches := protectedToken.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return fn(text)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(fn(text[last:m[0]]))
+		b.WriteString(text[m[0]:m[1]])
+		last = m[1]
+	}
+	b.WriteString(fn(text[last:]))
+	return b.String()
+}
+
+// localized 按语言选择规则的实现，没有对应语言时保持原文
+func localized(zh, en func(string) string) func(string, *Context) string {
+	return func(text string, ctx *Context) string {
+		switch ctx.Language {
+		case "zh":
+			return zh(text)
+		case "en":
+			return en(text)
+		}
+		return text
+	}
+}
+
+// replaceMatches 替换正则的全部匹配，fn可以根据匹配前后的字符放弃替换
+// groups与FindStringSubmatch的结果相同，未参与匹配的分组为空字符串
+func replaceMatches(re *regexp.Regexp, text string, fn func(groups []string, before, after rune) (string, bool)) string {
+	matches := re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		groups := make([]string, len(m)/2)
+		for i := range groups {
+			if m[2*i] >= 0 {
+				groups[i] = text[m[2*i]:m[2*i+1]]
+			}
+		}
+		before, _ := utf8.DecodeLastRuneInString(text[:m[0]])
+		after, _ := utf8.DecodeRuneInString(text[m[1]:])
+		if before == utf8.RuneError {
+			before = 0
+		}
+		if after == utf8.RuneError {
+			after = 0
+		}
+
+		replacement, ok := fn(groups, before, after)
+		if !ok {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString(replacement)
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isDigit 匹配前后紧邻数字时不能拆开替换
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// isASCIILetter 单位、缩写前后紧邻字母时属于更长的单词
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// isWordChar 数字前紧邻字母、数字或小数点时属于更长的编号
+func isWordChar(r rune) bool {
+	return isDigit(r) || isASCIILetter(r) || r == '.' || r == '_'
+}
+
+// isIDJoiner 日期前后紧邻连字符或斜线时属于更长的编号，如2024-03-05-01
+func isIDJoiner(r rune) bool {
+	return r == '-' || r == '/'
+}
+
+// continuesWord 匹配后紧跟字母或数字时属于更长的单词或编号，句末的标点不算
+func continuesWord(r rune) bool {
+	return isDigit(r) || isASCIILetter(r) || r == '_'
+}
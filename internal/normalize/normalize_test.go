@@ -0,0 +1,79 @@
+package normalize
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		lang, text, want string
+	}{
+		// 年份
+		{"zh", "1990年出生", "一九九零年出生"},
+		{"zh", "2024年3月5日", "二零二四年三月五日"},
+		{"zh", "2020-2023年", "二零二零到二零二三年"},
+		{"zh", "2020至2023年", "二零二零至二零二三年"},
+		{"en", "Born in 1990.", "Born in nineteen ninety."},
+		{"en", "In the 1990s, prices rose.", "In the nineteen nineties, prices rose."},
+		{"en", "In 2005, 1990s, and 1900.", "In two thousand five, nineteen nineties, and nineteen hundred."},
+		{"en", "March 5, 2024", "March fifth, twenty twenty-four"},
+		// 范围
+		{"zh", "3-5个", "三到五个"},
+		{"en", "pages 10-20", "pages ten to twenty"},
+		{"en", "from 2020-2023", "from twenty twenty to twenty twenty-three"},
+		{"en", "10 - 5", "ten - five"},
+		// 编号
+		{"zh", "订单号2024031500123", "订单号二零二四零三一五零零一二三"},
+		{"zh", "编号A123-45", "编号A123-45"},
+		{"zh", "批次2024-03-05-01", "批次2024-03-05-01"},
+		{"en", "order ID A1234B", "order ID A1234B"},
+		{"en", "card 4111111111111111", "card four one one one one one one one one one one one one one one one"},
+		{"en", "Call 555-123-4567", "Call five five five, one two three, four five six seven"},
+		{"zh", "电话13812345678", "电话幺三八，幺二三四，五六七八"},
+		// 版本号
+		{"zh", "版本v1.2.3发布", "版本v1.2.3发布"},
+		{"en", "version 2.1.3", "version 2.1.3"},
+		{"en", "upgrade to v2.0", "upgrade to v2.0"},
+		// 其他数字
+		{"zh", "价格是12.5元", "价格是十二点五元"},
+		{"zh", "¥1200", "一千二百元"},
+		{"zh", "增长了15%", "增长了百分之十五"},
+		{"zh", "共有1234人", "共有一千二百三十四人"},
+		{"zh", "温度-5度", "温度负五度"},
+		{"zh", "下午3:30开会", "下午三点三十分开会"},
+		{"en", "It costs $12.50", "It costs twelve dollars and fifty cents"},
+		{"en", "1,234 people", "one thousand two hundred thirty-four people"},
+		{"en", "1st place", "first place"},
+		{"en", "5 km", "five kilometers"},
+		{"en", "Dr. Smith", "Doctor Smith"},
+		// 没有内置读法的语言保持原样
+		{"ja", "2024年", "2024年"},
+	}
+
+	n := New()
+	for _, tt := range tests {
+		t.Run(tt.lang+"/"+tt.text, func(t *testing.T) {
+			got, _ := n.Normalize(tt.text, tt.lang, Options{})
+			if got != tt.want {
+				t.Fatalf("Normalize(%q) = %q, 期望 %q", tt.text, got, tt.want)
+			}
+			// 规则的输出不再被其他规则匹配，重复执行结果不变
+			if again, _ := n.Normalize(got, tt.lang, Options{}); again != got {
+				t.Fatalf("重复规范化得到 %q, 期望 %q", again, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeDisabledRules(t *testing.T) {
+	n := New()
+	text := "2024年涨了15%"
+
+	got, steps := n.Normalize(text, "zh", Options{})
+	if got != "二零二四年涨了百分之十五" || len(steps) != 2 || steps[0].Rule != RuleDate || steps[1].Rule != RulePercent {
+		t.Fatalf("Normalize = %q, %v", got, steps)
+	}
+
+	got, steps = n.Normalize(text, "zh", Options{Disabled: map[string]bool{RuleDate: true, RulePercent: true, RuleNumber: true}})
+	if got != text || len(steps) != 0 {
+		t.Fatalf("关闭规则后 Normalize = %q, %v, 期望保持原样", got, steps)
+	}
+}
@@ -0,0 +1,385 @@
+package normalize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberPattern 整数、千分位和小数
+const numberPattern = `\d+(?:,\d{3})*(?:\.\d+)?`
+
+var (
+	zhDigits     = []string{"零", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+	zhGroupUnits = []string{"", "万", "亿", "万亿"}
+
+	zhFullDate  = regexp.MustCompile(`(\d{4})\s*年\s*(\d{1,2})\s*月\s*(\d{1,2})\s*([日号])`)
+	zhDashDate  = regexp.MustCompile(`(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})`)
+	zhYearRange = regexp.MustCompile(`(\d{4})\s*([-~～到至])\s*(\d{4})\s*年`)
+	zhYear      = regexp.MustCompile(`(\d{4})\s*年`)
+	zhMonthDay  = regexp.MustCompile(`(\d{1,2})\s*月\s*(\d{1,2})\s*([日号])`)
+	zhClock     = regexp.MustCompile(`(\d{1,2}):(\d{2})(?::(\d{2}))?`)
+	zhMobile    = regexp.MustCompile(`(?:\+?86[-\s]?)?1[3-9]\d[-\s]?\d{4}[-\s]?\d{4}`)
+	zhLandline  = regexp.MustCompile(`(?:0\d{2,3}-\d{7,8}|400-?\d{3}-?\d{4})`)
+	zhMoney     = regexp.MustCompile(`(HK\$|US\$|[¥￥$€£])\s*(-?` + numberPattern + `)`)
+	zhPercentRe = regexp.MustCompile(`(-?` + numberPattern + `)\s*([%％‰])`)
+	zhRange     = regexp.MustCompile(`(` + numberPattern + `)[-~～](` + numberPattern + `)`)
+	zhFraction  = regexp.MustCompile(`(\d+)/(\d+)`)
+	zhNumber    = regexp.MustCompile(`-?` + numberPattern)
+	zhUnitRe    = regexp.MustCompile(`(-?` + numberPattern + `)\s*(km/h|km²|m²|m³|km|cm|mm|kg|mg|ml|mL|kWh|kW|mAh|Hz|GHz|MHz|°C|℃|°F|m|g|L|W|V)`)
+	zhAbbrevRe  = regexp.MustCompile(`(?i)(e\.g\.|i\.e\.|etc\.|vs\.?|No\.\s*)`)
+)
+
+var zhCurrencyNames = map[string]string{
+	"¥": "元", "￥": "元", "$": "美元", "US$": "美元", "HK$": "港元", "€": "欧元", "£": "英镑",
+}
+
+var zhUnitNames = map[string]string{
+	"km/h": "公里每小时", "km²": "平方公里", "m²": "平方米", "m³": "立方米",
+	"km": "公里", "cm": "厘米", "mm": "毫米", "m": "米",
+	"kg": "千克", "mg": "毫克", "g": "克", "ml": "毫升", "mL": "毫升", "L": "升",
+	"kW": "千瓦", "kWh": "千瓦时", "W": "瓦", "V": "伏", "mAh": "毫安时",
+	"Hz": "赫兹", "GHz": "吉赫兹", "MHz": "兆赫兹",
+	"°C": "摄氏度", "℃": "摄氏度", "°F": "华氏度",
+}
+
+// zhAbbreviations 常见外文缩写的中文读法，No.后接数字读作"第"
+func zhAbbreviations(text string) string {
+	return replaceMatches(zhAbbrevRe, text, func(m []string, before, after rune) (string, bool) {
+		if isASCIILetter(before) {
+			return "", false
+		}
+		switch strings.ToLower(strings.TrimSpace(m[1])) {
+		case "e.g.":
+			return "例如", true
+		case "i.e.":
+			return "即", true
+		case "etc.":
+			return "等", true
+		case "vs", "vs.":
+			return "对", !isASCIILetter(after)
+		case "no.":
+			return "第", isDigit(after)
+		}
+		return "", false
+	})
+}
+
+// zhDates 日期：年份逐位读，月和日按数值读
+func zhDates(text string) string {
+	date := func(year, month, day, suffix string) (string, bool) {
+		m, _ := strconv.Atoi(month)
+		d, _ := strconv.Atoi(day)
+		if m < 1 || m > 12 || d < 1 || d > 31 {
+			return "", false
+		}
+		return zhSpellDigits(year, false) + "年" + zhInteger(strconv.Itoa(m)) + "月" + zhInteger(strconv.Itoa(d)) + suffix, true
+	}
+
+	text = replaceMatches(zhFullDate, text, func(m []string, before, _ rune) (string, bool) {
+		if isDigit(before) {
+			return "", false
+		}
+		return date(m[1], m[2], m[3], m[4])
+	})
+	text = replaceMatches(zhDashDate, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || continuesWord(after) || isIDJoiner(before) || isIDJoiner(after) {
+			return "", false
+		}
+		return date(m[1], m[2], m[3], "日")
+	})
+	// 年份范围的两端都按年份读，如2020-2023年读作"二零二零到二零二三年"
+	text = replaceMatches(zhYearRange, text, func(m []string, before, _ rune) (string, bool) {
+		if isWordChar(before) || isIDJoiner(before) {
+			return "", false
+		}
+		sep := m[2]
+		if sep != "至" {
+			sep = "到"
+		}
+		return zhSpellDigits(m[1], false) + sep + zhSpellDigits(m[3], false) + "年", true
+	})
+	text = replaceMatches(zhYear, text, func(m []string, before, _ rune) (string, bool) {
+		if isWordChar(before) {
+			return "", false
+		}
+		return zhSpellDigits(m[1], false) + "年", true
+	})
+	return replaceMatches(zhMonthDay, text, func(m []string, before, _ rune) (string, bool) {
+		if isWordChar(before) {
+			return "", false
+		}
+		month, _ := strconv.Atoi(m[1])
+		day, _ := strconv.Atoi(m[2])
+		if month < 1 || month > 12 || day < 1 || day > 31 {
+			return "", false
+		}
+		return zhInteger(m[1]) + "月" + zhInteger(m[2]) + m[3], true
+	})
+}
+
+// zhTimes 时间：14:05 读作"十四点零五分"，整点省略分钟
+func zhTimes(text string) string {
+	return replaceMatches(zhClock, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || isDigit(after) || after == ':' {
+			return "", false
+		}
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour > 24 || minute > 59 {
+			return "", false
+		}
+
+		spoken := zhInteger(strconv.Itoa(hour)) + "点"
+		switch {
+		case minute == 0 && m[3] != "":
+			spoken += "零分"
+		case minute == 0:
+		case minute < 10:
+			spoken += "零" + zhDigits[minute] + "分"
+		default:
+			spoken += zhInteger(strconv.Itoa(minute)) + "分"
+		}
+		if m[3] != "" {
+			second, _ := strconv.Atoi(m[3])
+			if second > 59 {
+				return "", false
+			}
+			spoken += zhInteger(strconv.Itoa(second)) + "秒"
+		}
+		return spoken, true
+	})
+}
+
+// zhPhones 电话号码逐位读，1读作"幺"，分组之间停顿
+func zhPhones(text string) string {
+	spell := func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || continuesWord(after) {
+			return "", false
+		}
+		digits := strings.Map(keepDigits, m[0])
+		var parts []string
+		switch {
+		case len(digits) == 13 && strings.HasPrefix(digits, "86") && digits[2] == '1':
+			parts = []string{"86", digits[2:5], digits[5:9], digits[9:]}
+		case len(digits) == 11 && digits[0] == '1':
+			// 手机号按3-4-4分组
+			parts = []string{digits[:3], digits[3:7], digits[7:]}
+		default:
+			parts = strings.FieldsFunc(m[0], func(r rune) bool { return !isDigit(r) })
+		}
+		for i := range parts {
+			parts[i] = zhSpellDigits(parts[i], true)
+		}
+		return strings.Join(parts, "，"), true
+	}
+
+	text = replaceMatches(zhMobile, text, spell)
+	return replaceMatches(zhLandline, text, spell)
+}
+
+// zhCurrency 货币：人民币读作"元角分"，外币读作"数值+币种"
+func zhCurrency(text string) string {
+	return replaceMatches(zhMoney, text, func(m []string, before, _ rune) (string, bool) {
+		if isASCIILetter(before) && m[1] != "HK$" && m[1] != "US$" {
+			return "", false
+		}
+		name := zhCurrencyNames[m[1]]
+		amount := strings.ReplaceAll(m[2], ",", "")
+		if name != "元" {
+			return zhNumberString(amount) + name, true
+		}
+
+		negative := strings.HasPrefix(amount, "-")
+		amount = strings.TrimPrefix(amount, "-")
+		integer, fraction, _ := strings.Cut(amount, ".")
+		if len(fraction) > 2 {
+			return zhNumberString(strings.ReplaceAll(m[2], ",", "")) + "元", true
+		}
+
+		spoken := zhInteger(integer) + "元"
+		fraction += strings.Repeat("0", 2-len(fraction))
+		jiao, fen := fraction[0]-'0', fraction[1]-'0'
+		if jiao > 0 {
+			spoken += zhDigits[jiao] + "角"
+		}
+		if fen > 0 {
+			if jiao == 0 {
+				spoken += "零"
+			}
+			spoken += zhDigits[fen] + "分"
+		}
+		if negative {
+			spoken = "负" + spoken
+		}
+		return spoken, true
+	})
+}
+
+// zhPercent 百分数和千分数
+func zhPercent(text string) string {
+	return replaceMatches(zhPercentRe, text, func(m []string, before, _ rune) (string, bool) {
+		if isWordChar(before) {
+			return "", false
+		}
+		prefix := "百分之"
+		if m[2] == "‰" {
+			prefix = "千分之"
+		}
+		number := strings.ReplaceAll(m[1], ",", "")
+		if strings.HasPrefix(number, "-") {
+			return "负" + prefix + zhNumberString(number[1:]), true
+		}
+		return prefix + zhNumberString(number), true
+	})
+}
+
+// zhUnits 数值后的计量单位，单位后紧跟字母时视为普通单词
+func zhUnits(text string) string {
+	return replaceMatches(zhUnitRe, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || isASCIILetter(after) {
+			return "", false
+		}
+		return zhNumberString(strings.ReplaceAll(m[1], ",", "")) + zhUnitNames[m[2]], true
+	})
+}
+
+// zhNumbers 剩余的数字：范围读作"到"，分数读作"几分之几"，以0开头或超长的数字逐位读，版本号和编号保持原样
+func zhNumbers(text string) string {
+	return skipProtected(text, zhPlainNumbers)
+}
+
+func zhPlainNumbers(text string) string {
+	// 连字符两侧有空白时是减号或负号，不按范围读
+	text = replaceMatches(zhRange, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || continuesWord(after) || isIDJoiner(before) || isIDJoiner(after) {
+			return "", false
+		}
+		return zhNumberString(strings.ReplaceAll(m[1], ",", "")) + "到" + zhNumberString(strings.ReplaceAll(m[2], ",", "")), true
+	})
+	text = replaceMatches(zhFraction, text, func(m []string, before, after rune) (string, bool) {
+		if isWordChar(before) || continuesWord(after) || isIDJoiner(before) || isIDJoiner(after) || strings.Trim(m[2], "0") == "" {
+			return "", false
+		}
+		return zhInteger(m[2]) + "分之" + zhInteger(m[1]), true
+	})
+	return replaceMatches(zhNumber, text, func(m []string, before, _ rune) (string, bool) {
+		number := m[0]
+		// 连字符前是字母或数字时是编号的一部分，不是负号
+		if strings.HasPrefix(number, "-") && (isWordChar(before) || before == '-') {
+			return "-" + zhNumberString(strings.ReplaceAll(number[1:], ",", "")), true
+		}
+		if isDigitID(number) {
+			return zhSpellDigits(number, false), true
+		}
+		return zhNumberString(strings.ReplaceAll(number, ",", "")), true
+	})
+}
+
+// zhNumberString 数字字符串的读法，支持负号和小数
+func zhNumberString(number string) string {
+	prefix := ""
+	if strings.HasPrefix(number, "-") {
+		prefix, number = "负", number[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(number, ".")
+	if !hasFraction && len(integer) > 1 && integer[0] == '0' {
+		return prefix + zhSpellDigits(integer, false)
+	}
+
+	spoken := prefix + zhInteger(integer)
+	if hasFraction {
+		spoken += "点" + zhSpellDigits(fraction, false)
+	}
+	return spoken
+}
+
+// zhInteger 整数读法，如12305读作"一万二千三百零五"，超过16位时逐位读
+func zhInteger(digits string) string {
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return zhDigits[0]
+	}
+	if len(digits) > 16 {
+		return zhSpellDigits(digits, false)
+	}
+
+	// 从低位开始每4位一组
+	var groups []string
+	for end := len(digits); end > 0; end -= 4 {
+		start := end - 4
+		if start < 0 {
+			start = 0
+		}
+		groups = append([]string{digits[start:end]}, groups...)
+	}
+
+	var b strings.Builder
+	pendingZero := false
+	for i, group := range groups {
+		value, _ := strconv.Atoi(group)
+		if value == 0 {
+			pendingZero = b.Len() > 0
+			continue
+		}
+		if b.Len() > 0 && (pendingZero || value < 1000) {
+			b.WriteString(zhDigits[0])
+		}
+		b.WriteString(zhGroup(group))
+		b.WriteString(zhGroupUnits[len(groups)-1-i])
+		pendingZero = false
+	}
+
+	// 十到十九省略开头的"一"
+	spoken := b.String()
+	if strings.HasPrefix(spoken, "一十") {
+		spoken = strings.TrimPrefix(spoken, "一")
+	}
+	return spoken
+}
+
+// zhGroup 4位以内的数字读法，中间的连续零只读一个
+func zhGroup(group string) string {
+	units := []string{"千", "百", "十", ""}
+	group = strings.Repeat("0", 4-len(group)) + group
+
+	var b strings.Builder
+	zero := false
+	for i, c := range group {
+		d := int(c - '0')
+		if d == 0 {
+			zero = b.Len() > 0
+			continue
+		}
+		if zero {
+			b.WriteString(zhDigits[0])
+			zero = false
+		}
+		b.WriteString(zhDigits[d])
+		b.WriteString(units[i])
+	}
+	return b.String()
+}
+
+// zhSpellDigits 逐位读数字，phone为true时1读作"幺"
+func zhSpellDigits(digits string, phone bool) string {
+	var b strings.Builder
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			continue
+		}
+		if phone && c == '1' {
+			b.WriteString("幺")
+			continue
+		}
+		b.WriteString(zhDigits[c-'0'])
+	}
+	return b.String()
+}
+
+func keepDigits(r rune) rune {
+	if isDigit(r) {
+		return r
+	}
+	return -1
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"tts-service/internal/models"
+	"tts-service/internal/tts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理接口处理器
+type AdminHandler struct {
+	ttsService *tts.TTSService
+}
+
+// NewAdminHandler 创建新的管理接口处理器
+func NewAdminHandler(ttsService *tts.TTSService) *AdminHandler {
+	return &AdminHandler{ttsService: ttsService}
+}
+
+// GetCleanup 获取缓存清理统计和最近一次清理的结果
+func (h *AdminHandler) GetCleanup(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"cleanup": h.ttsService.CleanupStats(),
+			"storage": h.ttsService.StorageStatus(),
+		},
+	})
+}
+
+// RunCleanup 立即执行一次缓存清理，清理正在进行时等待其结束后再执行
+func (h *AdminHandler) RunCleanup(c *gin.Context) {
+	report, err := h.ttsService.CleanupExpiredCache()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "缓存清理失败",
+			Error:   err.Error(),
+			Details: report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    report,
+	})
+}
+
+// PinCache 固定音频文件对应的缓存，固定后不会因存储容量限制被淘汰
+func (h *AdminHandler) PinCache(c *gin.Context) {
+	h.setCachePinned(c, true)
+}
+
+// UnpinCache 取消固定缓存
+func (h *AdminHandler) UnpinCache(c *gin.Context) {
+	h.setCachePinned(c, false)
+}
+
+func (h *AdminHandler) setCachePinned(c *gin.Context, pinned bool) {
+	cache, err := h.ttsService.PinCache(c.Param("filename"), pinned)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "更新缓存失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if cache == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    404,
+			Message: "缓存不存在",
+			Error:   "cache not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    cache,
+	})
+}
+
+// Metrics 以Prometheus文本格式输出存储占用和缓存清理指标
+func (h *AdminHandler) Metrics(c *gin.Context) {
+	storage := h.ttsService.StorageStatus()
+	cleanup := h.ttsService.CleanupStats()
+
+	var b strings.Builder
+	metric := func(name, kind, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+	metric("tts_storage_used_bytes", "gauge", "Bytes used by the storage directory.", storage.UsedBytes)
+	metric("tts_storage_max_bytes", "gauge", "Configured storage limit in bytes, 0 if unlimited.", storage.MaxBytes)
+	metric("tts_cleanup_runs_total", "counter", "Cache cleanup runs.", cleanup.Runs)
+	metric("tts_cleanup_failures_total", "counter", "Cache cleanup runs that failed.", cleanup.Failures)
+	metric("tts_cleanup_expired_entries_total", "counter", "Expired cache entries removed with their files.", cleanup.ExpiredEntries)
+	metric("tts_cleanup_orphan_files_total", "counter", "Orphan files without a cache entry removed.", cleanup.OrphanFiles)
+	metric("tts_cleanup_freed_bytes_total", "counter", "Bytes freed by cache cleanup.", cleanup.FreedBytes)
+	if cleanup.LastRun != nil {
+		metric("tts_cleanup_last_run_timestamp_seconds", "gauge", "Finish time of the last cache cleanup.", cleanup.LastRun.FinishedAt.Unix())
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
@@ -0,0 +1,256 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"tts-service/internal/audiobook"
+	"tts-service/internal/models"
+	"tts-service/internal/storage"
+	"tts-service/internal/tts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认上传文件大小上限
+const defaultMaxUploadMB = 50
+
+// AudiobookHandler 有声书处理器
+type AudiobookHandler struct {
+	manager     *audiobook.Manager
+	ttsService  *tts.TTSService
+	maxUploadMB int
+}
+
+// NewAudiobookHandler 创建新的有声书处理器，有声书文件通过ttsService的存储后端下载
+func NewAudiobookHandler(manager *audiobook.Manager, ttsService *tts.TTSService, maxUploadMB int) *AudiobookHandler {
+	if maxUploadMB <= 0 {
+		maxUploadMB = defaultMaxUploadMB
+	}
+	return &AudiobookHandler{
+		manager:     manager,
+		ttsService:  ttsService,
+		maxUploadMB: maxUploadMB,
+	}
+}
+
+// CreateAudiobook 上传EPUB/Markdown/TXT文件，提交有声书任务
+func (h *AudiobookHandler) CreateAudiobook(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.maxUploadMB)<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "请上传文件",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "读取文件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "读取文件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	opts := audiobook.Options{
+		Title:  c.PostForm("title"),
+		Author: c.PostForm("author"),
+		Request: models.TTSRequest{
+			Voice:  c.PostForm("voice"),
+			Format: c.PostForm("format"),
+			Engine: c.PostForm("engine"),
+			Rate:   c.PostForm("rate"),
+			Pitch:  models.ProsodyValue(c.PostForm("pitch")),
+			Volume: models.ProsodyValue(c.PostForm("volume")),
+			Style:  c.PostForm("style"),
+			Role:   c.PostForm("role"),
+		},
+	}
+	if speed := c.PostForm("speed"); speed != "" {
+		if opts.Request.Speed, err = strconv.ParseFloat(speed, 64); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:    400,
+				Message: "语速参数无效",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+	if degree := c.PostForm("styledegree"); degree != "" {
+		if opts.Request.StyleDegree, err = strconv.ParseFloat(degree, 64); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:    400,
+				Message: "风格强度参数无效",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+	user := c.MustGet("user").(*models.User)
+	book, err := h.manager.Submit(user.ID, header.Filename, data, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "提交有声书失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    202,
+		"message": "有声书已提交",
+		"data":    book,
+	})
+}
+
+// ListAudiobooks 获取当前用户的有声书列表
+func (h *AudiobookHandler) ListAudiobooks(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	books, err := h.manager.List(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "查询有声书失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if books == nil {
+		books = []*models.Audiobook{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    books,
+	})
+}
+
+// GetAudiobook 查询有声书进度和章节
+func (h *AudiobookHandler) GetAudiobook(c *gin.Context) {
+	book, ok := h.findAudiobook(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    book,
+	})
+}
+
+// ResumeAudiobook 重新排队失败的有声书，从未完成的章节继续
+func (h *AudiobookHandler) ResumeAudiobook(c *gin.Context) {
+	book, ok := h.findAudiobook(c)
+	if !ok {
+		return
+	}
+
+	requeued, err := h.manager.Resume(book.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "恢复有声书失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if !requeued {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    409,
+			Message: "只有失败的有声书可以恢复",
+			Error:   "audiobook is " + book.Status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    202,
+		"message": "有声书已重新排队",
+	})
+}
+
+// ServeFile 下载章节文件或整本书，与缓存音频一样可以重定向到预签名地址
+func (h *AudiobookHandler) ServeFile(c *gin.Context) {
+	book, ok := h.findAudiobook(c)
+	if !ok {
+		return
+	}
+
+	key := audiobook.FileKey(book.ID, c.Param("name"))
+	info, err := h.ttsService.Storage().Stat(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) || errors.Is(err, storage.ErrInvalidKey) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Code:    404,
+				Message: "文件不存在",
+				Error:   "file not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "读取文件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	redirectURL, err := h.ttsService.AudioRedirectURL(c.Request.Context(), key)
+	if err != nil {
+		fmt.Printf("生成预签名地址失败，改为转发: %v\n", err)
+	}
+	if redirectURL != "" {
+		c.Redirect(http.StatusFound, redirectURL)
+		return
+	}
+
+	c.Header("Content-Type", "audio/mpeg")
+	serveObject(c, h.ttsService.Storage(), info)
+}
+
+// findAudiobook 查询有声书并检查归属，不存在或不属于当前用户时返回404
+func (h *AudiobookHandler) findAudiobook(c *gin.Context) (*models.Audiobook, bool) {
+	user := c.MustGet("user").(*models.User)
+
+	book, err := h.manager.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "查询有声书失败",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+	if book == nil || book.UserID != user.ID {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    404,
+			Message: "有声书不存在",
+			Error:   "audiobook not found",
+		})
+		return nil, false
+	}
+	return book, true
+}
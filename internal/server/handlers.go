@@ -1,11 +1,19 @@
 package server
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strconv"
 	"tts-service/internal/models"
+	"tts-service/internal/ssml"
+	"tts-service/internal/storage"
+	"tts-service/internal/subtitle"
 	"tts-service/internal/tts"
+	"tts-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -33,6 +41,7 @@ func (h *TTSHandler) Synthesize(c *gin.Context) {
 		})
 		return
 	}
+	req.UserID = c.MustGet("user").(*models.User).ID
 
 	// 验证请求参数
 	if req.Text == "" {
@@ -44,13 +53,29 @@ func (h *TTSHandler) Synthesize(c *gin.Context) {
 		return
 	}
 
+	// 流式返回音频数据
+	if req.Stream {
+		streamAudio(c, h.ttsService, &req, h.formatContentType, func(err error) {
+			status := errorStatus(c, err)
+			c.JSON(status, models.ErrorResponse{
+				Code:    status,
+				Message: "语音合成失败",
+				Error:   err.Error(),
+				Details: errorDetails(err),
+			})
+		})
+		return
+	}
+
 	// 处理TTS请求
 	result, err := h.ttsService.ProcessTTSRequest(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Code:    500,
+		status := errorStatus(c, err)
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
 			Message: "语音合成失败",
 			Error:   err.Error(),
+			Details: errorDetails(err),
 		})
 		return
 	}
@@ -63,6 +88,145 @@ func (h *TTSHandler) Synthesize(c *gin.Context) {
 	})
 }
 
+// Batch 批量合成，返回包含清单的ZIP或合并后的单个音频
+func (h *TTSHandler) Batch(c *gin.Context) {
+	var req models.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	user := c.MustGet("user").(*models.User)
+	for i := range req.Items {
+		req.Items[i].UserID = user.ID
+	}
+
+	if err := h.ttsService.NormalizeBatch(&req); err != nil {
+		// 参数错误返回400，加载语音目录等内部错误按类型返回
+		status := errorStatus(c, err)
+		message := "请求参数错误"
+		if status != http.StatusBadRequest {
+			message = "批量合成失败"
+		}
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: message,
+			Error:   err.Error(),
+			Details: errorDetails(err),
+		})
+		return
+	}
+
+	results, err := h.ttsService.ProcessBatch(c.Request.Context(), &req)
+	if err != nil {
+		status := errorStatus(c, err)
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: "批量合成失败",
+			Error:   err.Error(),
+			Details: errorDetails(err),
+		})
+		return
+	}
+
+	// 所有条目合成完成后再输出，此后的错误只能中断响应
+	if req.Output == models.BatchOutputMerged {
+		c.Header("Content-Type", h.formatContentType(req.Format))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "batch"+utils.GetFileExtension(req.Format)))
+		c.Status(http.StatusOK)
+		err = h.ttsService.WriteBatchMerged(c.Writer, &req, results)
+	} else {
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", `attachment; filename="batch.zip"`)
+		c.Status(http.StatusOK)
+		err = h.ttsService.WriteBatchZip(c.Writer, &req, results)
+	}
+	if err != nil {
+		fmt.Printf("输出批量合成结果失败: %v\n", err)
+		c.Abort()
+	}
+}
+
+// Subtitles 合成语音并返回对应的SRT/WebVTT字幕文件
+func (h *TTSHandler) Subtitles(c *gin.Context) {
+	var req models.TTSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	req.UserID = c.MustGet("user").(*models.User).ID
+
+	if req.Subtitles == "" {
+		req.Subtitles = subtitle.FormatSRT
+	}
+	if !subtitle.IsSupported(req.Subtitles) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "不支持的字幕格式",
+			Error:   "subtitles must be srt or vtt",
+		})
+		return
+	}
+
+	result, err := h.ttsService.ProcessTTSRequest(&req)
+	if err != nil {
+		status := errorStatus(c, err)
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: "字幕生成失败",
+			Error:   err.Error(),
+			Details: errorDetails(err),
+		})
+		return
+	}
+
+	filename := filepath.Base(result.SubtitleURL)
+	c.Header("Content-Type", subtitle.ContentType(req.Subtitles))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("X-Audio-URL", result.AudioURL)
+	if info, ok := h.statObject(c, h.ttsService.AudioKey(filename)); ok {
+		serveObject(c, h.ttsService.Storage(), info)
+	}
+}
+
+// Normalize 返回文本规范化的结果和每条规则的执行过程，不合成音频
+func (h *TTSHandler) Normalize(c *gin.Context) {
+	var req models.NormalizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := h.ttsService.NormalizeText(&req)
+	if err != nil {
+		status := errorStatus(c, err)
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: "文本规范化失败",
+			Error:   err.Error(),
+			Details: errorDetails(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    result,
+	})
+}
+
 // ServeAudio 提供音频文件服务
 func (h *TTSHandler) ServeAudio(c *gin.Context) {
 	filename := c.Param("filename")
@@ -75,63 +239,118 @@ func (h *TTSHandler) ServeAudio(c *gin.Context) {
 		return
 	}
 
-	// 获取音频文件路径
-	audioPath := h.ttsService.GetAudioFilePath(filename)
-	
+	// 获取音频的对象键
+	key := h.ttsService.AudioKey(filename)
+
 	// 检查文件是否存在
-	if _, err := os.Stat(audioPath); os.IsNotExist(err) {
+	info, ok := h.statObject(c, key)
+	if !ok {
+		return
+	}
+
+	h.ttsService.RecordAudioAccess(filename)
+
+	// 对象存储配置了重定向时，让客户端直接从预签名地址下载
+	redirectURL, err := h.ttsService.AudioRedirectURL(c.Request.Context(), key)
+	if err != nil {
+		fmt.Printf("生成预签名地址失败，改为转发: %v\n", err)
+	}
+	if redirectURL != "" {
+		c.Redirect(http.StatusFound, redirectURL)
+		return
+	}
+
+	// 设置响应头
+	c.Header("Content-Type", h.getContentType(filepath.Ext(filename)))
+	c.Header("Cache-Control", "public, max-age=3600")
+
+	// 提供文件服务
+	serveObject(c, h.ttsService.Storage(), info)
+}
+
+// statObject 获取存储中的对象信息，不存在或读取失败时写入错误响应
+func (h *TTSHandler) statObject(c *gin.Context, key string) (*storage.ObjectInfo, bool) {
+	info, err := h.ttsService.Storage().Stat(c.Request.Context(), key)
+	if err == nil {
+		return info, true
+	}
+
+	if errors.Is(err, storage.ErrNotExist) || errors.Is(err, storage.ErrInvalidKey) {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Code:    404,
 			Message: "音频文件不存在",
 			Error:   "audio file not found",
 		})
+		return nil, false
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Code:    500,
+		Message: "读取音频文件失败",
+		Error:   err.Error(),
+	})
+	return nil, false
+}
+
+// serveObject 转发存储中的对象，本地文件支持Range请求
+func serveObject(c *gin.Context, st storage.Storage, info *storage.ObjectInfo) {
+	r, err := st.Get(c.Request.Context(), info.Key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "读取音频文件失败",
+			Error:   err.Error(),
+		})
 		return
 	}
+	defer r.Close()
 
-	// 设置响应头
-	c.Header("Content-Type", h.getContentType(filepath.Ext(filename)))
-	c.Header("Cache-Control", "public, max-age=3600")
-	
-	// 提供文件服务
-	c.File(audioPath)
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, info.Key, info.ModTime, seeker)
+		return
+	}
+	c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+	c.Status(http.StatusOK)
+	if c.Request.Method != http.MethodHead {
+		io.Copy(c.Writer, r)
+	}
 }
 
-// HealthCheck 健康检查
+// HealthCheck 健康检查，所有引擎都熔断时状态为degraded
 func (h *TTSHandler) HealthCheck(c *gin.Context) {
+	breakers := h.ttsService.BreakerStatus()
+
+	status := "degraded"
+	for _, b := range breakers {
+		if b.State != tts.BreakerOpen {
+			status = "ok"
+			break
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "ok",
-		"service": "TTS Service",
-		"version": "1.0.0",
+		"status":   status,
+		"service":  "TTS Service",
+		"version":  "1.0.0",
+		"breakers": breakers,
+		"storage":  h.ttsService.StorageStatus(),
 	})
 }
 
-// GetVoices 获取可用语音列表（示例）
+// GetVoices 获取所有已启用引擎的语音列表
 func (h *TTSHandler) GetVoices(c *gin.Context) {
-	voices := []map[string]interface{}{
-		{
-			"name":        "zh-CN-XiaoxiaoNeural",
-			"language":    "zh-CN",
-			"gender":      "female",
-			"description": "中文女声",
-		},
-		{
-			"name":        "zh-CN-YunxiNeural", 
-			"language":    "zh-CN",
-			"gender":      "male",
-			"description": "中文男声",
-		},
-		{
-			"name":        "en-US-JennyNeural",
-			"language":    "en-US",
-			"gender":      "female", 
-			"description": "英语女声",
-		},
-		{
-			"name":        "en-US-GuyNeural",
-			"language":    "en-US",
-			"gender":      "male",
-			"description": "英语男声",
-		},
+	filter := tts.VoiceFilter{
+		Locale: c.Query("locale"),
+		Gender: c.Query("gender"),
+		Style:  c.Query("style"),
+	}
+	voices, err := h.ttsService.ListVoices(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "获取语音列表失败",
+			Error:   err.Error(),
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -141,6 +360,61 @@ func (h *TTSHandler) GetVoices(c *gin.Context) {
 	})
 }
 
+// GetEngines 获取已启用的TTS引擎及其能力
+func (h *TTSHandler) GetEngines(c *gin.Context) {
+	engines := []gin.H{}
+	for _, engine := range h.ttsService.Engines() {
+		engines = append(engines, gin.H{
+			"name":         engine.Name(),
+			"formats":      tts.EngineFormats(engine),
+			"capabilities": engine.Capabilities(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    engines,
+	})
+}
+
+// formatContentType 根据音频格式获取Content-Type
+func (h *TTSHandler) formatContentType(format string) string {
+	return h.getContentType(utils.GetFileExtension(format))
+}
+
+// errorStatus 根据错误类型确定HTTP状态码，引擎熔断时返回503并设置Retry-After
+func errorStatus(c *gin.Context, err error) int {
+	var unavailable *tts.UnavailableError
+	if errors.As(err, &unavailable) {
+		seconds := int(math.Ceil(unavailable.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		return http.StatusServiceUnavailable
+	}
+	var invalid *ssml.ValidationError
+	if errors.As(err, &invalid) {
+		return http.StatusBadRequest
+	}
+	var param *tts.ParamError
+	if errors.As(err, &param) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// errorDetails 提取结构化错误信息，没有时返回nil
+func errorDetails(err error) interface{} {
+	var invalid *ssml.ValidationError
+	if errors.As(err, &invalid) {
+		return invalid
+	}
+	var param *tts.ParamError
+	if errors.As(err, &param) {
+		return param
+	}
+	return nil
+}
+
 // getContentType 根据文件扩展名获取Content-Type
 func (h *TTSHandler) getContentType(ext string) string {
 	switch ext {
@@ -154,7 +428,17 @@ func (h *TTSHandler) getContentType(ext string) string {
 		return "audio/mp4"
 	case ".flac":
 		return "audio/flac"
+	case ".aac":
+		return "audio/aac"
+	case ".pcm":
+		return "audio/pcm"
+	case ".srt":
+		return subtitle.ContentType(subtitle.FormatSRT)
+	case ".vtt":
+		return subtitle.ContentType(subtitle.FormatVTT)
+	case ".json":
+		return "application/json"
 	default:
 		return "audio/mpeg"
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"net/http"
+	"tts-service/internal/job"
+	"tts-service/internal/models"
+	"tts-service/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler 异步任务处理器
+type JobHandler struct {
+	jobs     *job.Manager
+	webhooks *webhook.Dispatcher
+}
+
+// NewJobHandler 创建新的异步任务处理器
+func NewJobHandler(jobs *job.Manager, webhooks *webhook.Dispatcher) *JobHandler {
+	return &JobHandler{
+		jobs:     jobs,
+		webhooks: webhooks,
+	}
+}
+
+// CreateJob 提交异步合成任务，立即返回任务信息
+func (h *JobHandler) CreateJob(c *gin.Context) {
+	var req models.JobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if req.CallbackURL != "" {
+		if err := h.webhooks.ValidateURL(c.Request.Context(), req.CallbackURL); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:    400,
+				Message: "回调地址无效",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	user := c.MustGet("user").(*models.User)
+	created, err := h.jobs.Submit(user.ID, &req.TTSRequest, req.CallbackURL)
+	if err != nil {
+		status := errorStatus(c, err)
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: "提交任务失败",
+			Error:   err.Error(),
+			Details: errorDetails(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    202,
+		"message": "任务已提交",
+		"data":    created,
+	})
+}
+
+// GetJob 查询任务状态
+func (h *JobHandler) GetJob(c *gin.Context) {
+	found, ok := h.findJob(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    found,
+	})
+}
+
+// CancelJob 取消任务
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	found, ok := h.findJob(c)
+	if !ok {
+		return
+	}
+
+	if found.Status != models.JobQueued && found.Status != models.JobRunning {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    409,
+			Message: "任务已结束，无法取消",
+			Error:   "job is already " + found.Status,
+		})
+		return
+	}
+
+	cancelled, err := h.jobs.Cancel(found.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "取消任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "取消请求已提交",
+		"data":    cancelled,
+	})
+}
+
+// findJob 查找当前用户的任务，找不到时写入404响应
+func (h *JobHandler) findJob(c *gin.Context) (*models.Job, bool) {
+	found, err := h.jobs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "查询任务失败",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+
+	user := c.MustGet("user").(*models.User)
+	if found == nil || found.UserID != user.ID {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    404,
+			Message: "任务不存在",
+			Error:   "job not found",
+		})
+		return nil, false
+	}
+
+	return found, true
+}
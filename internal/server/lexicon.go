@@ -0,0 +1,405 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"tts-service/internal/db"
+	"tts-service/internal/lexicon"
+	"tts-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 每个API Key的词典条目上限和导入文件大小上限
+const (
+	maxLexiconEntries  = 5000
+	maxLexiconUploadMB = 5
+)
+
+// LexiconHandler 发音词典处理器
+type LexiconHandler struct {
+	db *db.DB
+}
+
+// NewLexiconHandler 创建新的发音词典处理器
+func NewLexiconHandler(database *db.DB) *LexiconHandler {
+	return &LexiconHandler{db: database}
+}
+
+// ListEntries 获取当前API Key的全部词典条目和词典版本
+func (h *LexiconHandler) ListEntries(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	entries, err := h.db.ListLexiconEntries(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "查询词典失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"version": lexicon.Version(entries),
+			"entries": entries,
+		},
+	})
+}
+
+// CreateEntry 添加词典条目
+func (h *LexiconHandler) CreateEntry(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	entry, ok := bindLexiconEntry(c)
+	if !ok {
+		return
+	}
+	count, err := h.db.CountLexiconEntries(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "查询词典失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if count >= maxLexiconEntries {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "词典条目数已达上限",
+			Error:   fmt.Sprintf("lexicon is limited to %d entries", maxLexiconEntries),
+		})
+		return
+	}
+
+	entry.UserID = user.ID
+	if err := h.db.CreateLexiconEntry(entry); err != nil {
+		lexiconWriteError(c, err, "添加词典条目失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "success",
+		"data":    entry,
+	})
+}
+
+// UpdateEntry 修改词典条目
+func (h *LexiconHandler) UpdateEntry(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	id, ok := lexiconEntryID(c)
+	if !ok {
+		return
+	}
+	entry, ok := bindLexiconEntry(c)
+	if !ok {
+		return
+	}
+
+	entry.ID = id
+	entry.UserID = user.ID
+	found, err := h.db.UpdateLexiconEntry(entry)
+	if err != nil {
+		lexiconWriteError(c, err, "修改词典条目失败")
+		return
+	}
+	if !found {
+		lexiconNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    entry,
+	})
+}
+
+// DeleteEntry 删除词典条目
+func (h *LexiconHandler) DeleteEntry(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	id, ok := lexiconEntryID(c)
+	if !ok {
+		return
+	}
+	found, err := h.db.DeleteLexiconEntry(user.ID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "删除词典条目失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if !found {
+		lexiconNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "词典条目已删除",
+	})
+}
+
+// ImportEntries 导入CSV或PLS格式的词典
+// 文件通过multipart的file字段或直接作为请求体上传；format参数省略时按文件扩展名和内容判断
+// mode=merge(默认)时同一词语覆盖原读法，mode=replace时先清空原有词典
+func (h *LexiconHandler) ImportEntries(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxLexiconUploadMB<<20)
+
+	mode := c.DefaultQuery("mode", "merge")
+	if mode != "merge" && mode != "replace" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "导入方式无效",
+			Error:   "mode must be merge or replace",
+		})
+		return
+	}
+
+	data, filename, err := readLexiconUpload(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "读取文件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = sniffLexiconFormat(filename, data)
+	}
+	var entries []models.LexiconEntry
+	switch format {
+	case lexicon.FormatCSV:
+		entries, err = lexicon.ParseCSV(bytes.NewReader(data))
+	case lexicon.FormatPLS:
+		entries, err = lexicon.ParsePLS(bytes.NewReader(data))
+	default:
+		err = fmt.Errorf("不支持的格式 %s，可选值: %s", format, strings.Join(lexicon.Formats, ", "))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "解析词典失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 合并后的条目数，同一词语和语言只计一次
+	keys := make(map[string]bool)
+	if mode == "merge" {
+		existing, err := h.db.ListLexiconEntries(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Code:    500,
+				Message: "查询词典失败",
+				Error:   err.Error(),
+			})
+			return
+		}
+		for _, e := range existing {
+			keys[e.Word+"\x00"+e.Language] = true
+		}
+	}
+	for _, e := range entries {
+		keys[e.Word+"\x00"+e.Language] = true
+	}
+	if len(keys) > maxLexiconEntries {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "词典条目数超过上限",
+			Error:   fmt.Sprintf("lexicon is limited to %d entries, got %d", maxLexiconEntries, len(keys)),
+		})
+		return
+	}
+
+	if err := h.db.ImportLexiconEntries(user.ID, entries, mode == "replace"); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "导入词典失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	all, err := h.db.ListLexiconEntries(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "查询词典失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "词典已导入",
+		"data": gin.H{
+			"imported": len(entries),
+			"total":    len(all),
+			"version":  lexicon.Version(all),
+		},
+	})
+}
+
+// ExportEntries 以CSV或PLS格式下载词典
+func (h *LexiconHandler) ExportEntries(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	format := strings.ToLower(c.DefaultQuery("format", lexicon.FormatCSV))
+	var write func(io.Writer, []models.LexiconEntry) error
+	var contentType string
+	switch format {
+	case lexicon.FormatCSV:
+		write, contentType = lexicon.WriteCSV, "text/csv; charset=utf-8"
+	case lexicon.FormatPLS:
+		write, contentType = lexicon.WritePLS, "application/pls+xml"
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "导出格式无效",
+			Error:   fmt.Sprintf("format must be one of: %s", strings.Join(lexicon.Formats, ", ")),
+		})
+		return
+	}
+
+	entries, err := h.db.ListLexiconEntries(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "查询词典失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, entries); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "导出词典失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="lexicon.%s"`, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// bindLexiconEntry 解析并校验请求体中的词典条目
+func bindLexiconEntry(c *gin.Context) (*models.LexiconEntry, bool) {
+	var entry models.LexiconEntry
+	if err := c.ShouldBindJSON(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+	if err := lexicon.Validate(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "词典条目无效",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+	return &entry, true
+}
+
+// lexiconEntryID 解析路径中的条目ID
+func lexiconEntryID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "词典条目ID无效",
+			Error:   "invalid lexicon entry id",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// lexiconWriteError 写入条目失败，词语重复时返回409
+func lexiconWriteError(c *gin.Context, err error, message string) {
+	if errors.Is(err, db.ErrLexiconConflict) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    409,
+			Message: "同一语言下的词语已存在",
+			Error:   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Code:    500,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+func lexiconNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, models.ErrorResponse{
+		Code:    404,
+		Message: "词典条目不存在",
+		Error:   "lexicon entry not found",
+	})
+}
+
+// readLexiconUpload 读取multipart上传的文件，不是multipart请求时读取整个请求体
+func readLexiconUpload(c *gin.Context) ([]byte, string, error) {
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		return data, header.Filename, err
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err == nil && len(bytes.TrimSpace(data)) == 0 {
+		err = errors.New("请求体为空")
+	}
+	return data, "", err
+}
+
+// sniffLexiconFormat 按扩展名判断格式，没有扩展名时以 < 开头的内容视为PLS
+func sniffLexiconFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pls", ".xml":
+		return lexicon.FormatPLS
+	case ".csv", ".txt":
+		return lexicon.FormatCSV
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff"))), []byte("<")) {
+		return lexicon.FormatPLS
+	}
+	return lexicon.FormatCSV
+}
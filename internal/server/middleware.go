@@ -13,57 +13,93 @@ import (
 // AuthMiddleware API认证中间件
 func AuthMiddleware(database *db.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取Authorization头
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Code:    401,
-				Message: "需要提供API Key",
-				Error:   "Authorization header is required",
-			})
+		user, failure := authenticate(database, c.GetHeader("Authorization"))
+		if failure != nil {
+			c.JSON(http.StatusUnauthorized, failure)
 			c.Abort()
 			return
 		}
 
-		// 解析Bearer token
-		const bearerPrefix = "Bearer "
-		if !strings.HasPrefix(authHeader, bearerPrefix) {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Code:    401,
-				Message: "无效的认证格式",
-				Error:   "Authorization header must start with 'Bearer '",
-			})
+		// 将用户信息存储到上下文中
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+// OpenAIAuthMiddleware OpenAI兼容接口的认证中间件，错误按OpenAI的格式返回，官方SDK能识别为认证错误
+func OpenAIAuthMiddleware(database *db.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, failure := authenticate(database, c.GetHeader("Authorization"))
+		if failure != nil {
+			openAIError(c, http.StatusUnauthorized, failure.Message, "", "invalid_api_key")
 			c.Abort()
 			return
 		}
 
-		apiKey := strings.TrimPrefix(authHeader, bearerPrefix)
-		if apiKey == "" {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Code:    401,
-				Message: "API Key不能为空",
-				Error:   "API key is empty",
-			})
-			c.Abort()
-			return
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+// AdminMiddleware 管理接口的权限检查，需在AuthMiddleware之后使用，只允许 server.admin_keys 中的API Key访问
+func AdminMiddleware(adminKeys []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(*models.User)
+		for _, key := range adminKeys {
+			if key != "" && key == user.APIKey {
+				c.Next()
+				return
+			}
 		}
 
-		// 验证API Key
-		user, err := database.GetUserByAPIKey(apiKey)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Code:    401,
-				Message: "无效的API Key",
-				Error:   "Invalid API key",
-			})
-			c.Abort()
-			return
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Code:    403,
+			Message: "没有管理权限",
+			Error:   "API key is not listed in server.admin_keys",
+		})
+		c.Abort()
+	}
+}
+
+// authenticate 校验Bearer API Key，失败时返回错误响应
+func authenticate(database *db.DB, authHeader string) (*models.User, *models.ErrorResponse) {
+	if authHeader == "" {
+		return nil, &models.ErrorResponse{
+			Code:    401,
+			Message: "需要提供API Key",
+			Error:   "Authorization header is required",
 		}
+	}
 
-		// 将用户信息存储到上下文中
-		c.Set("user", user)
-		c.Next()
+	// 解析Bearer token
+	const bearerPrefix = "Bearer "
+	if !strings.HasPrefix(authHeader, bearerPrefix) {
+		return nil, &models.ErrorResponse{
+			Code:    401,
+			Message: "无效的认证格式",
+			Error:   "Authorization header must start with 'Bearer '",
+		}
+	}
+
+	apiKey := strings.TrimPrefix(authHeader, bearerPrefix)
+	if apiKey == "" {
+		return nil, &models.ErrorResponse{
+			Code:    401,
+			Message: "API Key不能为空",
+			Error:   "API key is empty",
+		}
 	}
+
+	// 验证API Key
+	user, err := database.GetUserByAPIKey(apiKey)
+	if err != nil {
+		return nil, &models.ErrorResponse{
+			Code:    401,
+			Message: "无效的API Key",
+			Error:   "Invalid API key",
+		}
+	}
+	return user, nil
 }
 
 // CORSMiddleware CORS中间件
@@ -104,4 +140,4 @@ func LoggingMiddleware() gin.HandlerFunc {
 // ErrorHandlingMiddleware 错误处理中间件
 func ErrorHandlingMiddleware() gin.HandlerFunc {
 	return gin.Recovery()
-}
\ No newline at end of file
+}
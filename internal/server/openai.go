@@ -1,8 +1,15 @@
 package server
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"tts-service/internal/models"
+	"tts-service/internal/ssml"
 	"tts-service/internal/tts"
 
 	"github.com/gin-gonic/gin"
@@ -20,113 +27,279 @@ func NewOpenAIHandler(ttsService *tts.TTSService) *OpenAIHandler {
 	}
 }
 
+// openAIFormats OpenAI的response_format与内部音频格式的对应关系
+// opus以Ogg封装输出，与OpenAI一致
+var openAIFormats = map[string]string{
+	"mp3":  "mp3",
+	"opus": "ogg",
+	"aac":  "aac",
+	"flac": "flac",
+	"wav":  "wav",
+	"pcm":  "pcm",
+}
+
+// OpenAI接口的语速范围
+const (
+	openAIMinSpeed = 0.25
+	openAIMaxSpeed = 4.0
+)
+
 // CreateSpeech OpenAI兼容的语音合成接口
 func (h *OpenAIHandler) CreateSpeech(c *gin.Context) {
 	var req models.OpenAITTSRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"message": "请求参数错误: " + err.Error(),
-				"type":    "invalid_request_error",
-			},
-		})
+		openAIError(c, http.StatusBadRequest, "请求参数错误: "+err.Error(), "", "")
 		return
 	}
 
-	// 验证必要参数
-	if req.Input == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"message": "input字段不能为空",
-				"type":    "invalid_request_error",
-			},
-		})
+	// 转换OpenAI请求为内部TTS请求
+	ttsReq, err := h.convertOpenAIRequest(&req)
+	if err != nil {
+		writeOpenAIError(c, err)
 		return
 	}
+	ttsReq.UserID = c.MustGet("user").(*models.User).ID
 
-	if req.Voice == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"message": "voice字段不能为空",
-				"type":    "invalid_request_error",
-			},
-		})
+	// 语音别名先确定语音和默认韵律，指令中的风格和韵律在其基础上调整
+	if err := h.ttsService.ResolveVoiceAlias(ttsReq, req.Voice); err != nil {
+		writeOpenAIError(c, err)
 		return
 	}
+	h.ttsService.ApplyInstructions(ttsReq, req.Instructions)
 
-	// 转换OpenAI请求为内部TTS请求
-	ttsReq := h.convertOpenAIRequest(&req)
-
-	// 处理TTS请求
-	result, err := h.ttsService.ProcessTTSRequest(ttsReq)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"message": "语音合成失败: " + err.Error(),
-				"type":    "server_error",
-			},
-		})
+	if req.StreamFormat == "sse" {
+		h.streamEvents(c, ttsReq, req.Input)
 		return
 	}
 
-	// 获取音频文件路径
-	audioPath := h.ttsService.GetAudioFilePath(result.AudioURL[len("/api/v1/audio/"):])
-	
-	// 设置响应头并直接返回音频文件
-	c.Header("Content-Type", h.getContentType(ttsReq.Format))
-	c.Header("Transfer-Encoding", "chunked")
-	
-	// 直接提供文件下载
-	c.File(audioPath)
+	// 边合成边返回音频数据
+	streamAudio(c, h.ttsService, ttsReq, func(string) string {
+		return h.getContentType(req.ResponseFormat)
+	}, func(err error) {
+		writeOpenAIError(c, err)
+	})
 }
 
-// convertOpenAIRequest 将OpenAI请求转换为内部TTS请求
-func (h *OpenAIHandler) convertOpenAIRequest(req *models.OpenAITTSRequest) *models.TTSRequest {
-	// OpenAI语音映射到Edge TTS语音
-	voice := h.mapOpenAIVoice(req.Voice)
-	
+// convertOpenAIRequest 校验OpenAI请求并转换为内部TTS请求
+func (h *OpenAIHandler) convertOpenAIRequest(req *models.OpenAITTSRequest) (*models.TTSRequest, error) {
+	// 验证必要参数
+	for _, field := range []struct{ name, value string }{
+		{"model", req.Model},
+		{"input", req.Input},
+		{"voice", req.Voice},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			return nil, &tts.ParamError{Param: field.name, Message: field.name + "字段不能为空"}
+		}
+	}
+
 	// 默认音频格式
-	format := "mp3"
-	if req.ResponseFormat != "" {
-		format = req.ResponseFormat
+	if req.ResponseFormat == "" {
+		req.ResponseFormat = "mp3"
+	}
+	format, ok := openAIFormats[req.ResponseFormat]
+	if !ok {
+		return nil, &tts.ParamError{
+			Param:     "response_format",
+			Value:     req.ResponseFormat,
+			Message:   "不支持的音频格式",
+			Supported: []string{"mp3", "opus", "aac", "flac", "wav", "pcm"},
+		}
 	}
-	
+
 	// 默认语速
 	speed := 1.0
-	if req.Speed > 0 {
-		speed = req.Speed
+	if req.Speed != nil {
+		speed = *req.Speed
+		if speed < openAIMinSpeed || speed > openAIMaxSpeed {
+			return nil, &tts.ParamError{
+				Param:   "speed",
+				Value:   strconv.FormatFloat(speed, 'f', -1, 64),
+				Message: fmt.Sprintf("语速必须在 %g 到 %g 之间", openAIMinSpeed, openAIMaxSpeed),
+			}
+		}
+	}
+
+	switch req.StreamFormat {
+	case "", "audio", "sse":
+	default:
+		return nil, &tts.ParamError{
+			Param:     "stream_format",
+			Value:     req.StreamFormat,
+			Message:   "不支持的输出方式",
+			Supported: []string{"audio", "sse"},
+		}
 	}
 
 	return &models.TTSRequest{
 		Text:   req.Input,
-		Voice:  voice,
 		Format: format,
 		Speed:  speed,
-		Pitch:  0,
-		Volume: 1.0,
 		Style:  "default",
 		SSML:   false,
+	}, nil
+}
+
+// streamEvents 以SSE输出音频，每段音频为一个speech.audio.delta事件，结束时输出speech.audio.done
+func (h *OpenAIHandler) streamEvents(c *gin.Context, req *models.TTSRequest, input string) {
+	if err := h.ttsService.NormalizeRequest(req); err != nil {
+		writeOpenAIError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	events := &sseWriter{w: c.Writer}
+	if _, err := h.ttsService.StreamTTSRequest(c.Request.Context(), req, events); err != nil {
+		if !events.written {
+			c.Writer.Header().Del("Content-Type")
+			writeOpenAIError(c, err)
+			return
+		}
+		fmt.Printf("音频流中断: %v\n", err)
+		c.Abort()
+		return
+	}
+
+	// 本服务不按token计费，usage按输入文本估算，便于客户端统计
+	tokens := estimateTokens(input)
+	events.event(gin.H{
+		"type": "speech.audio.done",
+		"usage": gin.H{
+			"input_tokens":  tokens,
+			"output_tokens": 0,
+			"total_tokens":  tokens,
+		},
+	})
+}
+
+// sseWriter 把音频数据编码为speech.audio.delta事件
+type sseWriter struct {
+	w       gin.ResponseWriter
+	written bool
+}
+
+func (s *sseWriter) Write(p []byte) (int, error) {
+	if err := s.event(gin.H{
+		"type":  "speech.audio.delta",
+		"audio": base64.StdEncoding.EncodeToString(p),
+	}); err != nil {
+		return 0, err
 	}
+	return len(p), nil
 }
 
-// mapOpenAIVoice 将OpenAI语音名称映射到Edge TTS语音
-func (h *OpenAIHandler) mapOpenAIVoice(openaiVoice string) string {
-	voiceMap := map[string]string{
-		// OpenAI语音 -> Edge TTS语音
-		"alloy":   "en-US-JennyNeural",
-		"echo":    "en-US-GuyNeural", 
-		"fable":   "en-US-DavisNeural",
-		"onyx":    "en-US-JasonNeural",
-		"nova":    "en-US-SaraNeural",
-		"shimmer": "en-US-AriaNeural",
+// event 输出一个SSE事件并立即刷新
+func (s *sseWriter) event(data gin.H) error {
+	s.written = true
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", body); err != nil {
+		return err
 	}
+	s.w.Flush()
+	return nil
+}
 
-	if edgeVoice, exists := voiceMap[openaiVoice]; exists {
-		return edgeVoice
+// estimateTokens 粗略估算文本的token数：CJK字符每字一个，其余每4个字符一个
+func estimateTokens(text string) int {
+	var cjk, other int
+	for _, r := range text {
+		if r >= 0x2E80 {
+			cjk++
+		} else {
+			other++
+		}
 	}
+	return cjk + (other+3)/4
+}
 
-	// 如果没有映射，直接使用原始语音名（可能是Edge TTS的语音名）
-	return openaiVoice
+// openAIFormatName 内部音频格式对应的OpenAI名称
+func openAIFormatName(format string) string {
+	for name, internal := range openAIFormats {
+		if internal == format {
+			return name
+		}
+	}
+	return format
+}
+
+// openAIParams 内部参数名与OpenAI参数名不同的部分
+var openAIParams = map[string]string{
+	"format":      "response_format",
+	"rate":        "speed",
+	"style":       "instructions",
+	"styledegree": "instructions",
+	"pitch":       "instructions",
+	"volume":      "instructions",
+	"text":        "input",
+}
+
+// writeOpenAIError 把合成错误转换为OpenAI格式的错误响应
+func writeOpenAIError(c *gin.Context, err error) {
+	status := errorStatus(c, err)
+
+	var param *tts.ParamError
+	if errors.As(err, &param) {
+		openAIParam := *param
+		if mapped, ok := openAIParams[param.Param]; ok {
+			openAIParam.Param = mapped
+		}
+		// 音频格式使用OpenAI的名称，只列出OpenAI支持的格式
+		if param.Param == "format" {
+			openAIParam.Value = openAIFormatName(param.Value)
+			openAIParam.Supported = nil
+			for _, format := range param.Supported {
+				if name := openAIFormatName(format); openAIFormats[name] != "" {
+					openAIParam.Supported = append(openAIParam.Supported, name)
+				}
+			}
+		}
+
+		// 缺少参数时没有可以判定为无效的取值，code为null
+		code := ""
+		if param.Value != "" {
+			code = "invalid_value"
+		}
+		openAIError(c, status, openAIParam.Error(), openAIParam.Param, code)
+		return
+	}
+
+	message := err.Error()
+	if status >= http.StatusInternalServerError {
+		message = "语音合成失败: " + message
+	}
+	openAIError(c, status, message, "", "")
+}
+
+// openAIError 以OpenAI的错误格式响应：{"error":{"message","type","param","code"}}
+// param和code为空时输出null
+func openAIError(c *gin.Context, status int, message, param, code string) {
+	errorType := "invalid_request_error"
+	switch {
+	case status == http.StatusTooManyRequests:
+		errorType = "rate_limit_error"
+	case status >= http.StatusInternalServerError:
+		errorType = "server_error"
+	}
+
+	body := gin.H{
+		"message": message,
+		"type":    errorType,
+		"param":   nil,
+		"code":    nil,
+	}
+	if param != "" {
+		body["param"] = param
+	}
+	if code != "" {
+		body["code"] = code
+	}
+	c.JSON(status, gin.H{"error": body})
 }
 
 // getContentType 根据格式获取Content-Type
@@ -157,22 +330,31 @@ func (h *OpenAIHandler) GetModels(c *gin.Context) {
 		"object": "list",
 		"data": []gin.H{
 			{
-				"id":       "tts-1",
-				"object":   "model",
-				"created":  1677610602,
-				"owned_by": "openai-internal",
+				"id":         "tts-1",
+				"object":     "model",
+				"created":    1677610602,
+				"owned_by":   "openai-internal",
+				"permission": []gin.H{},
+				"root":       "tts-1",
+				"parent":     nil,
+			},
+			{
+				"id":         "tts-1-hd",
+				"object":     "model",
+				"created":    1677610602,
+				"owned_by":   "openai-internal",
 				"permission": []gin.H{},
-				"root":     "tts-1",
-				"parent":   nil,
+				"root":       "tts-1-hd",
+				"parent":     nil,
 			},
 			{
-				"id":       "tts-1-hd",
-				"object":   "model", 
-				"created":  1677610602,
-				"owned_by": "openai-internal",
+				"id":         "gpt-4o-mini-tts",
+				"object":     "model",
+				"created":    1742403959,
+				"owned_by":   "system",
 				"permission": []gin.H{},
-				"root":     "tts-1-hd",
-				"parent":   nil,
+				"root":       "gpt-4o-mini-tts",
+				"parent":     nil,
 			},
 		},
 	}
@@ -180,54 +362,95 @@ func (h *OpenAIHandler) GetModels(c *gin.Context) {
 	c.JSON(http.StatusOK, models)
 }
 
-// GetVoicesOpenAI 获取OpenAI兼容的语音列表
+// GetVoicesOpenAI 获取当前API Key可用的OpenAI语音别名
 func (h *OpenAIHandler) GetVoicesOpenAI(c *gin.Context) {
-	voices := []gin.H{
-		{
-			"id":          "alloy",
-			"name":        "Alloy",
-			"description": "A balanced, neutral voice",
-			"language":    "en-US",
-			"gender":      "neutral",
-		},
-		{
-			"id":          "echo", 
-			"name":        "Echo",
-			"description": "A clear, expressive voice",
-			"language":    "en-US",
-			"gender":      "male",
-		},
-		{
-			"id":          "fable",
-			"name":        "Fable",
-			"description": "A warm, storytelling voice", 
-			"language":    "en-US",
-			"gender":      "neutral",
-		},
-		{
-			"id":          "onyx",
-			"name":        "Onyx",
-			"description": "A deep, authoritative voice",
-			"language":    "en-US", 
-			"gender":      "male",
-		},
-		{
-			"id":          "nova",
-			"name":        "Nova",
-			"description": "A bright, energetic voice",
-			"language":    "en-US",
-			"gender":      "female",
-		},
-		{
-			"id":          "shimmer",
-			"name":        "Shimmer", 
-			"description": "A soft, elegant voice",
-			"language":    "en-US",
-			"gender":      "female",
-		},
+	user := c.MustGet("user").(*models.User)
+	aliases, err := h.ttsService.VoiceAliases(user.ID)
+	if err != nil {
+		openAIError(c, http.StatusInternalServerError, "获取语音别名失败: "+err.Error(), "", "")
+		return
+	}
+
+	voices := []gin.H{}
+	for _, alias := range aliases {
+		voices = append(voices, gin.H{
+			"id":          alias.Name,
+			"name":        strings.ToUpper(alias.Name[:1]) + alias.Name[1:],
+			"description": alias.Description,
+			"language":    ssml.VoiceLocale(alias.Voice),
+			"gender":      alias.Gender,
+			"voice":       alias.Voice,
+			"locales":     alias.Locales,
+			"speed":       alias.Speed,
+			"pitch":       alias.Pitch,
+			"volume":      alias.Volume,
+			"style":       alias.Style,
+			"source":      alias.Source,
+		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"voices": voices,
 	})
-}
\ No newline at end of file
+}
+
+// PutVoiceAlias 创建或替换当前API Key的语音别名，同名时覆盖配置或内置的别名
+func (h *OpenAIHandler) PutVoiceAlias(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var alias models.VoiceAlias
+	if err := c.ShouldBindJSON(&alias); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	alias.UserID = user.ID
+	alias.Name = c.Param("name")
+
+	if err := h.ttsService.SaveVoiceAlias(&alias); err != nil {
+		c.JSON(errorStatus(c, err), models.ErrorResponse{
+			Code:    errorStatus(c, err),
+			Message: "保存语音别名失败",
+			Error:   err.Error(),
+			Details: errorDetails(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    alias,
+	})
+}
+
+// DeleteVoiceAlias 删除当前API Key的语音别名
+func (h *OpenAIHandler) DeleteVoiceAlias(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	found, err := h.ttsService.DeleteVoiceAlias(user.ID, c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "删除语音别名失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    404,
+			Message: "语音别名不存在",
+			Error:   "voice alias not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+	})
+}
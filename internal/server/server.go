@@ -1,10 +1,15 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"tts-service/internal/audiobook"
 	"tts-service/internal/config"
 	"tts-service/internal/db"
+	"tts-service/internal/job"
+	"tts-service/internal/storage"
 	"tts-service/internal/tts"
+	"tts-service/internal/webhook"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,11 +19,14 @@ type Server struct {
 	config     *config.Config
 	db         *db.DB
 	ttsService *tts.TTSService
+	jobs       *job.Manager
+	webhooks   *webhook.Dispatcher
+	audiobooks *audiobook.Manager
 	router     *gin.Engine
 }
 
 // New 创建新的服务器实例
-func New(cfg *config.Config, database *db.DB) *Server {
+func New(cfg *config.Config, database *db.DB, store storage.Storage) *Server {
 	// 设置Gin模式
 	if cfg.Logging.Level == "debug" {
 		gin.SetMode(gin.DebugMode)
@@ -27,12 +35,26 @@ func New(cfg *config.Config, database *db.DB) *Server {
 	}
 
 	// 创建TTS服务
-	ttsService := tts.NewTTSService(database, cfg)
+	ttsService := tts.NewTTSService(database, cfg, store)
+
+	// 创建异步任务管理器
+	jobs := job.NewManager(database, ttsService, cfg.Jobs.Workers)
+
+	// 任务结束后发送Webhook通知
+	webhooks := webhook.NewDispatcher(database, cfg)
+	jobs.SetNotifier(webhooks)
+
+	// 创建有声书任务管理器
+	builder := audiobook.NewBuilder(database, ttsService, cfg)
+	audiobooks := audiobook.NewManager(database, builder, cfg.Audiobook.Workers)
 
 	server := &Server{
 		config:     cfg,
 		db:         database,
 		ttsService: ttsService,
+		jobs:       jobs,
+		webhooks:   webhooks,
+		audiobooks: audiobooks,
 		router:     gin.New(),
 	}
 
@@ -52,12 +74,18 @@ func (s *Server) setupRoutes() {
 	// 创建处理器
 	ttsHandler := NewTTSHandler(s.ttsService)
 	openaiHandler := NewOpenAIHandler(s.ttsService)
+	jobHandler := NewJobHandler(s.jobs, s.webhooks)
+	webhookHandler := NewWebhookHandler(s.db, s.jobs, s.webhooks)
+	audiobookHandler := NewAudiobookHandler(s.audiobooks, s.ttsService, s.config.Audiobook.MaxUploadMB)
+	lexiconHandler := NewLexiconHandler(s.db)
+	adminHandler := NewAdminHandler(s.ttsService)
 
 	// 公开路由（无需认证）
 	public := s.router.Group("/api/v1")
 	{
 		public.GET("/health", ttsHandler.HealthCheck)
 		public.GET("/voices", ttsHandler.GetVoices)
+		public.GET("/engines", ttsHandler.GetEngines)
 		public.GET("/audio/:filename", ttsHandler.ServeAudio)
 	}
 
@@ -67,11 +95,61 @@ func (s *Server) setupRoutes() {
 	{
 		// 基础TTS接口
 		private.POST("/tts/synthesize", ttsHandler.Synthesize)
-		
-		// OpenAI兼容接口
-		private.POST("/audio/speech", openaiHandler.CreateSpeech)
-		private.GET("/models", openaiHandler.GetModels)
-		private.GET("/voices/openai", openaiHandler.GetVoicesOpenAI)
+		private.POST("/tts/subtitles", ttsHandler.Subtitles)
+		private.POST("/tts/batch", ttsHandler.Batch)
+		private.POST("/tts/normalize", ttsHandler.Normalize)
+
+		// 异步任务接口
+		private.POST("/tts/jobs", jobHandler.CreateJob)
+		private.GET("/tts/jobs/:id", jobHandler.GetJob)
+		private.DELETE("/tts/jobs/:id", jobHandler.CancelJob)
+		private.GET("/tts/jobs/:id/deliveries", webhookHandler.ListDeliveries)
+
+		// Webhook接口
+		private.GET("/webhooks/secret", webhookHandler.GetSecret)
+		private.POST("/webhooks/secret", webhookHandler.RotateSecret)
+		private.POST("/webhooks/deliveries/:id/replay", webhookHandler.ReplayDelivery)
+
+		// 有声书接口
+		private.POST("/audiobooks", audiobookHandler.CreateAudiobook)
+		private.GET("/audiobooks", audiobookHandler.ListAudiobooks)
+		private.GET("/audiobooks/:id", audiobookHandler.GetAudiobook)
+		private.POST("/audiobooks/:id/resume", audiobookHandler.ResumeAudiobook)
+		private.GET("/audiobooks/:id/files/:name", audiobookHandler.ServeFile)
+
+		// 发音词典接口
+		private.GET("/lexicon", lexiconHandler.ListEntries)
+		private.POST("/lexicon", lexiconHandler.CreateEntry)
+		private.PUT("/lexicon/:id", lexiconHandler.UpdateEntry)
+		private.DELETE("/lexicon/:id", lexiconHandler.DeleteEntry)
+		private.POST("/lexicon/import", lexiconHandler.ImportEntries)
+		private.GET("/lexicon/export", lexiconHandler.ExportEntries)
+
+		// OpenAI语音别名管理
+		private.PUT("/voices/openai/:name", openaiHandler.PutVoiceAlias)
+		private.DELETE("/voices/openai/:name", openaiHandler.DeleteVoiceAlias)
+	}
+
+	// OpenAI兼容接口，认证失败等错误使用OpenAI的错误格式
+	openai := s.router.Group("/api/v1")
+	openai.Use(OpenAIAuthMiddleware(s.db))
+	{
+		openai.POST("/audio/speech", openaiHandler.CreateSpeech)
+		openai.GET("/models", openaiHandler.GetModels)
+		openai.GET("/voices/openai", openaiHandler.GetVoicesOpenAI)
+	}
+
+	// 管理接口
+	admin := s.router.Group("/api/v1/admin")
+	admin.Use(AuthMiddleware(s.db), AdminMiddleware(s.config.Server.AdminKeys))
+	{
+		admin.GET("/cleanup", adminHandler.GetCleanup)
+		admin.POST("/cleanup", adminHandler.RunCleanup)
+		admin.GET("/metrics", adminHandler.Metrics)
+
+		// 缓存固定影响所有用户共享的缓存，只允许管理员操作
+		admin.PUT("/cache/:filename/pin", adminHandler.PinCache)
+		admin.DELETE("/cache/:filename/pin", adminHandler.UnpinCache)
 	}
 
 	// 根路径
@@ -86,16 +164,37 @@ func (s *Server) setupRoutes() {
 
 // Start 启动服务器
 func (s *Server) Start() error {
+	s.ttsService.Start(context.Background())
+
+	// 清理旧版本缓存键的缓存，文件较多时可能耗时较长，不阻塞启动
+	go func() {
+		removed, err := s.ttsService.MigrateCache()
+		if err != nil {
+			fmt.Printf("清理旧版缓存失败: %v\n", err)
+		}
+		if removed > 0 {
+			fmt.Printf("清理了 %d 条旧版缓存记录\n", removed)
+		}
+	}()
+
+	s.webhooks.Start(context.Background())
+	if err := s.jobs.Start(context.Background()); err != nil {
+		return fmt.Errorf("启动任务管理器失败: %w", err)
+	}
+	if err := s.audiobooks.Start(context.Background()); err != nil {
+		return fmt.Errorf("启动有声书任务管理器失败: %w", err)
+	}
+
 	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
 	fmt.Printf("🚀 TTS服务启动成功！\n")
 	fmt.Printf("📡 监听地址: http://%s\n", addr)
 	fmt.Printf("🔍 健康检查: http://%s/api/v1/health\n", addr)
 	fmt.Printf("📚 API文档: http://%s/\n", addr)
-	
+
 	return s.router.Run(addr)
 }
 
 // GetRouter 获取路由器（用于测试）
 func (s *Server) GetRouter() *gin.Engine {
 	return s.router
-}
\ No newline at end of file
+}
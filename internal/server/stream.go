@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"tts-service/internal/models"
+	"tts-service/internal/tts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// flushWriter 每次写入后立即刷新，让音频帧实时到达客户端
+type flushWriter struct {
+	w       gin.ResponseWriter
+	written bool
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	f.written = true
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	f.w.Flush()
+	return n, nil
+}
+
+// streamAudio 将合成的音频实时写入响应
+// 开始输出音频之前发生的错误交给onError处理；输出开始后出错只能中断连接
+func streamAudio(c *gin.Context, ttsService *tts.TTSService, req *models.TTSRequest, contentType func(format string) string, onError func(err error)) {
+	if err := ttsService.NormalizeRequest(req); err != nil {
+		onError(err)
+		return
+	}
+
+	writer := &flushWriter{w: c.Writer}
+	c.Header("Content-Type", contentType(req.Format))
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	if _, err := ttsService.StreamTTSRequest(c.Request.Context(), req, writer); err != nil {
+		if !writer.written {
+			c.Writer.Header().Del("Content-Type")
+			onError(err)
+			return
+		}
+		fmt.Printf("音频流中断: %v\n", err)
+		c.Abort()
+	}
+}
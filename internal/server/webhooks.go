@@ -0,0 +1,143 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"tts-service/internal/db"
+	"tts-service/internal/job"
+	"tts-service/internal/models"
+	"tts-service/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler Webhook处理器
+type WebhookHandler struct {
+	db         *db.DB
+	jobs       *job.Manager
+	dispatcher *webhook.Dispatcher
+}
+
+// NewWebhookHandler 创建新的Webhook处理器
+func NewWebhookHandler(database *db.DB, jobs *job.Manager, dispatcher *webhook.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{
+		db:         database,
+		jobs:       jobs,
+		dispatcher: dispatcher,
+	}
+}
+
+// GetSecret 获取当前API Key的签名密钥，尚未生成时自动生成
+func (h *WebhookHandler) GetSecret(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	secret, err := h.dispatcher.Secret(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "获取Webhook密钥失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    gin.H{"secret": secret},
+	})
+}
+
+// RotateSecret 重新生成签名密钥
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	secret, err := h.dispatcher.RotateSecret(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "生成Webhook密钥失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    gin.H{"secret": secret},
+	})
+}
+
+// ListDeliveries 获取任务的Webhook投递记录
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	found, err := h.jobs.Get(c.Param("id"))
+	if err != nil || found == nil || found.UserID != user.ID {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    404,
+			Message: "任务不存在",
+			Error:   "job not found",
+		})
+		return
+	}
+
+	deliveries, err := h.db.ListDeliveriesByJob(found.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "查询投递记录失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*models.WebhookDelivery{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    deliveries,
+	})
+}
+
+// ReplayDelivery 重新投递一条Webhook
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    400,
+			Message: "投递记录ID无效",
+			Error:   "invalid delivery id",
+		})
+		return
+	}
+
+	delivery, err := h.db.GetDelivery(id)
+	if err != nil || delivery == nil || delivery.UserID != user.ID {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    404,
+			Message: "投递记录不存在",
+			Error:   "delivery not found",
+		})
+		return
+	}
+
+	if err := h.dispatcher.Replay(delivery.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    500,
+			Message: "重新投递失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    202,
+		"message": "已重新加入投递队列",
+	})
+}
@@ -0,0 +1,262 @@
+package ssml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultLang 语音名称中没有区域信息时使用的xml:lang
+const defaultLang = "en-US"
+
+// Builder 用xml.Encoder逐个写出SSML元素，文本和属性值由编码器转义
+// 元素名和属性名按原样写出，带前缀的名称(mstts:express-as、xml:lang)需要调用方声明命名空间
+type Builder struct {
+	buf   bytes.Buffer
+	enc   *xml.Encoder
+	stack []string
+	err   error
+}
+
+// NewBuilder 创建SSML构建器
+func NewBuilder() *Builder {
+	b := &Builder{}
+	b.enc = xml.NewEncoder(&b.buf)
+	return b
+}
+
+// Start 写出开始标签，attrs为成对的属性名和属性值，值为空的属性不写出
+func (b *Builder) Start(name string, attrs ...string) {
+	if b.err != nil {
+		return
+	}
+	if len(attrs)%2 != 0 {
+		b.err = fmt.Errorf("元素 <%s> 的属性必须成对出现", name)
+		return
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	for i := 0; i < len(attrs); i += 2 {
+		if attrs[i+1] == "" {
+			continue
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attrs[i]}, Value: SanitizeText(attrs[i+1])})
+	}
+	b.err = b.enc.EncodeToken(start)
+	b.stack = append(b.stack, name)
+}
+
+// End 写出最近一个未关闭元素的结束标签
+func (b *Builder) End() {
+	if b.err != nil {
+		return
+	}
+	if len(b.stack) == 0 {
+		b.err = fmt.Errorf("没有未关闭的元素")
+		return
+	}
+	name := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+	b.err = b.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+// Text 写出纯文本，标记字符会被转义，XML不允许的字符先经过SanitizeText处理
+func (b *Builder) Text(text string) {
+	if b.err != nil || text == "" {
+		return
+	}
+	b.err = b.enc.EncodeToken(xml.CharData(SanitizeText(text)))
+}
+
+// Element 写出只包含文本的元素
+func (b *Builder) Element(name, text string, attrs ...string) {
+	b.Start(name, attrs...)
+	b.Text(text)
+	b.End()
+}
+
+// String 返回生成的SSML，元素没有全部关闭时返回错误
+func (b *Builder) String() (string, error) {
+	if b.err == nil && len(b.stack) > 0 {
+		b.err = fmt.Errorf("元素 <%s> 没有关闭", b.stack[len(b.stack)-1])
+	}
+	if b.err == nil {
+		b.err = b.enc.Flush()
+	}
+	if b.err != nil {
+		return "", fmt.Errorf("生成SSML失败: %w", b.err)
+	}
+	return b.buf.String(), nil
+}
+
+// Prosody SSML中 <prosody> 的属性，取值为规范写法，为空时使用默认值
+type Prosody struct {
+	Rate    string
+	Pitch   string
+	Volume  string
+	Contour string
+}
+
+// Speech 单个语音朗读一段文本的SSML参数
+type Speech struct {
+	Voice       string
+	Lang        string // xml:lang，为空时取语音名称中的区域
+	Prosody     Prosody
+	Style       string  // 不为空时用 <mstts:express-as> 包裹正文
+	StyleDegree float64 // 为0时使用默认强度
+	Role        string
+	Text        string           // 纯文本正文
+	Body        func(b *Builder) // 不为nil时代替Text写出正文，用于插入 <sub>、<phoneme> 等元素
+}
+
+// Build 生成完整的SSML文档，正文中的标记字符都会被转义，用户输入不能注入新的元素
+func Build(s Speech) (string, error) {
+	lang := s.Lang
+	if lang == "" {
+		lang = VoiceLocale(s.Voice)
+	}
+	if lang == "" {
+		lang = defaultLang
+	}
+
+	b := NewBuilder()
+	if s.Style != "" || s.Role != "" {
+		b.Start("speak", "version", "1.0", "xmlns", NamespaceSynthesis, "xmlns:mstts", NamespaceMSTTS, "xml:lang", lang)
+	} else {
+		b.Start("speak", "version", "1.0", "xmlns", NamespaceSynthesis, "xml:lang", lang)
+	}
+	b.Start("voice", "name", s.Voice)
+
+	if s.Style != "" || s.Role != "" {
+		degree := ""
+		if s.Style != "" && s.StyleDegree != 0 {
+			degree = strconv.FormatFloat(s.StyleDegree, 'f', -1, 64)
+		}
+		b.Start("mstts:express-as", "style", s.Style, "styledegree", degree, "role", s.Role)
+	}
+
+	// 语速和音调总是写出，音量和音调曲线只在指定时写出
+	rate, pitch := "default", "default"
+	if s.Prosody.Rate != "" {
+		rate = s.Prosody.Rate
+	}
+	if s.Prosody.Pitch != "" {
+		pitch = s.Prosody.Pitch
+	}
+	b.Start("prosody", "rate", rate, "pitch", pitch, "volume", s.Prosody.Volume, "contour", s.Prosody.Contour)
+	if s.Body != nil {
+		s.Body(b)
+	} else {
+		b.Text(s.Text)
+	}
+	b.End()
+
+	if s.Style != "" || s.Role != "" {
+		b.End()
+	}
+	b.End()
+	b.End()
+	return b.String()
+}
+
+// VoiceLocale 从语音名称中取出区域，支持短名称(zh-CN-XiaoxiaoNeural)和
+// 完整名称(Microsoft Server Speech Text to Speech Voice (zh-CN, XiaoxiaoNeural))，无法识别时返回空字符串
+func VoiceLocale(voice string) string {
+	if start := strings.LastIndex(voice, "("); start >= 0 {
+		voice = strings.TrimSpace(voice[start+1:])
+		if locale, _, ok := strings.Cut(voice, ","); ok {
+			return validLocale(strings.TrimSpace(locale))
+		}
+		return ""
+	}
+
+	parts := strings.SplitN(voice, "-", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return validLocale(parts[0] + "-" + parts[1])
+}
+
+// validLocale 区域由2到3个字母的语言和2个字母或3个数字的地区组成
+func validLocale(locale string) string {
+	lang, region, ok := strings.Cut(locale, "-")
+	if !ok || len(lang) < 2 || len(lang) > 3 || !asciiAlpha(lang) {
+		return ""
+	}
+	if !(len(region) == 2 && asciiAlpha(region)) && !(len(region) == 3 && asciiDigits(region)) {
+		return ""
+	}
+	return locale
+}
+
+func asciiAlpha(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func asciiDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// SanitizeText 把文本整理为XML 1.0允许的字符：
+// 无效的UTF-8字节和非字符(U+FFFE、U+FFFF)被删除，除制表符和换行外的控制字符替换为空格
+func SanitizeText(text string) string {
+	clean := true
+	for i, r := range text {
+		if !validChar(r) || (r == utf8.RuneError && isInvalidByte(text[i:])) {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for i, r := range text {
+		switch {
+		case r == utf8.RuneError && isInvalidByte(text[i:]):
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r', r >= 0x7F && r <= 0x9F:
+			b.WriteByte(' ')
+		case validChar(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// validChar XML 1.0的Char产生式，另外排除C1控制字符
+func validChar(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return true
+	case r < 0x20, r >= 0x7F && r <= 0x9F:
+		return false
+	case r <= 0xD7FF:
+		return true
+	case r >= 0xE000 && r <= 0xFFFD:
+		return true
+	case r >= 0x10000 && r <= 0x10FFFF:
+		return true
+	}
+	return false
+}
+
+// isInvalidByte 当前位置是无效的UTF-8字节，而不是原文中的U+FFFD
+func isInvalidByte(s string) bool {
+	_, size := utf8.DecodeRuneInString(s)
+	return size == 1
+}
@@ -0,0 +1,141 @@
+package ssml_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"tts-service/internal/lexicon"
+	"tts-service/internal/models"
+	"tts-service/internal/ssml"
+)
+
+// parsed 解析生成的SSML，返回元素名称、正文和根元素的属性
+type parsed struct {
+	elements []string
+	text     string
+	attrs    map[string]string
+}
+
+func parse(t *testing.T, document string) parsed {
+	t.Helper()
+	result := parsed{attrs: make(map[string]string)}
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	var text strings.Builder
+	for depth := 0; ; {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("生成的SSML不是合法的XML: %v\n%s", err, document)
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			name := tok.Name.Local
+			if tok.Name.Space == ssml.NamespaceMSTTS {
+				name = "mstts:" + name
+			}
+			result.elements = append(result.elements, name)
+			for _, attr := range tok.Attr {
+				key := attr.Name.Local
+				if attr.Name.Space == "xml" || attr.Name.Space == "http://www.w3.org/XML/1998/namespace" {
+					key = "xml:" + key
+				}
+				result.attrs[name+"@"+key] = attr.Value
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 {
+				t.Fatalf("根元素之外出现文本: %q", tok)
+			}
+			text.Write(tok)
+		case xml.ProcInst, xml.Directive, xml.Comment:
+			t.Fatalf("生成的SSML包含意外的节点: %#v", tok)
+		}
+	}
+	result.text = text.String()
+	return result
+}
+
+func FuzzBuild(f *testing.F) {
+	f.Add("你好，世界", "zh-CN-XiaoxiaoNeural", "")
+	f.Add(`</prosody></voice><voice name="x"><break time="100s"/>`, "en-US-JennyNeural", "cheerful")
+	f.Add("a & b < c > d \" ' ]]> &amp;", "Microsoft Server Speech Text to Speech Voice (ja-JP, NanamiNeural)", "")
+	f.Add("\x00\x01\x1b[31m\ufffe\uffff\xff\xfe\ud7ff\U0010ffff", "bad\x00voice", "sad\"")
+	f.Add("line\r\nbreak\ttab", "", "")
+
+	f.Fuzz(func(t *testing.T, text, voice, style string) {
+		document, err := ssml.Build(ssml.Speech{
+			Voice:   voice,
+			Prosody: ssml.Prosody{Rate: "+10%"},
+			Style:   style,
+			Text:    text,
+		})
+		if err != nil {
+			t.Fatalf("生成SSML失败: %v", err)
+		}
+		doc := parse(t, document)
+
+		want := []string{"speak", "voice", "prosody"}
+		if style != "" {
+			want = []string{"speak", "voice", "mstts:express-as", "prosody"}
+		}
+		if strings.Join(doc.elements, ",") != strings.Join(want, ",") {
+			t.Fatalf("元素 %v, 期望 %v\n%s", doc.elements, want, document)
+		}
+		if doc.text != ssml.SanitizeText(text) {
+			t.Fatalf("正文 %q, 期望 %q", doc.text, ssml.SanitizeText(text))
+		}
+		if voice != "" && doc.attrs["voice@name"] != ssml.SanitizeText(voice) {
+			t.Fatalf("语音 %q, 期望 %q", doc.attrs["voice@name"], ssml.SanitizeText(voice))
+		}
+
+		lang := ssml.VoiceLocale(voice)
+		if lang == "" {
+			lang = "en-US"
+		}
+		if doc.attrs["speak@xml:lang"] != lang {
+			t.Fatalf("xml:lang %q, 期望 %q", doc.attrs["speak@xml:lang"], lang)
+		}
+	})
+}
+
+func FuzzBuildLexicon(f *testing.F) {
+	f.Add("我在重庆用SQL", "重庆", "chóng qìng", "SQL", "sequel")
+	f.Add("<SQL> & MySQL", "SQL", "ˈɛs kjuː ˈɛl", "My", `"quoted" <alias>`)
+	f.Add("\xff重\xe5庆\x00", "\xff", "x", "\x00", "y")
+
+	f.Fuzz(func(t *testing.T, text, word1, phoneme, word2, alias string) {
+		entries := []models.LexiconEntry{
+			{Word: word1, Phoneme: phoneme, Alphabet: lexicon.AlphabetIPA},
+			{Word: word2, Alias: alias},
+		}
+		var valid []models.LexiconEntry
+		for _, entry := range entries {
+			if lexicon.Validate(&entry) == nil {
+				valid = append(valid, entry)
+			}
+		}
+
+		document, err := ssml.Build(ssml.Speech{
+			Voice: "zh-CN-XiaoxiaoNeural",
+			Body: func(b *ssml.Builder) {
+				lexicon.Write(b, text, valid)
+			},
+		})
+		if err != nil {
+			t.Fatalf("生成SSML失败: %v", err)
+		}
+		doc := parse(t, document)
+
+		for _, name := range doc.elements[3:] {
+			if name != "sub" && name != "phoneme" {
+				t.Fatalf("正文中出现意外的元素 <%s>\n%s", name, document)
+			}
+		}
+	})
+}
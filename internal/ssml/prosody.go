@@ -0,0 +1,234 @@
+package ssml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NominalPitchHz 用于把以Hz表示的音调变化换算成倍数的参考基频
+const NominalPitchHz = 200.0
+
+// 韵律值的单位
+const (
+	UnitMultiplier = ""   // 相对倍数，如语速 1.5
+	UnitPercent    = "%"  // 相对百分比，如 +20%
+	UnitHz         = "Hz" // 相对频率，如 +50Hz
+	UnitSemitone   = "st" // 相对半音，如 -2st
+	UnitKeyword    = "keyword"
+)
+
+// 关键字对应的倍数，取值与Azure语音服务的说明一致
+var (
+	rateKeywords = map[string]float64{
+		"x-slow": 0.5, "slow": 0.64, "medium": 1, "fast": 1.55, "x-fast": 2, "default": 1,
+	}
+	pitchKeywords = map[string]float64{
+		"x-low": 0.55, "low": 0.8, "medium": 1, "high": 1.2, "x-high": 1.45, "default": 1,
+	}
+	volumeKeywords = map[string]float64{
+		"silent": 0, "x-soft": 0.2, "soft": 0.4, "medium": 0.6, "loud": 0.8, "x-loud": 1, "default": 1,
+	}
+)
+
+var prosodyPattern = regexp.MustCompile(`^([+-]?(?:\d+(?:\.\d*)?|\.\d+))(%|hz|st)?$`)
+
+// Value 解析后的韵律值
+type Value struct {
+	Number  float64
+	Unit    string
+	Keyword string
+	factor  float64 // 关键字对应的倍数
+}
+
+// IsDefault 是否与默认值相同，默认值不需要写入SSML
+func (v Value) IsDefault() bool {
+	switch v.Unit {
+	case UnitKeyword:
+		return v.Keyword == "default"
+	case UnitMultiplier:
+		return v.Number == 1
+	default:
+		return v.Number == 0
+	}
+}
+
+// String SSML中使用的规范写法，相对值总是带符号
+func (v Value) String() string {
+	switch v.Unit {
+	case UnitKeyword:
+		return v.Keyword
+	case UnitMultiplier:
+		return formatNumber(v.Number, false)
+	default:
+		return formatNumber(v.Number, true) + v.Unit
+	}
+}
+
+// Factor 相对于默认值的倍数，Hz按 NominalPitchHz 换算
+func (v Value) Factor() float64 {
+	if v.Unit == UnitHz {
+		return (NominalPitchHz + v.Number) / NominalPitchHz
+	}
+	return v.Apply(1)
+}
+
+// Apply 把韵律值作用到基准值上，音调的基准值以Hz为单位
+func (v Value) Apply(base float64) float64 {
+	switch v.Unit {
+	case UnitKeyword:
+		return base * v.factor
+	case UnitMultiplier:
+		return base * v.Number
+	case UnitPercent:
+		return base * (1 + v.Number/100)
+	case UnitSemitone:
+		return base * math.Pow(2, v.Number/12)
+	case UnitHz:
+		return base + v.Number
+	}
+	return base
+}
+
+// ParseRate 解析语速：关键字(x-slow…x-fast)、相对百分比(+20%)或倍数(1.5)
+func ParseRate(s string) (Value, error) {
+	v, err := parse(s, rateKeywords, UnitMultiplier, UnitPercent)
+	if err != nil {
+		return v, fmt.Errorf("语速 %q 无效，应为关键字、百分比(如 +20%%)或倍数(如 1.5)", s)
+	}
+	if v.Unit == UnitMultiplier && v.Number <= 0 {
+		return v, fmt.Errorf("语速倍数必须大于0")
+	}
+	return v, nil
+}
+
+// ParsePitch 解析音调：关键字(x-low…x-high)、Hz(+50Hz)、半音(-2st)或百分比(+10%)
+// 不带单位的数字按Hz处理，与旧版接口的整数音调一致
+func ParsePitch(s string) (Value, error) {
+	v, err := parse(s, pitchKeywords, UnitHz, UnitPercent, UnitHz, UnitSemitone)
+	if err != nil {
+		return v, fmt.Errorf("音调 %q 无效，应为关键字、Hz(如 +50Hz)、半音(如 -2st)或百分比(如 +10%%)", s)
+	}
+	return v, nil
+}
+
+// ParseVolume 解析音量：关键字(silent…x-loud)、相对百分比(+10%)或倍数(0.5)
+func ParseVolume(s string) (Value, error) {
+	v, err := parse(s, volumeKeywords, UnitMultiplier, UnitPercent)
+	if err != nil {
+		return v, fmt.Errorf("音量 %q 无效，应为关键字、百分比(如 -20%%)或倍数(如 0.5)", s)
+	}
+	if v.Unit == UnitMultiplier && v.Number < 0 {
+		return v, fmt.Errorf("音量倍数不能小于0")
+	}
+	return v, nil
+}
+
+// ContourPoint 音调曲线上的一个点
+type ContourPoint struct {
+	Position float64 // 在文本中的位置，0-100
+	Pitch    Value
+}
+
+// ParseContour 解析音调曲线，格式为 "(0%,+20Hz) (50%,-2st)"
+func ParseContour(s string) ([]ContourPoint, error) {
+	var points []ContourPoint
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		if rest[0] != '(' {
+			return nil, fmt.Errorf("音调曲线 %q 格式无效，应为 (位置%%,音调) 列表", s)
+		}
+		end := strings.IndexByte(rest, ')')
+		if end < 0 {
+			return nil, fmt.Errorf("音调曲线 %q 缺少 )", s)
+		}
+		parts := strings.Split(rest[1:end], ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("音调曲线 %q 格式无效，应为 (位置%%,音调) 列表", s)
+		}
+		position, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(parts[0]), "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("音调曲线位置 %q 无效", parts[0])
+		}
+		pitch, err := ParsePitch(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, ContourPoint{Position: position, Pitch: pitch})
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+	return points, CheckContour(points)
+}
+
+// CheckContour 检查音调曲线：位置在0-100之间且不递减，音调不能使用关键字
+func CheckContour(points []ContourPoint) error {
+	if len(points) == 0 {
+		return fmt.Errorf("音调曲线不能为空")
+	}
+	last := 0.0
+	for _, point := range points {
+		if point.Position < 0 || point.Position > 100 {
+			return fmt.Errorf("音调曲线位置 %g 超出 0-100", point.Position)
+		}
+		if point.Position < last {
+			return fmt.Errorf("音调曲线位置需要按顺序排列")
+		}
+		if point.Pitch.Unit == UnitKeyword {
+			return fmt.Errorf("音调曲线不能使用关键字 %s", point.Pitch.Keyword)
+		}
+		last = point.Position
+	}
+	return nil
+}
+
+// FormatContour 音调曲线的SSML写法
+func FormatContour(points []ContourPoint) string {
+	parts := make([]string, len(points))
+	for i, point := range points {
+		parts[i] = fmt.Sprintf("(%s%%,%s)", formatNumber(point.Position, false), point.Pitch)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parse 解析关键字或数字，bare为不带单位时使用的单位，units为允许的单位
+func parse(s string, keywords map[string]float64, bare string, units ...string) (Value, error) {
+	s = strings.TrimSpace(s)
+	if factor, ok := keywords[strings.ToLower(s)]; ok {
+		return Value{Unit: UnitKeyword, Keyword: strings.ToLower(s), factor: factor}, nil
+	}
+
+	m := prosodyPattern.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return Value{}, fmt.Errorf("invalid prosody value")
+	}
+	number, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return Value{}, err
+	}
+
+	unit := bare
+	switch m[2] {
+	case "%":
+		unit = UnitPercent
+	case "hz":
+		unit = UnitHz
+	case "st":
+		unit = UnitSemitone
+	}
+	if m[2] != "" && !contains(units, unit) {
+		return Value{}, fmt.Errorf("unsupported unit")
+	}
+	// 不带符号的百分比在SSML中表示绝对值，这里统一按相对变化处理
+	return Value{Number: number, Unit: unit}, nil
+}
+
+// formatNumber 去掉多余的小数位，signed为true时正数带+号
+func formatNumber(n float64, signed bool) string {
+	s := strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+	if signed && n >= 0 {
+		s = "+" + s
+	}
+	return s
+}
@@ -0,0 +1,299 @@
+package ssml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SSML相关命名空间
+const (
+	NamespaceSynthesis = "http://www.w3.org/2001/10/synthesis"
+	NamespaceMSTTS     = "https://www.w3.org/2001/mstts"
+	namespaceXML       = "http://www.w3.org/XML/1998/namespace"
+)
+
+// ValidationError SSML校验错误，指出出错的元素及其位置
+type ValidationError struct {
+	Element string `json:"element,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	if e.Element == "" {
+		return fmt.Sprintf("SSML校验失败 (第%d行): %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("SSML校验失败 <%s> (第%d行第%d列, %s): %s", e.Element, e.Line, e.Column, e.Path, e.Message)
+}
+
+// Document 校验通过的SSML信息
+type Document struct {
+	Lang   string
+	Voices []string // 按出现顺序去重
+}
+
+// elementSpec 元素规则
+type elementSpec struct {
+	attrs    []string        // 允许的属性，xml:lang 记为 "xml:lang"
+	required []string        // 必需的属性
+	parents  map[string]bool // 允许的父元素
+	empty    bool            // 不能包含内容
+	textOnly bool            // 只能包含文本
+}
+
+// 可以包含正文的元素
+var contentParents = set("voice", "mstts:express-as", "prosody", "lang", "p", "s", "emphasis", "audio")
+
+var elements = map[string]elementSpec{
+	"speak": {attrs: []string{"version", "xml:lang", "xml:base"}, required: []string{"version", "xml:lang"}},
+	"voice": {attrs: []string{"name", "effect"}, required: []string{"name"}, parents: set("speak")},
+
+	"p":        {attrs: []string{"xml:lang"}, parents: set("voice", "mstts:express-as", "prosody", "lang", "emphasis", "audio")},
+	"s":        {attrs: []string{"xml:lang"}, parents: set("voice", "mstts:express-as", "prosody", "lang", "p", "emphasis", "audio")},
+	"prosody":  {attrs: []string{"rate", "pitch", "volume", "contour", "range", "duration"}, parents: contentParents},
+	"emphasis": {attrs: []string{"level"}, parents: contentParents},
+	"lang":     {attrs: []string{"xml:lang"}, required: []string{"xml:lang"}, parents: contentParents},
+	"audio":    {attrs: []string{"src"}, required: []string{"src"}, parents: contentParents},
+	"break":    {attrs: []string{"time", "strength"}, parents: contentParents, empty: true},
+	"bookmark": {attrs: []string{"mark"}, required: []string{"mark"}, parents: contentParents, empty: true},
+	"phoneme":  {attrs: []string{"alphabet", "ph"}, required: []string{"ph"}, parents: contentParents, textOnly: true},
+	"say-as":   {attrs: []string{"interpret-as", "format", "detail"}, required: []string{"interpret-as"}, parents: contentParents, textOnly: true},
+	"sub":      {attrs: []string{"alias"}, required: []string{"alias"}, parents: contentParents, textOnly: true},
+	"lexicon":  {attrs: []string{"uri"}, required: []string{"uri"}, parents: set("voice"), empty: true},
+
+	"mstts:express-as":      {attrs: []string{"style", "styledegree", "role"}, parents: set("voice")},
+	"mstts:silence":         {attrs: []string{"type", "value"}, required: []string{"type", "value"}, parents: set("voice"), empty: true},
+	"mstts:viseme":          {attrs: []string{"type"}, required: []string{"type"}, parents: set("voice"), empty: true},
+	"mstts:audioduration":   {attrs: []string{"value"}, required: []string{"value"}, parents: set("voice"), empty: true},
+	"mstts:backgroundaudio": {attrs: []string{"src", "volume", "fadein", "fadeout"}, required: []string{"src"}, parents: set("speak"), empty: true},
+}
+
+// frame 当前打开的元素
+type frame struct {
+	name     string
+	path     string
+	spec     elementSpec
+	children map[string]int
+}
+
+// Validate 解析并校验SSML文档：只允许已知元素（包括mstts扩展），检查嵌套关系和必需属性
+// voiceExists不为nil时，voice元素引用的语音必须存在
+func Validate(doc string, voiceExists func(name string) bool) (*Document, error) {
+	decoder := xml.NewDecoder(strings.NewReader(doc))
+	result := &Document{}
+	seen := make(map[string]bool)
+
+	var stack []*frame
+	root := false
+
+	for {
+		// 读取前的位置即下一个token的起始位置
+		line, column := decoder.InputPos()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				line = syntaxErr.Line
+			}
+			return nil, &ValidationError{Line: line, Message: fmt.Sprintf("XML格式错误: %v", err)}
+		}
+
+		var parent *frame
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name, err := elementName(t.Name)
+			path := name
+			if parent != nil {
+				parent.children[name]++
+				path = fmt.Sprintf("%s/%s[%d]", parent.path, name, parent.children[name])
+			}
+			fail := func(format string, args ...interface{}) error {
+				return &ValidationError{Element: name, Path: path, Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+			}
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+
+			spec, ok := elements[name]
+			if !ok {
+				return nil, fail("不支持的元素")
+			}
+
+			switch {
+			case parent == nil && name != "speak":
+				return nil, fail("根元素必须是 speak")
+			case parent == nil && root:
+				return nil, fail("只能有一个根元素")
+			case parent != nil && name == "speak":
+				return nil, fail("speak 只能作为根元素")
+			case parent != nil && parent.spec.empty:
+				return nil, fail("<%s> 不能包含内容", parent.name)
+			case parent != nil && parent.spec.textOnly:
+				return nil, fail("<%s> 只能包含文本", parent.name)
+			case parent != nil && !spec.parents[parent.name]:
+				return nil, fail("不能出现在 <%s> 中", parent.name)
+			}
+			root = true
+
+			attrs, err := attributes(t.Attr, spec)
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			for _, attr := range spec.required {
+				if attrs[attr] == "" {
+					return nil, fail("缺少属性 %s", attr)
+				}
+			}
+			if name == "mstts:express-as" && attrs["style"] == "" && attrs["role"] == "" {
+				return nil, fail("需要 style 或 role 属性")
+			}
+			if name == "prosody" {
+				if err := checkProsody(attrs); err != nil {
+					return nil, fail("%v", err)
+				}
+			}
+
+			switch name {
+			case "speak":
+				if t.Name.Space != NamespaceSynthesis {
+					return nil, fail("缺少命名空间 xmlns=%q", NamespaceSynthesis)
+				}
+				result.Lang = attrs["xml:lang"]
+			case "voice":
+				voice := attrs["name"]
+				if voiceExists != nil && !voiceExists(voice) {
+					return nil, fail("未知的语音: %s", voice)
+				}
+				if !seen[voice] {
+					seen[voice] = true
+					result.Voices = append(result.Voices, voice)
+				}
+			}
+
+			stack = append(stack, &frame{name: name, path: path, spec: spec, children: make(map[string]int)})
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if strings.TrimSpace(string(t)) == "" {
+				continue
+			}
+			if parent == nil {
+				return nil, &ValidationError{Line: line, Message: "speak 之外不能有文本"}
+			}
+			if parent.spec.empty {
+				return nil, &ValidationError{Element: parent.name, Path: parent.path, Line: line, Column: column,
+					Message: "不能包含内容"}
+			}
+			if parent.name == "speak" {
+				return nil, &ValidationError{Element: parent.name, Path: parent.path, Line: line, Column: column,
+					Message: "不能直接包含文本，正文需要放在 voice 中"}
+			}
+		}
+	}
+
+	if !root {
+		return nil, &ValidationError{Message: "SSML文档为空"}
+	}
+	if len(result.Voices) == 0 {
+		return nil, &ValidationError{Element: "speak", Path: "speak", Line: 1, Column: 1, Message: "至少需要一个 voice 元素"}
+	}
+	return result, nil
+}
+
+// checkProsody 检查 prosody 的语速、音调、音量和音调曲线取值
+func checkProsody(attrs map[string]string) error {
+	if rate, ok := attrs["rate"]; ok {
+		if _, err := ParseRate(rate); err != nil {
+			return err
+		}
+	}
+	if pitch, ok := attrs["pitch"]; ok {
+		if _, err := ParsePitch(pitch); err != nil {
+			return err
+		}
+	}
+	if volume, ok := attrs["volume"]; ok {
+		if _, err := ParseVolume(volume); err != nil {
+			return err
+		}
+	}
+	if contour, ok := attrs["contour"]; ok {
+		if _, err := ParseContour(contour); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// elementName 元素名称，mstts扩展加上前缀，其他命名空间的元素视为错误
+func elementName(name xml.Name) (string, error) {
+	switch name.Space {
+	case NamespaceSynthesis:
+		return name.Local, nil
+	case NamespaceMSTTS, "http://www.w3.org/2001/mstts":
+		return "mstts:" + name.Local, nil
+	case "mstts":
+		return "mstts:" + name.Local, fmt.Errorf("未声明 mstts 命名空间 xmlns:mstts=%q", NamespaceMSTTS)
+	case "":
+		// speak缺少xmlns时由调用方给出更明确的提示
+		return name.Local, nil
+	default:
+		return name.Local, fmt.Errorf("不支持的命名空间: %s", name.Space)
+	}
+}
+
+// attributes 校验属性并返回属性值，忽略命名空间声明
+func attributes(attrs []xml.Attr, spec elementSpec) (map[string]string, error) {
+	values := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+
+		name := attr.Name.Local
+		switch attr.Name.Space {
+		case "":
+		case namespaceXML, "xml":
+			name = "xml:" + name
+		default:
+			return nil, fmt.Errorf("不支持的属性 %s:%s", attr.Name.Space, attr.Name.Local)
+		}
+
+		if !contains(spec.attrs, name) {
+			return nil, fmt.Errorf("不支持的属性 %s", name)
+		}
+		values[name] = attr.Value
+	}
+	return values, nil
+}
+
+func set(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, name := range names {
+		m[name] = true
+	}
+	return m
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
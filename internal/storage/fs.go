@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FS 本地目录存储，对象直接保存为目录下的文件，键中的前缀对应子目录
+type FS struct {
+	dir string
+}
+
+// NewFS 创建本地目录存储
+func NewFS(dir string) *FS {
+	return &FS{dir: dir}
+}
+
+func (f *FS) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(f.dir, filepath.FromSlash(key)), nil
+}
+
+// Put 先写入同目录的临时文件，成功后原子重命名
+func (f *FS) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// putFile 把本地文件重命名为对象，重命名失败(如不在同一文件系统)时复制
+func (f *FS) putFile(key, src string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := os.Chmod(src, 0644); err == nil {
+		if err := os.Rename(src, path); err == nil {
+			return nil
+		}
+	}
+
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	defer os.Remove(src)
+	defer file.Close()
+	return f.Put(context.Background(), key, file, 0)
+}
+
+// Get 打开文件，返回的*os.File支持Seek，可以响应Range请求
+func (f *FS) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotExist
+		}
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return file, nil
+}
+
+// Stat 获取文件信息，目录视为不存在
+func (f *FS) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotExist
+		}
+		return nil, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, ErrNotExist
+	}
+	return &ObjectInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Delete 删除文件
+func (f *FS) Delete(ctx context.Context, key string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	return nil
+}
+
+// List 列出目录及子目录下的文件，子目录中文件的键以/分隔
+func (f *FS) List(ctx context.Context) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.WalkDir(f.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 遍历期间被删除的文件和不存在的存储目录跳过
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(f.dir, path)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{Key: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取存储目录失败: %w", err)
+	}
+	return objects, nil
+}
+
+// Presign 本地目录没有独立的下载地址，由服务转发
+func (f *FS) Presign(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrPresignUnsupported
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCheckKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"a.mp3", true},
+		{"audiobooks/1/001.mp3", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../etc/passwd", false},
+		{"audiobooks/../a.mp3", false},
+		{"/a.mp3", false},
+		{"audiobooks//a.mp3", false},
+		{"audiobooks/", false},
+		{`a\b.mp3`, false},
+	}
+	for _, tt := range tests {
+		err := checkKey(tt.key)
+		if (err == nil) != tt.valid {
+			t.Errorf("checkKey(%q) = %v, 期望有效: %v", tt.key, err, tt.valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("checkKey(%q) 返回 %v, 期望 ErrInvalidKey", tt.key, err)
+		}
+	}
+}
+
+func TestFSNestedKeys(t *testing.T) {
+	dir := t.TempDir()
+	f := NewFS(dir)
+	ctx := context.Background()
+
+	for _, key := range []string{"a.mp3", "audiobooks/1/001.mp3"} {
+		if err := f.Put(ctx, key, strings.NewReader("data-"+key), 0); err != nil {
+			t.Fatalf("Put %s: %v", key, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "audiobooks", "1", "001.mp3")); err != nil {
+		t.Fatalf("带前缀的对象应保存在子目录中: %v", err)
+	}
+
+	// 本地文件直接移动到子目录
+	src := filepath.Join(t.TempDir(), "book.tmp")
+	if err := os.WriteFile(src, []byte("book"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutFile(ctx, f, "audiobooks/1/book.mp3", src); err != nil {
+		t.Fatalf("PutFile: %v", err)
+	}
+	data, err := ReadAll(ctx, f, "audiobooks/1/book.mp3")
+	if err != nil || string(data) != "book" {
+		t.Fatalf("ReadAll = %q, %v", data, err)
+	}
+
+	objects, err := f.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "a.mp3,audiobooks/1/001.mp3,audiobooks/1/book.mp3" {
+		t.Errorf("List = %v", keys)
+	}
+
+	if err := f.Delete(ctx, "audiobooks/1/001.mp3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Stat(ctx, "audiobooks/1/001.mp3"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("删除后Stat返回 %v", err)
+	}
+	if _, err := f.Stat(ctx, "audiobooks/1"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("目录应视为不存在, Stat返回 %v", err)
+	}
+}
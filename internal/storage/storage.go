@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"tts-service/internal/config"
+)
+
+var (
+	// ErrNotExist 对象不存在
+	ErrNotExist = errors.New("对象不存在")
+	// ErrInvalidKey 对象键为空、以/开头或包含空的、"."、".."路径段
+	ErrInvalidKey = errors.New("无效的对象键")
+	// ErrPresignUnsupported 后端不支持预签名URL，需要由服务转发
+	ErrPresignUnsupported = errors.New("存储后端不支持预签名URL")
+)
+
+// AudiobookPrefix 有声书文件的对象键前缀，键为 audiobooks/<ID>/<文件名>，缓存清理和淘汰不处理
+const AudiobookPrefix = "audiobooks/"
+
+// Storage 缓存音频、边界元数据、字幕和有声书文件的存储后端
+// 缓存的对象键是不含目录的文件名，如 <hash>.mp3；有声书等其他对象的键带有以/分隔的前缀
+// 对象键与后端无关，可以直接保存在数据库中
+type Storage interface {
+	// Put 写入对象，size为内容长度，写入完成前其他请求读不到不完整的内容
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get 读取对象，文件系统后端返回的Reader同时实现了io.ReadSeeker
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat 获取对象的大小和修改时间
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// List 列出全部对象，包括带前缀的对象
+	List(ctx context.Context) ([]ObjectInfo, error)
+	// Presign 生成限时有效的下载地址，不支持时返回ErrPresignUnsupported
+	Presign(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// ObjectInfo 对象信息
+type ObjectInfo struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// New 按 storage.backend 创建存储后端，默认使用本地目录
+func New(cfg *config.StorageConfig) (Storage, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", "fs":
+		return NewFS(cfg.Path), nil
+	case "s3":
+		return NewS3(&cfg.S3)
+	default:
+		return nil, fmt.Errorf("不支持的存储后端: %s，可选 fs、s3", cfg.Backend)
+	}
+}
+
+// filePutter 可以直接移动本地文件的后端，避免再复制一次
+type filePutter interface {
+	putFile(key, path string) error
+}
+
+// PutFile 把本地文件写入存储，成功后本地文件不再存在
+// 文件系统后端在同一目录下时直接重命名，其他后端上传后删除本地文件
+func PutFile(ctx context.Context, st Storage, key, path string) error {
+	if putter, ok := st.(filePutter); ok {
+		return putter.putFile(key, path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := st.Put(ctx, key, file, info.Size()); err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(path)
+}
+
+// ReadAll 读取整个对象
+func ReadAll(ctx context.Context, st Storage, key string) ([]byte, error) {
+	r, err := st.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// checkKey 对象键是以/分隔的相对路径，不能包含反斜杠和空的、"."、".."路径段，防止访问存储目录之外的文件
+func checkKey(key string) error {
+	if key == "" || strings.Contains(key, `\`) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+// contentTypes 写入对象存储时设置的Content-Type，预签名下载时由对象存储返回
+var contentTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wav",
+	".ogg":  "audio/ogg",
+	".m4a":  "audio/mp4",
+	".flac": "audio/flac",
+	".aac":  "audio/aac",
+	".pcm":  "audio/pcm",
+	".srt":  "application/x-subrip; charset=utf-8",
+	".vtt":  "text/vtt; charset=utf-8",
+	".json": "application/json",
+}
+
+func contentType(key string) string {
+	if ct, ok := contentTypes[path.Ext(key)]; ok {
+		return ct
+	}
+	return "application/octet-stream"
+}
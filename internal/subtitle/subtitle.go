@@ -0,0 +1,177 @@
+package subtitle
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"tts-service/internal/models"
+)
+
+// 支持的字幕格式
+const (
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+)
+
+// Options 字幕切分选项
+type Options struct {
+	MaxLineLength int           // 每条字幕的最大字符数
+	MaxDuration   time.Duration // 每条字幕的最大时长
+}
+
+// Cue 单条字幕
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// IsSupported 检查字幕格式是否支持
+func IsSupported(format string) bool {
+	return format == FormatSRT || format == FormatVTT
+}
+
+// ContentType 获取字幕格式对应的Content-Type
+func ContentType(format string) string {
+	if format == FormatVTT {
+		return "text/vtt; charset=utf-8"
+	}
+	return "application/x-subrip; charset=utf-8"
+}
+
+// BuildCues 根据边界事件生成字幕条目
+// 优先使用词边界按长度和时长切分，且不跨越句子；没有词边界时每句一条字幕
+func BuildCues(boundaries []models.Boundary, opts Options) []Cue {
+	var words, sentences []models.Boundary
+	for _, b := range boundaries {
+		switch b.Type {
+		case models.WordBoundary:
+			words = append(words, b)
+		case models.SentenceBoundary:
+			sentences = append(sentences, b)
+		}
+	}
+
+	if len(words) == 0 {
+		cues := make([]Cue, 0, len(sentences))
+		for _, s := range sentences {
+			cues = append(cues, Cue{
+				Start: ms(s.Offset),
+				End:   ms(s.Offset + s.Duration),
+				Text:  strings.TrimSpace(s.Text),
+			})
+		}
+		return cues
+	}
+
+	var cues []Cue
+	var current *Cue
+	currentSentence := -1
+
+	for _, w := range words {
+		text := strings.TrimSpace(w.Text)
+		if text == "" {
+			continue
+		}
+		sentence := sentenceIndex(sentences, w.Offset)
+		start, end := ms(w.Offset), ms(w.Offset+w.Duration)
+
+		if current != nil {
+			joined := joinText(current.Text, text)
+			tooLong := opts.MaxLineLength > 0 && utf8.RuneCountInString(joined) > opts.MaxLineLength
+			tooSlow := opts.MaxDuration > 0 && end-current.Start > opts.MaxDuration
+			if sentence != currentSentence || tooLong || tooSlow {
+				cues = append(cues, *current)
+				current = nil
+			} else {
+				current.Text = joined
+				current.End = end
+				continue
+			}
+		}
+
+		current = &Cue{Start: start, End: end, Text: text}
+		currentSentence = sentence
+	}
+
+	if current != nil {
+		cues = append(cues, *current)
+	}
+
+	return cues
+}
+
+// Render 将字幕条目渲染为指定格式
+func Render(format string, cues []Cue) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatSRT:
+		for i, cue := range cues {
+			fmt.Fprintf(&buf, "%d\n%s --> %s\n%s\n\n", i+1,
+				timestamp(cue.Start, ","), timestamp(cue.End, ","), cue.Text)
+		}
+	case FormatVTT:
+		buf.WriteString("WEBVTT\n\n")
+		for _, cue := range cues {
+			fmt.Fprintf(&buf, "%s --> %s\n%s\n\n",
+				timestamp(cue.Start, "."), timestamp(cue.End, "."), cue.Text)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的字幕格式: %s", format)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// sentenceIndex 查找词所在的句子，找不到时返回-1
+func sentenceIndex(sentences []models.Boundary, offset int64) int {
+	for i := len(sentences) - 1; i >= 0; i-- {
+		if offset >= sentences[i].Offset {
+			return i
+		}
+	}
+	return -1
+}
+
+// joinText 拼接字幕文本，中日韩文字之间不加空格
+func joinText(left, right string) string {
+	if left == "" {
+		return right
+	}
+	last, _ := utf8.DecodeLastRuneInString(left)
+	first, _ := utf8.DecodeRuneInString(right)
+	if isCJK(last) || isCJK(first) || unicode.IsPunct(first) {
+		return left + right
+	}
+	return left + " " + right
+}
+
+// isCJK 判断是否为中日韩文字或全角标点
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r) ||
+		(r >= 0x3000 && r <= 0x303F) ||
+		(r >= 0xFF00 && r <= 0xFFEF)
+}
+
+// timestamp 格式化字幕时间戳
+func timestamp(d time.Duration, sep string) string {
+	total := d.Milliseconds()
+	h := total / 3600000
+	m := total / 60000 % 60
+	s := total / 1000 % 60
+	msec := total % 1000
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, s, sep, msec)
+}
+
+// ms 毫秒转换为time.Duration
+func ms(v int64) time.Duration {
+	return time.Duration(v) * time.Millisecond
+}
@@ -0,0 +1,188 @@
+package tts
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"tts-service/internal/language"
+	"tts-service/internal/models"
+	"tts-service/internal/ssml"
+)
+
+// builtinVoiceAliases 内置的OpenAI语音别名，配置了 openai.voices 时不再使用
+var builtinVoiceAliases = []models.VoiceAlias{
+	{Name: "alloy", Voice: "en-US-JennyNeural", Locales: map[string]string{"zh": "zh-CN-XiaoxiaoNeural"}, Description: "A balanced, neutral voice", Gender: "neutral"},
+	{Name: "echo", Voice: "en-US-GuyNeural", Locales: map[string]string{"zh": "zh-CN-YunxiNeural"}, Description: "A clear, expressive voice", Gender: "male"},
+	{Name: "fable", Voice: "en-GB-RyanNeural", Locales: map[string]string{"zh": "zh-CN-YunjianNeural"}, Description: "A warm, storytelling voice", Gender: "neutral"},
+	{Name: "onyx", Voice: "en-US-ChristopherNeural", Locales: map[string]string{"zh": "zh-CN-YunyangNeural"}, Description: "A deep, authoritative voice", Gender: "male"},
+	{Name: "nova", Voice: "en-US-MichelleNeural", Locales: map[string]string{"zh": "zh-CN-XiaoyiNeural"}, Description: "A bright, energetic voice", Gender: "female"},
+	{Name: "shimmer", Voice: "en-US-AriaNeural", Locales: map[string]string{"zh": "zh-CN-XiaoxiaoNeural"}, Description: "A soft, elegant voice", Gender: "female"},
+}
+
+// aliasNamePattern 别名只能由小写字母、数字、下划线和连字符组成
+var aliasNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// VoiceAliases 用户可用的语音别名：配置文件中的别名(未配置时为内置别名)，再用用户自定义的别名按名称覆盖
+func (s *TTSService) VoiceAliases(userID int) ([]models.VoiceAlias, error) {
+	aliases := make(map[string]models.VoiceAlias)
+	if len(s.config.OpenAI.Voices) > 0 {
+		for name, cfg := range s.config.OpenAI.Voices {
+			aliases[strings.ToLower(name)] = models.VoiceAlias{
+				Name:        strings.ToLower(name),
+				Voice:       cfg.Voice,
+				Locales:     cfg.Locales,
+				Speed:       cfg.Speed,
+				Pitch:       cfg.Pitch,
+				Volume:      cfg.Volume,
+				Style:       cfg.Style,
+				Description: cfg.Description,
+				Gender:      cfg.Gender,
+				Source:      models.VoiceAliasConfig,
+			}
+		}
+	} else {
+		for _, alias := range builtinVoiceAliases {
+			alias.Source = models.VoiceAliasBuiltin
+			aliases[alias.Name] = alias
+		}
+	}
+
+	if userID != 0 && s.db != nil {
+		custom, err := s.db.ListVoiceAliases(userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, alias := range custom {
+			aliases[alias.Name] = alias
+		}
+	}
+
+	list := make([]models.VoiceAlias, 0, len(aliases))
+	for _, alias := range aliases {
+		list = append(list, alias)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// ResolveVoiceAlias 把OpenAI请求中的语音名称解析为实际语音，并应用别名的默认韵律
+// 按输入文本的主要语言查别名的locales，没有对应语言或该语音未启用时使用别名的默认语音，
+// 默认语音也为空时按语言自动选择；不是别名的名称按语音名称原样使用
+func (s *TTSService) ResolveVoiceAlias(req *models.TTSRequest, name string) error {
+	aliases, err := s.VoiceAliases(req.UserID)
+	if err != nil {
+		return err
+	}
+
+	var alias *models.VoiceAlias
+	for i := range aliases {
+		if strings.EqualFold(aliases[i].Name, name) {
+			alias = &aliases[i]
+			break
+		}
+	}
+	if alias == nil {
+		req.Voice = name
+		return nil
+	}
+
+	req.Voice = alias.Voice
+	if voice := alias.Locales[language.Detect(req.Text)]; voice != "" {
+		found, _, err := s.findVoice(&models.TTSRequest{Voice: voice, Engine: req.Engine})
+		if err != nil {
+			return err
+		}
+		if found != nil {
+			req.Voice = voice
+		}
+	}
+
+	if alias.Speed > 0 {
+		if req.Speed == 0 {
+			req.Speed = 1
+		}
+		req.Speed *= alias.Speed
+	}
+	if req.Pitch == "" {
+		req.Pitch = models.ProsodyValue(alias.Pitch)
+	}
+	if req.Volume == "" {
+		req.Volume = models.ProsodyValue(alias.Volume)
+	}
+	if alias.Style != "" && (req.Style == "" || req.Style == "default") {
+		req.Style = alias.Style
+	}
+	return nil
+}
+
+// SaveVoiceAlias 校验并保存用户自定义的语音别名，同名时覆盖
+func (s *TTSService) SaveVoiceAlias(alias *models.VoiceAlias) error {
+	if err := s.validateVoiceAlias(alias); err != nil {
+		return err
+	}
+	return s.db.SaveVoiceAlias(alias)
+}
+
+// DeleteVoiceAlias 删除用户自定义的语音别名，返回是否找到该别名
+// 删除后同名的配置或内置别名重新生效
+func (s *TTSService) DeleteVoiceAlias(userID int, name string) (bool, error) {
+	return s.db.DeleteVoiceAlias(userID, strings.ToLower(name))
+}
+
+// validateVoiceAlias 规范化别名名称和语言代码，检查引用的语音、韵律和风格
+func (s *TTSService) validateVoiceAlias(alias *models.VoiceAlias) error {
+	alias.Name = strings.ToLower(strings.TrimSpace(alias.Name))
+	if !aliasNamePattern.MatchString(alias.Name) {
+		return &ParamError{Param: "name", Value: alias.Name, Message: "别名只能包含小写字母、数字、下划线和连字符，最长64个字符"}
+	}
+
+	alias.Voice = strings.TrimSpace(alias.Voice)
+	if alias.Voice == "" && len(alias.Locales) == 0 {
+		return &ParamError{Param: "voice", Message: "需要指定 voice 或 locales"}
+	}
+
+	voices := []string{}
+	if alias.Voice != "" {
+		voices = append(voices, alias.Voice)
+	}
+	locales := make(map[string]string, len(alias.Locales))
+	for lang, voice := range alias.Locales {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		voice = strings.TrimSpace(voice)
+		if lang == "" || voice == "" {
+			return &ParamError{Param: "locales", Message: "语言代码和语音都不能为空"}
+		}
+		locales[lang] = voice
+		voices = append(voices, voice)
+	}
+	alias.Locales = locales
+
+	for _, name := range voices {
+		voice, _, err := s.findVoice(&models.TTSRequest{Voice: name})
+		if err != nil {
+			return err
+		}
+		if voice == nil {
+			return &ParamError{Param: "voice", Value: name, Message: "未知的语音，可用语音见 /api/v1/voices"}
+		}
+		if alias.Style != "" && !containsFold(voice.Styles, &alias.Style) {
+			return &ParamError{Param: "style", Value: alias.Style, Message: "语音 " + voice.Name + " 不支持该风格", Supported: voice.Styles}
+		}
+	}
+
+	if alias.Speed < 0 {
+		return &ParamError{Param: "speed", Value: fmt.Sprint(alias.Speed), Message: "语速倍数必须大于0"}
+	}
+	if alias.Pitch != "" {
+		if _, err := ssml.ParsePitch(alias.Pitch); err != nil {
+			return &ParamError{Param: "pitch", Value: alias.Pitch, Message: err.Error()}
+		}
+	}
+	if alias.Volume != "" {
+		if _, err := ssml.ParseVolume(alias.Volume); err != nil {
+			return &ParamError{Param: "volume", Value: alias.Volume, Message: err.Error()}
+		}
+	}
+	return nil
+}
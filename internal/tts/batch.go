@@ -0,0 +1,233 @@
+package tts
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+	"tts-service/internal/audio"
+	"tts-service/internal/models"
+	"tts-service/internal/utils"
+)
+
+const (
+	defaultBatchMaxItems    = 100
+	defaultBatchConcurrency = 3
+	maxBatchSilenceMs       = 10000
+)
+
+// NormalizeBatch 校验批量请求并设置默认值，参数错误返回 *ParamError
+// merged 输出要求所有条目格式一致，未指定格式的条目使用批量请求的格式
+func (s *TTSService) NormalizeBatch(batch *models.BatchRequest) error {
+	maxItems := s.config.TTS.Batch.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultBatchMaxItems
+	}
+	if len(batch.Items) == 0 {
+		return &ParamError{Param: "items", Message: "批量请求不能为空"}
+	}
+	if len(batch.Items) > maxItems {
+		return &ParamError{Param: "items", Value: strconv.Itoa(len(batch.Items)), Message: fmt.Sprintf("批量请求最多 %d 条", maxItems)}
+	}
+
+	if batch.Output == "" {
+		batch.Output = models.BatchOutputZip
+	}
+	switch batch.Output {
+	case models.BatchOutputZip:
+	case models.BatchOutputMerged:
+		if batch.Format == "" {
+			batch.Format = s.config.TTS.DefaultFormat
+		}
+		if !audio.CanJoin(batch.Format) {
+			return &ParamError{Param: "format", Value: batch.Format, Message: "不支持合并输出的音频格式", Supported: audio.JoinFormats}
+		}
+		if batch.SilenceMs < 0 || batch.SilenceMs > maxBatchSilenceMs {
+			return &ParamError{Param: "silence_ms", Value: strconv.Itoa(batch.SilenceMs), Message: fmt.Sprintf("静音时长需在 0-%d 毫秒之间", maxBatchSilenceMs)}
+		}
+	default:
+		return &ParamError{
+			Param:     "output",
+			Value:     batch.Output,
+			Message:   "不支持的输出方式",
+			Supported: []string{models.BatchOutputZip, models.BatchOutputMerged},
+		}
+	}
+
+	for i := range batch.Items {
+		item := &batch.Items[i]
+		if item.Text == "" {
+			return &ParamError{Param: fmt.Sprintf("items[%d].text", i), Message: "文本内容不能为空"}
+		}
+		// 批量结果以文件形式返回，不支持逐条流式输出
+		item.Stream = false
+		if batch.Output == models.BatchOutputMerged {
+			if item.Format != "" && item.Format != batch.Format {
+				return &ParamError{
+					Param:   fmt.Sprintf("items[%d].format", i),
+					Value:   item.Format,
+					Message: fmt.Sprintf("与合并输出格式 %s 不一致", batch.Format),
+				}
+			}
+			item.Format = batch.Format
+		}
+		if err := s.NormalizeRequest(item); err != nil {
+			return fmt.Errorf("第 %d 条: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// ProcessBatch 并发合成所有条目，已缓存的条目直接复用
+// 任一条目失败时取消其余条目，返回序号最小的错误
+func (s *TTSService) ProcessBatch(ctx context.Context, batch *models.BatchRequest) ([]*models.TTSData, error) {
+	if err := s.NormalizeBatch(batch); err != nil {
+		return nil, err
+	}
+
+	concurrency := s.config.TTS.Batch.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*models.TTSData, len(batch.Items))
+	errs := make([]error, len(batch.Items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := range batch.Items {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			results[i], errs[i] = s.process(ctx, &batch.Items[i], nil)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// 优先返回真正的合成错误，而不是被取消的条目
+	var firstErr error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("第 %d 条合成失败: %w", i+1, err)
+		if !errors.Is(errs[i], context.Canceled) {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// WriteBatchZip 将每条音频打包为ZIP，附带 manifest.json
+func (s *TTSService) WriteBatchZip(w io.Writer, batch *models.BatchRequest, results []*models.TTSData) error {
+	zw := zip.NewWriter(w)
+	manifest := make([]models.BatchManifestItem, 0, len(results))
+
+	for i, result := range results {
+		item := batch.Items[i]
+		name := fmt.Sprintf("%03d%s", i+1, utils.GetFileExtension(item.Format))
+
+		// 音频本身已经压缩，直接存储
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Store,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("写入ZIP失败: %w", err)
+		}
+		if err := s.copyObject(context.Background(), fw, s.resultKey(result)); err != nil {
+			return fmt.Errorf("写入第 %d 条音频失败: %w", i+1, err)
+		}
+
+		manifest = append(manifest, models.BatchManifestItem{
+			Index:    i + 1,
+			File:     name,
+			Text:     item.Text,
+			Voice:    item.Voice,
+			Engine:   item.Engine,
+			Format:   item.Format,
+			Speed:    item.Speed,
+			Rate:     item.Rate,
+			Pitch:    string(item.Pitch),
+			Volume:   string(item.Volume),
+			Duration: result.Duration,
+			Size:     result.Size,
+			AudioURL: result.AudioURL,
+		})
+	}
+
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     "manifest.json",
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("写入ZIP失败: %w", err)
+	}
+	encoder := json.NewEncoder(fw)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("写入清单失败: %w", err)
+	}
+
+	return zw.Close()
+}
+
+// WriteBatchMerged 按顺序拼接所有音频，条目之间插入静音
+func (s *TTSService) WriteBatchMerged(w io.Writer, batch *models.BatchRequest, results []*models.TTSData) error {
+	joiner, err := audio.NewJoiner(batch.Format, w)
+	if err != nil {
+		return err
+	}
+
+	silence := time.Duration(batch.SilenceMs) * time.Millisecond
+	for i, result := range results {
+		if i > 0 {
+			if err := joiner.AppendSilence(silence); err != nil {
+				return fmt.Errorf("插入静音失败: %w", err)
+			}
+		}
+
+		data, err := s.ReadAudio(context.Background(), s.resultKey(result))
+		if err != nil {
+			return fmt.Errorf("读取第 %d 条音频失败: %w", i+1, err)
+		}
+		if _, err := joiner.Append(data); err != nil {
+			return fmt.Errorf("拼接第 %d 条音频失败: %w", i+1, err)
+		}
+	}
+
+	return joiner.Close()
+}
+
+// resultKey 合成结果对应的音频对象键
+func (s *TTSService) resultKey(result *models.TTSData) string {
+	return s.AudioKey(path.Base(result.AudioURL))
+}
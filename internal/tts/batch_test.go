@@ -0,0 +1,52 @@
+package tts
+
+import (
+	"errors"
+	"testing"
+	"tts-service/internal/config"
+	"tts-service/internal/models"
+)
+
+func TestNormalizeBatchParamErrors(t *testing.T) {
+	item := func(text, format string) models.TTSRequest {
+		return models.TTSRequest{Text: text, Voice: testVoice, Format: format}
+	}
+
+	tests := []struct {
+		name      string
+		batch     models.BatchRequest
+		wantParam string // 为空时期望校验通过
+	}{
+		{name: "valid zip", batch: models.BatchRequest{Items: []models.TTSRequest{item("你好", "wav")}}},
+		{name: "valid merged", batch: models.BatchRequest{Items: []models.TTSRequest{item("你好", "")}, Output: models.BatchOutputMerged, Format: "wav"}},
+		{name: "empty", batch: models.BatchRequest{}, wantParam: "items"},
+		{name: "too many", batch: models.BatchRequest{Items: make([]models.TTSRequest, 3)}, wantParam: "items"},
+		{name: "unknown output", batch: models.BatchRequest{Items: []models.TTSRequest{item("你好", "")}, Output: "tar"}, wantParam: "output"},
+		{name: "merged format", batch: models.BatchRequest{Items: []models.TTSRequest{item("你好", "")}, Output: models.BatchOutputMerged, Format: "flac"}, wantParam: "format"},
+		{name: "silence", batch: models.BatchRequest{Items: []models.TTSRequest{item("你好", "")}, Output: models.BatchOutputMerged, Format: "wav", SilenceMs: -1}, wantParam: "silence_ms"},
+		{name: "empty text", batch: models.BatchRequest{Items: []models.TTSRequest{item("你好", ""), item("", "")}}, wantParam: "items[1].text"},
+		{name: "format mismatch", batch: models.BatchRequest{Items: []models.TTSRequest{item("你好", "mp3")}, Output: models.BatchOutputMerged, Format: "wav"}, wantParam: "items[0].format"},
+		{name: "item error", batch: models.BatchRequest{Items: []models.TTSRequest{{Text: "你好", Voice: "unknown", Format: "wav"}}}, wantParam: "voice"},
+	}
+
+	s := newTestService(t, config.BreakerConfig{}, &fakeEngine{name: "a"})
+	s.config.TTS.Batch.MaxItems = 2
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.NormalizeBatch(&tt.batch)
+			if tt.wantParam == "" {
+				if err != nil {
+					t.Fatalf("校验失败: %v", err)
+				}
+				return
+			}
+			var param *ParamError
+			if !errors.As(err, &param) {
+				t.Fatalf("err = %v, 期望 ParamError", err)
+			}
+			if param.Param != tt.wantParam {
+				t.Fatalf("参数 %s, 期望 %s", param.Param, tt.wantParam)
+			}
+		})
+	}
+}
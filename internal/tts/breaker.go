@@ -0,0 +1,175 @@
+package tts
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// 熔断器默认参数
+const (
+	defaultFailureThreshold = 5
+	defaultCooldown         = 30 * time.Second
+	defaultHalfOpenRequests = 1
+)
+
+// BreakerStatus 熔断器状态快照
+type BreakerStatus struct {
+	Engine              string     `json:"engine"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAfterSeconds   int        `json:"retry_after_seconds,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+// CircuitBreaker 引擎熔断器
+// 连续失败达到阈值后打开，冷却期结束后进入半开状态放行少量探测请求，探测成功则关闭
+type CircuitBreaker struct {
+	mu sync.Mutex
+
+	engine           string
+	failureThreshold int
+	cooldown         time.Duration
+	halfOpenRequests int
+
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   int
+	lastError string
+}
+
+// NewCircuitBreaker 创建熔断器，参数不合法时使用默认值
+func NewCircuitBreaker(engine string, failureThreshold int, cooldown time.Duration, halfOpenRequests int) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = defaultFailureThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultCooldown
+	}
+	if halfOpenRequests <= 0 {
+		halfOpenRequests = defaultHalfOpenRequests
+	}
+
+	return &CircuitBreaker{
+		engine:           engine,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		halfOpenRequests: halfOpenRequests,
+		state:            BreakerClosed,
+	}
+}
+
+// Allow 判断是否放行请求，不放行时返回还需等待的时间
+func (b *CircuitBreaker) Allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		remaining := b.cooldown - time.Since(b.openedAt)
+		if remaining > 0 {
+			return false, remaining
+		}
+		b.state = BreakerHalfOpen
+		b.probing = 0
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing >= b.halfOpenRequests {
+			return false, time.Second
+		}
+		b.probing++
+		return true, 0
+	default:
+		return true, 0
+	}
+}
+
+// Success 记录一次成功
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = 0
+	b.lastError = ""
+}
+
+// Failure 记录一次失败
+func (b *CircuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if err != nil {
+		b.lastError = err.Error()
+	}
+
+	if b.state == BreakerHalfOpen || b.failures >= b.failureThreshold {
+		if b.state != BreakerOpen {
+			fmt.Printf("TTS引擎 %s 熔断器打开: %s\n", b.engine, b.lastError)
+		}
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		b.probing = 0
+	}
+}
+
+// Release 放弃一次已放行的请求，既不算成功也不算失败（如客户端断开）
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probing > 0 {
+		b.probing--
+	}
+}
+
+// Status 获取熔断器状态快照
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		Engine:              b.engine,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	if b.state == BreakerOpen {
+		if remaining := b.cooldown - time.Since(b.openedAt); remaining > 0 {
+			status.RetryAfterSeconds = int((remaining + time.Second - 1) / time.Second)
+		}
+	}
+	return status
+}
+
+// UnavailableError 所有可用引擎均不可用
+type UnavailableError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("TTS引擎暂不可用: %v", e.Err)
+	}
+	return "TTS引擎暂不可用"
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,131 @@
+package tts
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	errEngine := errors.New("引擎故障")
+
+	type step struct {
+		action    string // allow、success、failure、release、wait
+		wantAllow bool
+		wantState string
+	}
+	tests := []struct {
+		name             string
+		threshold, probe int
+		steps            []step
+	}{
+		{name: "trips at threshold", threshold: 2, probe: 1, steps: []step{
+			{action: "failure", wantState: BreakerClosed},
+			{action: "allow", wantAllow: true, wantState: BreakerClosed},
+			{action: "failure", wantState: BreakerOpen},
+			{action: "allow", wantAllow: false, wantState: BreakerOpen},
+		}},
+		{name: "success resets failures", threshold: 2, probe: 1, steps: []step{
+			{action: "failure", wantState: BreakerClosed},
+			{action: "success", wantState: BreakerClosed},
+			{action: "failure", wantState: BreakerClosed},
+		}},
+		{name: "half-open probe closes", threshold: 1, probe: 1, steps: []step{
+			{action: "failure", wantState: BreakerOpen},
+			{action: "wait"},
+			{action: "allow", wantAllow: true, wantState: BreakerHalfOpen},
+			{action: "allow", wantAllow: false, wantState: BreakerHalfOpen},
+			{action: "success", wantState: BreakerClosed},
+			{action: "allow", wantAllow: true, wantState: BreakerClosed},
+		}},
+		{name: "half-open failure reopens", threshold: 3, probe: 1, steps: []step{
+			{action: "failure"}, {action: "failure"}, {action: "failure", wantState: BreakerOpen},
+			{action: "wait"},
+			{action: "allow", wantAllow: true, wantState: BreakerHalfOpen},
+			{action: "failure", wantState: BreakerOpen},
+			{action: "allow", wantAllow: false, wantState: BreakerOpen},
+		}},
+		{name: "release frees probe", threshold: 1, probe: 2, steps: []step{
+			{action: "failure", wantState: BreakerOpen},
+			{action: "wait"},
+			{action: "allow", wantAllow: true, wantState: BreakerHalfOpen},
+			{action: "allow", wantAllow: true, wantState: BreakerHalfOpen},
+			{action: "allow", wantAllow: false, wantState: BreakerHalfOpen},
+			{action: "release", wantState: BreakerHalfOpen},
+			{action: "allow", wantAllow: true, wantState: BreakerHalfOpen},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker("test", tt.threshold, cooldown, tt.probe)
+			for i, s := range tt.steps {
+				switch s.action {
+				case "allow":
+					ok, wait := b.Allow()
+					if ok != s.wantAllow {
+						t.Fatalf("第 %d 步 Allow() = %v, 期望 %v", i+1, ok, s.wantAllow)
+					}
+					if !ok && wait <= 0 {
+						t.Fatalf("第 %d 步拒绝请求时没有返回等待时间", i+1)
+					}
+				case "success":
+					b.Success()
+				case "failure":
+					b.Failure(errEngine)
+				case "release":
+					b.Release()
+				case "wait":
+					time.Sleep(cooldown + 10*time.Millisecond)
+					continue
+				}
+				if s.wantState != "" && b.Status().State != s.wantState {
+					t.Fatalf("第 %d 步后状态 %s, 期望 %s", i+1, b.Status().State, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryRetryAfter(t *testing.T) {
+	registry := &EngineRegistry{engines: make(map[string]Engine), breakers: make(map[string]*CircuitBreaker)}
+	a, b, c := &fakeEngine{name: "a"}, &fakeEngine{name: "b"}, &fakeEngine{name: "c"}
+	for _, e := range []Engine{a, b, c} {
+		registry.Register(e)
+	}
+	registry.breakers["a"] = NewCircuitBreaker("a", 1, 30*time.Second, 1)
+	registry.breakers["b"] = NewCircuitBreaker("b", 1, 5*time.Second, 1)
+
+	if got := registry.RetryAfter([]Engine{a, b, c}); got != 0 {
+		t.Fatalf("没有熔断的引擎时 RetryAfter = %v, 期望 0", got)
+	}
+
+	registry.Breaker("a").Failure(errors.New("引擎故障"))
+	registry.Breaker("b").Failure(errors.New("引擎故障"))
+	tests := []struct {
+		engines []Engine
+		want    time.Duration
+	}{
+		{[]Engine{a}, 30 * time.Second},
+		{[]Engine{a, b}, 5 * time.Second}, // 取最早恢复的引擎
+		{[]Engine{a, b, c}, 5 * time.Second},
+		{[]Engine{c}, 0},
+	}
+	for _, tt := range tests {
+		if got := registry.RetryAfter(tt.engines); got != tt.want {
+			t.Errorf("RetryAfter(%d个引擎) = %v, 期望 %v", len(tt.engines), got, tt.want)
+		}
+	}
+
+	// 半开状态下探测名额已满时同样返回等待时间
+	half := NewCircuitBreaker("half", 1, 10*time.Millisecond, 1)
+	half.Failure(errors.New("引擎故障"))
+	time.Sleep(20 * time.Millisecond)
+	if ok, _ := half.Allow(); !ok {
+		t.Fatal("冷却结束后应放行探测请求")
+	}
+	if ok, wait := half.Allow(); ok || wait <= 0 {
+		t.Fatalf("探测名额已满时 Allow() = %v, %v, 期望拒绝并返回等待时间", ok, wait)
+	}
+}
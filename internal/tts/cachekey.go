@@ -0,0 +1,120 @@
+package tts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"tts-service/internal/models"
+	"tts-service/internal/storage"
+	"tts-service/internal/subtitle"
+)
+
+// migrateBatchSize 每次清理的旧版缓存记录数
+const migrateBatchSize = 500
+
+// CacheKeyVersion 缓存键版本，键的组成发生变化时递增，旧版本的缓存在启动时清理
+// 版本1为MD5(文本|语音|格式)，不包含语速、音调等参数
+const CacheKeyVersion = 2
+
+// cacheKeyFields 参与缓存键计算的请求字段，按固定顺序序列化
+// 字幕和边界选项不影响音频内容，不计入缓存键
+type cacheKeyFields struct {
+	Version     int     `json:"v"`
+	Text        string  `json:"text"`
+	SSML        bool    `json:"ssml"`
+	Engine      string  `json:"engine"`
+	Voice       string  `json:"voice"`
+	Format      string  `json:"format"`
+	Rate        string  `json:"rate"`
+	Pitch       string  `json:"pitch"`
+	Volume      string  `json:"volume"`
+	Contour     string  `json:"contour"`
+	Style       string  `json:"style"`
+	StyleDegree float64 `json:"styledegree"`
+	Role        string  `json:"role"`
+	Lexicon     string  `json:"lexicon"`
+	// 混合语言模式生成的文档，其他请求为空且不写出，不影响已有的缓存键
+	Documents []string `json:"documents,omitempty"`
+}
+
+// cacheHash 根据规范化后的完整请求生成SHA-256缓存键，同时用作缓存文件名
+func cacheHash(req *models.TTSRequest, engine string) string {
+	fields := cacheKeyFields{
+		Version:     CacheKeyVersion,
+		Text:        req.Text,
+		SSML:        req.SSML,
+		Engine:      engine,
+		Voice:       req.Voice,
+		Format:      req.Format,
+		Rate:        req.Rate,
+		Pitch:       string(req.Pitch),
+		Volume:      string(req.Volume),
+		Contour:     contourString(req.Contour),
+		Style:       req.Style,
+		StyleDegree: req.StyleDegree,
+		Role:        req.Role,
+		Lexicon:     req.LexiconVersion,
+		Documents:   req.Documents,
+	}
+
+	// 结构体按字段顺序序列化，结果是确定的；字段都是基本类型，不会失败
+	data, _ := json.Marshal(fields)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// redisCacheKey Redis中的缓存键，带版本前缀避免与旧版本的键混用
+func redisCacheKey(textHash string) string {
+	return fmt.Sprintf("tts:v%d:%s", CacheKeyVersion, textHash)
+}
+
+// MigrateCache 清理旧版本缓存键的缓存记录和对应的音频、边界、字幕文件，返回清理的记录数
+// 旧版本的缓存键不包含语速、音调等参数，无法判断文件是按哪组参数合成的，只能删除后按需重新合成
+func (s *TTSService) MigrateCache() (int, error) {
+	removed := 0
+	for {
+		caches, err := s.db.ListOutdatedCache(CacheKeyVersion, migrateBatchSize)
+		if err != nil {
+			return removed, err
+		}
+		if len(caches) == 0 {
+			return removed, nil
+		}
+
+		for _, cache := range caches {
+			freed, err := s.evictCache(context.Background(), cache)
+			s.releaseStorage(freed)
+			if err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+}
+
+// removeCacheObjects 删除音频及其边界元数据和字幕，对象不存在时忽略，返回删除的字节数
+// 字幕按当前的切分选项和旧版本不含选项的文件名删除，其他选项生成的字幕在缓存记录删除后由孤立文件清理删除
+func (s *TTSService) removeCacheObjects(ctx context.Context, key string) (int64, error) {
+	keys := []string{key, boundariesKey(key)}
+	for _, format := range []string{subtitle.FormatSRT, subtitle.FormatVTT} {
+		keys = append(keys, s.subtitleKey(key, format), sidecarKey(key, "."+format))
+	}
+	var freed int64
+	for _, key := range keys {
+		info, err := s.store.Stat(ctx, key)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotExist) {
+				continue
+			}
+			return freed, fmt.Errorf("删除缓存文件失败: %w", err)
+		}
+		if err := s.store.Delete(ctx, key); err != nil {
+			return freed, fmt.Errorf("删除缓存文件失败: %w", err)
+		}
+		freed += info.Size
+	}
+	return freed, nil
+}
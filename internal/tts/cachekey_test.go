@@ -0,0 +1,150 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"tts-service/internal/config"
+	"tts-service/internal/models"
+	"tts-service/internal/storage"
+)
+
+func TestCacheHashFields(t *testing.T) {
+	base := func() *models.TTSRequest {
+		return &models.TTSRequest{Text: "你好", Voice: "zh-CN-XiaoxiaoNeural", Format: "mp3"}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *models.TTSRequest)
+		engine string
+		same   bool
+	}{
+		{name: "text", modify: func(r *models.TTSRequest) { r.Text = "你好!" }},
+		{name: "ssml", modify: func(r *models.TTSRequest) { r.SSML = true }},
+		{name: "engine", engine: "offline"},
+		{name: "voice", modify: func(r *models.TTSRequest) { r.Voice = "zh-CN-YunxiNeural" }},
+		{name: "format", modify: func(r *models.TTSRequest) { r.Format = "wav" }},
+		{name: "rate", modify: func(r *models.TTSRequest) { r.Rate = "+20%" }},
+		{name: "pitch", modify: func(r *models.TTSRequest) { r.Pitch = "+2st" }},
+		{name: "volume", modify: func(r *models.TTSRequest) { r.Volume = "loud" }},
+		{name: "contour", modify: func(r *models.TTSRequest) {
+			r.Contour = []models.ContourPoint{{Position: 0, Pitch: "+10%"}, {Position: 100, Pitch: "-10%"}}
+		}},
+		{name: "style", modify: func(r *models.TTSRequest) { r.Style = "cheerful" }},
+		{name: "style degree", modify: func(r *models.TTSRequest) { r.StyleDegree = 1.5 }},
+		{name: "role", modify: func(r *models.TTSRequest) { r.Role = "Girl" }},
+		{name: "lexicon version", modify: func(r *models.TTSRequest) { r.LexiconVersion = "abc" }},
+		{name: "documents", modify: func(r *models.TTSRequest) { r.Documents = []string{"<speak/>"} }},
+		// 不影响音频内容的选项共用同一个缓存
+		{name: "subtitles", modify: func(r *models.TTSRequest) { r.Subtitles = "srt" }, same: true},
+		{name: "boundaries", modify: func(r *models.TTSRequest) { r.WordBoundary, r.SentenceBoundary = true, true }, same: true},
+		{name: "stream", modify: func(r *models.TTSRequest) { r.Stream = true }, same: true},
+		{name: "user", modify: func(r *models.TTSRequest) { r.UserID = 7 }, same: true},
+		{name: "normalized", modify: func(r *models.TTSRequest) { r.Normalized = true }, same: true},
+	}
+
+	want := cacheHash(base(), "edge")
+	if len(want) != 64 {
+		t.Fatalf("缓存键长度 %d, 期望64位十六进制", len(want))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base()
+			if tt.modify != nil {
+				tt.modify(req)
+			}
+			engine := "edge"
+			if tt.engine != "" {
+				engine = tt.engine
+			}
+			if got := cacheHash(req, engine); (got == want) != tt.same {
+				t.Fatalf("缓存键相同: %v, 期望 %v", got == want, tt.same)
+			}
+		})
+	}
+}
+
+// 缓存键变化会让已有缓存全部失效，修改键的组成时需要同时递增 CacheKeyVersion
+func TestCacheHashStable(t *testing.T) {
+	req := &models.TTSRequest{Text: "你好", Voice: "zh-CN-XiaoxiaoNeural", Format: "mp3", Rate: "+20%"}
+	const want = "f814fbbe2a92ea0b50af545f6ff1dd99a8243493007cbf3fde565c4057276cd7"
+	if got := cacheHash(req, "edge"); got != want {
+		t.Fatalf("缓存键 %s, 期望 %s", got, want)
+	}
+	if got := redisCacheKey(want); got != "tts:v2:"+want {
+		t.Fatalf("Redis键 %s 没有带版本前缀", got)
+	}
+}
+
+// 语速、音调的不同写法规范化后得到相同的缓存键
+func TestCacheHashEquivalentProsody(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b models.TTSRequest
+	}{
+		{name: "speed and rate", a: models.TTSRequest{Speed: 1.5}, b: models.TTSRequest{Speed: 1, Rate: "+50%"}},
+		{name: "default rate", a: models.TTSRequest{Speed: 1}, b: models.TTSRequest{Speed: 1, Rate: "+0%"}},
+		{name: "default pitch", a: models.TTSRequest{Speed: 1}, b: models.TTSRequest{Speed: 1, Pitch: "+0Hz"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashes := make([]string, 2)
+			for i, req := range []models.TTSRequest{tt.a, tt.b} {
+				req.Text, req.Voice, req.Format = "你好", "zh-CN-XiaoxiaoNeural", "mp3"
+				if err := normalizeProsody(&req); err != nil {
+					t.Fatalf("规范化失败: %v", err)
+				}
+				hashes[i] = cacheHash(&req, "edge")
+			}
+			if hashes[0] != hashes[1] {
+				t.Fatalf("等价的请求得到不同的缓存键")
+			}
+		})
+	}
+}
+
+func TestMigrateCache(t *testing.T) {
+	s := newTestService(t, config.BreakerConfig{}, &fakeEngine{name: "a"})
+	ctx := context.Background()
+
+	put := func(key string) {
+		t.Helper()
+		if err := s.store.Put(ctx, key, strings.NewReader("data"), 4); err != nil {
+			t.Fatal(err)
+		}
+	}
+	caches := []*models.TTSCache{
+		{TextHash: strings.Repeat("1", 32), Voice: testVoice, Format: "wav", AudioPath: strings.Repeat("1", 32) + ".wav", KeyVersion: 1},
+		{TextHash: strings.Repeat("2", 64), Voice: testVoice, Format: "wav", AudioPath: strings.Repeat("2", 64) + ".wav", KeyVersion: CacheKeyVersion},
+	}
+	for _, cache := range caches {
+		put(cache.AudioPath)
+		put(boundariesKey(cache.AudioPath))
+		if err := s.db.CreateTTSCache(cache); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := s.MigrateCache()
+	if err != nil || removed != 1 {
+		t.Fatalf("MigrateCache = %d, %v, 期望清理1条", removed, err)
+	}
+	for i, cache := range caches {
+		record, err := s.db.FindTTSCache(cache.TextHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		current := i == 1
+		if (record != nil) != current {
+			t.Errorf("版本%d的缓存记录存在: %v", cache.KeyVersion, record != nil)
+		}
+		for _, key := range []string{cache.AudioPath, boundariesKey(cache.AudioPath)} {
+			_, err := s.store.Stat(ctx, key)
+			if exists := !errors.Is(err, storage.ErrNotExist); exists != current {
+				t.Errorf("版本%d的文件 %s 存在: %v", cache.KeyVersion, key, exists)
+			}
+		}
+	}
+}
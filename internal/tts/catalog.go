@@ -0,0 +1,356 @@
+package tts
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"tts-service/internal/config"
+	"tts-service/internal/models"
+)
+
+const (
+	// defaultVoicesURL Edge语音列表地址，请求时追加Sec-MS-GEC参数
+	defaultVoicesURL = "https://speech.platform.bing.com/consumer/speech/synthesize/readaloud/voices/list"
+	// defaultVoicesRefresh 默认刷新间隔
+	defaultVoicesRefresh = 24 * time.Hour
+	// voicesRetryDelay 刷新失败后的重试间隔
+	voicesRetryDelay = 10 * time.Minute
+	// voicesFetchTimeout 单次获取的超时时间
+	voicesFetchTimeout = 30 * time.Second
+)
+
+// edgeVoicesSnapshot 内置的Edge语音列表快照，离线且没有本地缓存时使用
+// Edge的语音列表不包含说话风格和角色，StyleList/RolePlayList取自Azure语音服务文档
+//
+//go:embed voices/edge.json
+var edgeVoicesSnapshot []byte
+
+// edgeVoice Edge语音列表中的条目
+type edgeVoice struct {
+	Name           string `json:"Name"`
+	ShortName      string `json:"ShortName"`
+	Gender         string `json:"Gender"`
+	Locale         string `json:"Locale"`
+	SuggestedCodec string `json:"SuggestedCodec"`
+	FriendlyName   string `json:"FriendlyName"`
+	Status         string `json:"Status"`
+	VoiceTag       struct {
+		ContentCategories  []string `json:"ContentCategories"`
+		VoicePersonalities []string `json:"VoicePersonalities"`
+	} `json:"VoiceTag"`
+	StyleList    []string `json:"StyleList,omitempty"`
+	RolePlayList []string `json:"RolePlayList,omitempty"`
+}
+
+// VoiceCatalog Edge语音目录
+// 启动时先读取本地缓存或内置快照，再按配置的间隔在线刷新，刷新失败时保留已有列表
+type VoiceCatalog struct {
+	url       string
+	userAgent string
+	cachePath string
+	refresh   time.Duration
+	client    *http.Client
+
+	mu        sync.RWMutex
+	voices    []edgeVoice
+	index     map[string]int
+	updatedAt time.Time
+}
+
+// NewVoiceCatalog 创建语音目录，本地缓存默认放在数据库所在目录
+func NewVoiceCatalog(cfg *config.Config) *VoiceCatalog {
+	catalogCfg := cfg.EdgeTTS.Voices
+	c := &VoiceCatalog{
+		url:       catalogCfg.URL,
+		userAgent: cfg.EdgeTTS.UserAgent,
+		cachePath: catalogCfg.CachePath,
+		refresh:   time.Duration(catalogCfg.RefreshHours) * time.Hour,
+		client:    &http.Client{Timeout: voicesFetchTimeout},
+	}
+	if c.url == "" {
+		c.url = defaultVoicesURL
+	}
+	if c.cachePath == "" {
+		c.cachePath = filepath.Join(filepath.Dir(cfg.Database.Path), "edge_voices.json")
+	}
+	if c.refresh <= 0 {
+		c.refresh = defaultVoicesRefresh
+	}
+
+	if err := c.loadCache(); err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("读取语音目录缓存失败，使用内置列表: %v\n", err)
+		}
+		if err := c.loadSnapshot(); err != nil {
+			fmt.Printf("读取内置语音列表失败: %v\n", err)
+		}
+	}
+	return c
+}
+
+// Start 后台定时刷新，缓存已过期时立即刷新
+func (c *VoiceCatalog) Start(ctx context.Context) {
+	go func() {
+		delay := time.Until(c.UpdatedAt().Add(c.refresh))
+		for {
+			if delay < 0 {
+				delay = 0
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			delay = c.refresh
+			if err := c.Refresh(ctx); err != nil {
+				fmt.Printf("刷新Edge语音目录失败: %v\n", err)
+				delay = voicesRetryDelay
+			}
+		}
+	}()
+}
+
+// Refresh 在线获取语音列表并写入本地缓存
+func (c *VoiceCatalog) Refresh(ctx context.Context) error {
+	voices, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 在线列表不包含说话风格和角色，沿用已有列表中的信息
+	c.mu.RLock()
+	for i := range voices {
+		if j, ok := c.index[voices[i].ShortName]; ok {
+			if len(voices[i].StyleList) == 0 {
+				voices[i].StyleList = c.voices[j].StyleList
+			}
+			if len(voices[i].RolePlayList) == 0 {
+				voices[i].RolePlayList = c.voices[j].RolePlayList
+			}
+		}
+	}
+	c.mu.RUnlock()
+
+	if err := c.saveCache(voices); err != nil {
+		fmt.Printf("保存语音目录缓存失败: %v\n", err)
+	}
+	c.set(voices, time.Now())
+	fmt.Printf("Edge语音目录已更新，共 %d 个语音\n", len(voices))
+	return nil
+}
+
+// Voices 目录中的全部语音
+func (c *VoiceCatalog) Voices(formats []string) []models.Voice {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	voices := make([]models.Voice, len(c.voices))
+	for i, v := range c.voices {
+		voices[i] = v.toModel(formats)
+	}
+	return voices
+}
+
+// UpdatedAt 当前列表的更新时间，内置快照为零值
+func (c *VoiceCatalog) UpdatedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.updatedAt
+}
+
+// fetch 请求Edge语音列表
+func (c *VoiceCatalog) fetch(ctx context.Context) ([]edgeVoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, voicesFetchTimeout)
+	defer cancel()
+
+	separator := "?"
+	if strings.Contains(c.url, "?") {
+		separator = "&"
+	}
+	url := fmt.Sprintf("%s%strustedclienttoken=%s&Sec-MS-GEC=%s&Sec-MS-GEC-Version=%s",
+		c.url, separator, trustedClientToken, secMSGEC(time.Now()), secMSGECVersion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求语音列表失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求语音列表失败: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取语音列表失败: %w", err)
+	}
+	return parseEdgeVoices(data)
+}
+
+// loadCache 读取本地缓存文件，更新时间取文件修改时间
+func (c *VoiceCatalog) loadCache() error {
+	info, err := os.Stat(c.cachePath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(c.cachePath)
+	if err != nil {
+		return err
+	}
+	voices, err := parseEdgeVoices(data)
+	if err != nil {
+		return err
+	}
+	c.set(voices, info.ModTime())
+	return nil
+}
+
+// loadSnapshot 读取内置快照
+func (c *VoiceCatalog) loadSnapshot() error {
+	voices, err := parseEdgeVoices(edgeVoicesSnapshot)
+	if err != nil {
+		return err
+	}
+	c.set(voices, time.Time{})
+	return nil
+}
+
+// saveCache 先写临时文件再重命名，避免写入中断时留下不完整的缓存
+func (c *VoiceCatalog) saveCache(voices []edgeVoice) error {
+	data, err := json.Marshal(voices)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.cachePath), 0755); err != nil {
+		return err
+	}
+	tmp := c.cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.cachePath)
+}
+
+func (c *VoiceCatalog) set(voices []edgeVoice, updatedAt time.Time) {
+	index := make(map[string]int, len(voices))
+	for i, v := range voices {
+		index[v.ShortName] = i
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.voices = voices
+	c.index = index
+	c.updatedAt = updatedAt
+}
+
+// parseEdgeVoices 解析语音列表，空列表视为错误，避免用空列表覆盖已有目录
+func parseEdgeVoices(data []byte) ([]edgeVoice, error) {
+	var voices []edgeVoice
+	if err := json.Unmarshal(data, &voices); err != nil {
+		return nil, fmt.Errorf("解析语音列表失败: %w", err)
+	}
+
+	valid := voices[:0]
+	for _, v := range voices {
+		if v.ShortName != "" && v.Locale != "" {
+			valid = append(valid, v)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, fmt.Errorf("语音列表为空")
+	}
+	return valid, nil
+}
+
+// toModel 转换为接口返回的语音信息
+func (v edgeVoice) toModel(formats []string) models.Voice {
+	return models.Voice{
+		Name:          v.ShortName,
+		Language:      v.Locale,
+		Gender:        strings.ToLower(v.Gender),
+		Description:   v.FriendlyName,
+		Engine:        EngineEdge,
+		Styles:        v.StyleList,
+		Roles:         v.RolePlayList,
+		Personalities: v.VoiceTag.VoicePersonalities,
+		Formats:       formats,
+	}
+}
+
+// VoiceFilter 语音列表筛选条件，为空的条件不参与筛选
+type VoiceFilter struct {
+	Locale string // 完整区域(zh-CN)或语言(zh)
+	Gender string
+	Style  string
+}
+
+// Match 判断语音是否满足筛选条件，比较时忽略大小写
+func (f VoiceFilter) Match(voice models.Voice) bool {
+	if f.Locale != "" && !strings.EqualFold(voice.Language, f.Locale) &&
+		!strings.HasPrefix(strings.ToLower(voice.Language), strings.ToLower(f.Locale)+"-") {
+		return false
+	}
+	if f.Gender != "" && !strings.EqualFold(voice.Gender, f.Gender) {
+		return false
+	}
+	if f.Style != "" {
+		style := f.Style
+		if !containsFold(voice.Styles, &style) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateVoice 请求的语音必须在已启用引擎的语音目录中，SSML中的语音已在validateSSML中校验
+func (s *TTSService) validateVoice(req *models.TTSRequest) error {
+	if req.SSML || len(req.Documents) > 0 {
+		return nil
+	}
+	voice, _, err := s.findVoice(req)
+	if err != nil {
+		return err
+	}
+	if voice == nil {
+		return &ParamError{Param: "voice", Value: req.Voice, Message: "未知的语音，可用语音见 /api/v1/voices"}
+	}
+	return nil
+}
+
+// findVoice 在引擎的语音目录中查找请求的语音，指定了引擎时只查找该引擎，找不到时返回nil
+func (s *TTSService) findVoice(req *models.TTSRequest) (*models.Voice, string, error) {
+	for _, engine := range s.engines.Engines() {
+		if req.Engine != "" && engine.Name() != req.Engine {
+			continue
+		}
+		voices, err := engine.ListVoices(context.Background())
+		if err != nil {
+			return nil, "", fmt.Errorf("获取%s语音列表失败: %w", engine.Name(), err)
+		}
+		for i := range voices {
+			if voices[i].Name == req.Voice {
+				return &voices[i], engine.Name(), nil
+			}
+		}
+	}
+	return nil, "", nil
+}
@@ -0,0 +1,230 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"tts-service/internal/audio"
+	"tts-service/internal/models"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 长文本分段默认参数
+const (
+	defaultChunkMaxChars    = 1000
+	defaultChunkConcurrency = 3
+)
+
+// SplitText 按句子和标点将长文本切分为不超过maxChars个字符的片段
+// 优先在句末标点（含中文。！？；）处切分，单句过长时退化到逗号等次级标点，最后按长度硬切
+func SplitText(text string, maxChars int) []string {
+	text = strings.TrimSpace(text)
+	if maxChars <= 0 || utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current []rune
+	flush := func() {
+		if chunk := strings.TrimSpace(string(current)); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current = nil
+	}
+
+	for _, sentence := range splitAt(text, isSentenceEnd) {
+		for _, piece := range splitLong(sentence, maxChars) {
+			if len(current)+len(piece) > maxChars {
+				flush()
+			}
+			current = append(current, piece...)
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// splitLong 将超长的句子按次级标点切分，仍然过长时按长度硬切
+func splitLong(sentence []rune, maxChars int) [][]rune {
+	if len(sentence) <= maxChars {
+		return [][]rune{sentence}
+	}
+
+	var pieces [][]rune
+	for _, clause := range splitAt(string(sentence), isClauseEnd) {
+		for len(clause) > maxChars {
+			cut := maxChars
+			// 尽量在空白处切开，避免截断英文单词
+			for i := maxChars; i > maxChars/2; i-- {
+				if unicode.IsSpace(clause[i]) {
+					cut = i
+					break
+				}
+			}
+			pieces = append(pieces, clause[:cut])
+			clause = clause[cut:]
+		}
+		pieces = append(pieces, clause)
+	}
+	return pieces
+}
+
+// splitAt 在满足isEnd的位置之后切分，紧随其后的右引号和括号归入前一段
+func splitAt(text string, isEnd func(runes []rune, i int) bool) [][]rune {
+	runes := []rune(text)
+	var parts [][]rune
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if !isEnd(runes, i) {
+			continue
+		}
+		for i+1 < len(runes) && strings.ContainsRune("\"'”’」』）)】》", runes[i+1]) {
+			i++
+		}
+		parts = append(parts, runes[start:i+1])
+		start = i + 1
+	}
+	if start < len(runes) {
+		parts = append(parts, runes[start:])
+	}
+	return parts
+}
+
+// isSentenceEnd 句末标点：中文。！？；…，英文 .!?; 后接空白，以及换行
+func isSentenceEnd(runes []rune, i int) bool {
+	switch runes[i] {
+	case '。', '！', '？', '；', '…', '\n':
+		return true
+	case '.', '!', '?', ';':
+		return i+1 == len(runes) || unicode.IsSpace(runes[i+1])
+	}
+	return false
+}
+
+// isClauseEnd 次级标点：逗号、顿号、冒号等
+func isClauseEnd(runes []rune, i int) bool {
+	switch runes[i] {
+	case '，', '、', '：', ',', ':', '—':
+		return true
+	}
+	return unicode.IsSpace(runes[i])
+}
+
+// chunkResult 单个片段的合成结果
+type chunkResult struct {
+	audio  []byte
+	result *SynthesisResult
+	err    error
+}
+
+// chunkMaxChars 长文本分段的最大字符数
+func (s *TTSService) chunkMaxChars() int {
+	if s.config.TTS.Chunk.MaxChars > 0 {
+		return s.config.TTS.Chunk.MaxChars
+	}
+	return defaultChunkMaxChars
+}
+
+// synthesizeChunked 长文本分段并发合成，按顺序拼接后写入w
+// 分段结果按原顺序依次交给拼接器，前面的片段完成后即可开始输出
+// 混合语言模式的请求按已生成的SSML文档分段，每段作为SSML请求合成
+func (s *TTSService) synthesizeChunked(ctx context.Context, engine Engine, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error) {
+	chunks, documents := req.Documents, len(req.Documents) > 0
+	if !documents {
+		chunks = SplitText(req.Text, s.chunkMaxChars())
+		if len(chunks) <= 1 || req.SSML {
+			return engine.Synthesize(ctx, req, w)
+		}
+	}
+	if len(chunks) == 1 {
+		chunkReq := *req
+		chunkReq.Text, chunkReq.SSML = chunks[0], true
+		return engine.Synthesize(ctx, &chunkReq, w)
+	}
+
+	concurrency := s.config.TTS.Chunk.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultChunkConcurrency
+	}
+
+	out := &trackingWriter{w: w}
+	joiner, err := audio.NewJoiner(req.Format, out)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan chunkResult, len(chunks))
+	for i := range results {
+		results[i] = make(chan chunkResult, 1)
+	}
+
+	// 限制同时进行的合成数量
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	sem := make(chan struct{}, concurrency)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, chunk := range chunks {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func(i int, chunk string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				chunkReq := *req
+				chunkReq.Text = chunk
+				chunkReq.SSML = req.SSML || documents
+				var buf bytes.Buffer
+				result, err := engine.Synthesize(ctx, &chunkReq, &buf)
+				results[i] <- chunkResult{audio: buf.Bytes(), result: result, err: err}
+			}(i, chunk)
+		}
+	}()
+
+	merged := &SynthesisResult{}
+	var offset int64
+	for i := range chunks {
+		var r chunkResult
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("第%d段合成失败: %w", i+1, r.err)
+		}
+
+		duration, err := joiner.Append(r.audio)
+		if err != nil {
+			return nil, fmt.Errorf("拼接音频失败: %w", err)
+		}
+
+		// 边界时间加上前面片段的总时长
+		for _, b := range r.result.Boundaries {
+			b.Offset += offset
+			merged.Boundaries = append(merged.Boundaries, b)
+		}
+		offset += duration.Milliseconds()
+	}
+
+	if err := joiner.Close(); err != nil {
+		return nil, fmt.Errorf("拼接音频失败: %w", err)
+	}
+
+	merged.Size = out.n
+	return merged, nil
+}
@@ -0,0 +1,46 @@
+package tts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		want     []string
+	}{
+		{name: "short", text: "  你好。 ", maxChars: 10, want: []string{"你好。"}},
+		{name: "no limit", text: "第一句。第二句。", maxChars: 0, want: []string{"第一句。第二句。"}},
+		{name: "sentences", text: "第一句话。第二句话。第三句话。", maxChars: 10, want: []string{"第一句话。第二句话。", "第三句话。"}},
+		{name: "english", text: "One two. Three four. Five six.", maxChars: 20, want: []string{"One two. Three four.", "Five six."}},
+		{name: "decimal is not a sentence end", text: "价格是3.5元。很便宜。", maxChars: 8, want: []string{"价格是3.5元。", "很便宜。"}},
+		{name: "closing quote stays", text: "他说：“走吧。”我们走了。", maxChars: 9, want: []string{"他说：“走吧。”", "我们走了。"}},
+		{name: "newline", text: "标题\n正文内容", maxChars: 4, want: []string{"标题", "正文内容"}},
+		{name: "clause fallback", text: "一二三四，五六七八，九十", maxChars: 6, want: []string{"一二三四，", "五六七八，", "九十"}},
+		{name: "hard cut", text: "一二三四五六七八九十", maxChars: 4, want: []string{"一二三四", "五六七八", "九十"}},
+		{name: "hard cut at space", text: "abcdef ghij", maxChars: 8, want: []string{"abcdef", "ghij"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitText(tt.text, tt.maxChars)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("SplitText(%q, %d) = %q, 期望 %q", tt.text, tt.maxChars, got, tt.want)
+			}
+			for _, chunk := range got {
+				if tt.maxChars > 0 && utf8.RuneCountInString(chunk) > tt.maxChars {
+					t.Errorf("片段 %q 超过 %d 个字符", chunk, tt.maxChars)
+				}
+			}
+			// 切分只去掉片段两端的空白，不丢失内容
+			if joined, text := strings.Join(strings.Fields(strings.Join(got, "")), ""), strings.Join(strings.Fields(tt.text), ""); joined != text {
+				t.Errorf("拼接结果 %q 与原文不一致", joined)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"tts-service/internal/storage"
+)
+
+const (
+	// cleanupBatchSize 每次从数据库取出的过期缓存记录数
+	cleanupBatchSize = 100
+	// orphanGracePeriod 音频文件重命名到位后才写入缓存记录，修改时间在此之内的文件不视为孤立文件
+	orphanGracePeriod = time.Hour
+
+	minCleanupInterval = 10 * time.Minute
+	maxCleanupInterval = 6 * time.Hour
+)
+
+// cacheObjectName 缓存的音频、边界元数据、字幕文件和写入中断遗留的临时文件都以缓存键的SHA-256开头
+// 存储目录或共享bucket中不符合这一命名的对象不属于缓存，孤立文件清理不会删除
+var cacheObjectName = regexp.MustCompile(`^[0-9a-f]{64}\.[0-9A-Za-z.]+$`)
+
+// CleanupReport 一次缓存清理的结果
+type CleanupReport struct {
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	ExpiredEntries int       `json:"expired_entries"` // 删除的过期缓存记录数，对应的文件一并删除
+	OrphanFiles    int       `json:"orphan_files"`    // 删除的没有缓存记录的文件数
+	FreedBytes     int64     `json:"freed_bytes"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// CleanupStats 服务启动以来的缓存清理统计
+type CleanupStats struct {
+	IntervalSeconds int64          `json:"interval_seconds"` // 定时清理间隔，0表示未启用
+	Runs            int64          `json:"runs"`
+	Failures        int64          `json:"failures"`
+	ExpiredEntries  int64          `json:"expired_entries"`
+	OrphanFiles     int64          `json:"orphan_files"`
+	FreedBytes      int64          `json:"freed_bytes"`
+	NextRunAt       *time.Time     `json:"next_run_at,omitempty"`
+	LastRun         *CleanupReport `json:"last_run,omitempty"`
+}
+
+// cleanupState 清理任务的互斥锁和统计
+type cleanupState struct {
+	running sync.Mutex // 定时清理和手动清理不同时执行
+	mu      sync.Mutex
+	stats   CleanupStats
+}
+
+// cleanupInterval 按 storage.cleanup_hours 的四分之一定时清理，限制在10分钟到6小时之间
+func cleanupInterval(hours int) time.Duration {
+	interval := time.Duration(hours) * time.Hour / 4
+	if interval < minCleanupInterval {
+		interval = minCleanupInterval
+	}
+	if interval > maxCleanupInterval {
+		interval = maxCleanupInterval
+	}
+	return interval
+}
+
+// startCleanup 启动定时清理，storage.cleanup_hours 未配置时不启用；启动后立即执行一次
+func (s *TTSService) startCleanup(ctx context.Context) {
+	hours := s.config.Storage.CleanupHours
+	if hours <= 0 {
+		return
+	}
+	interval := cleanupInterval(hours)
+
+	s.cleanup.mu.Lock()
+	s.cleanup.stats.IntervalSeconds = int64(interval / time.Second)
+	s.cleanup.mu.Unlock()
+
+	go func() {
+		delay := time.Duration(0)
+		for {
+			next := time.Now().Add(delay)
+			s.cleanup.mu.Lock()
+			s.cleanup.stats.NextRunAt = &next
+			s.cleanup.mu.Unlock()
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			delay = interval
+			s.CleanupExpiredCache()
+		}
+	}()
+}
+
+// CleanupStats 获取缓存清理统计
+func (s *TTSService) CleanupStats() CleanupStats {
+	s.cleanup.mu.Lock()
+	defer s.cleanup.mu.Unlock()
+	return s.cleanup.stats
+}
+
+// CleanupExpiredCache 清理超过 storage.cleanup_hours 未访问的缓存和孤立文件
+// 过期缓存的音频、边界元数据、字幕文件、SQLite记录和Redis键一起删除，固定的缓存不清理；
+// 存储中没有缓存记录的对象视为孤立文件，有声书文件不处理
+func (s *TTSService) CleanupExpiredCache() (*CleanupReport, error) {
+	s.cleanup.running.Lock()
+	defer s.cleanup.running.Unlock()
+
+	ctx := context.Background()
+	report := &CleanupReport{StartedAt: time.Now()}
+	err := s.cleanupExpired(ctx, report)
+	if err == nil {
+		err = s.cleanupOrphans(ctx, report)
+	}
+	report.FinishedAt = time.Now()
+	s.releaseStorage(report.FreedBytes)
+
+	if err != nil {
+		report.Error = err.Error()
+		fmt.Printf("缓存清理失败: %v\n", err)
+	}
+	fmt.Printf("清理了 %d 条过期缓存、%d 个孤立文件，释放 %d 字节\n", report.ExpiredEntries, report.OrphanFiles, report.FreedBytes)
+
+	s.cleanup.mu.Lock()
+	stats := &s.cleanup.stats
+	stats.Runs++
+	if err != nil {
+		stats.Failures++
+	}
+	stats.ExpiredEntries += int64(report.ExpiredEntries)
+	stats.OrphanFiles += int64(report.OrphanFiles)
+	stats.FreedBytes += report.FreedBytes
+	stats.LastRun = report
+	s.cleanup.mu.Unlock()
+
+	return report, err
+}
+
+// cleanupExpired 删除过期的缓存记录及其文件和Redis键
+func (s *TTSService) cleanupExpired(ctx context.Context, report *CleanupReport) error {
+	hours := s.config.Storage.CleanupHours
+	if hours <= 0 {
+		return nil
+	}
+
+	for {
+		caches, err := s.db.ListExpiredCache(hours, cleanupBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(caches) == 0 {
+			return nil
+		}
+
+		for _, cache := range caches {
+			freed, err := s.evictCache(ctx, cache)
+			report.FreedBytes += freed
+			if err != nil {
+				return err
+			}
+			report.ExpiredEntries++
+		}
+	}
+}
+
+// cleanupOrphans 删除存储中没有缓存记录的音频、边界元数据和字幕文件，以及中断写入遗留的临时文件
+// 只处理符合缓存命名的对象，有声书文件直接跳过，其他对象(如bucket中其他程序的对象)跳过并记录日志
+func (s *TTSService) cleanupOrphans(ctx context.Context, report *CleanupReport) error {
+	hashes, err := s.db.ListCacheHashes()
+	if err != nil {
+		return err
+	}
+
+	objects, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	skipped, example := 0, ""
+	defer func() {
+		if skipped > 0 {
+			fmt.Printf("孤立文件清理跳过了 %d 个不属于缓存的对象，如 %s\n", skipped, example)
+		}
+	}()
+
+	cutoff := time.Now().Add(-orphanGracePeriod)
+	for _, obj := range objects {
+		if strings.HasPrefix(obj.Key, storage.AudiobookPrefix) {
+			continue
+		}
+		if !cacheObjectName.MatchString(obj.Key) {
+			if skipped == 0 {
+				example = obj.Key
+			}
+			skipped++
+			continue
+		}
+		if obj.ModTime.After(cutoff) {
+			continue
+		}
+
+		hash := cacheHashFromFilename(obj.Key)
+		if hashes[hash] && !strings.HasSuffix(obj.Key, ".tmp") {
+			continue
+		}
+
+		if err := s.store.Delete(ctx, obj.Key); err != nil {
+			return fmt.Errorf("删除孤立文件失败: %w", err)
+		}
+		if s.redis != nil && !hashes[hash] {
+			s.redis.Delete(redisCacheKey(hash))
+		}
+		report.OrphanFiles++
+		report.FreedBytes += obj.Size
+	}
+	return nil
+}
@@ -1,39 +1,112 @@
 package tts
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"tts-service/internal/config"
-	"tts-service/internal/utils"
+	"tts-service/internal/lexicon"
+	"tts-service/internal/models"
+	"tts-service/internal/ssml"
+)
+
+// SynthesisResult 语音合成结果，音频数据已写入调用方提供的io.Writer
+type SynthesisResult struct {
+	Size       int64
+	Boundaries []models.Boundary
+}
+
+// edgeMetadata Edge TTS audio.metadata 消息体
+type edgeMetadata struct {
+	Metadata []struct {
+		Type string `json:"Type"`
+		Data struct {
+			Offset   int64 `json:"Offset"`
+			Duration int64 `json:"Duration"`
+			Text     struct {
+				Text string `json:"Text"`
+			} `json:"text"`
+		} `json:"Data"`
+	} `json:"Metadata"`
+}
+
+// Edge TTS 鉴权参数
+const (
+	trustedClientToken = "6A5AA1D4EAFF4E9FB37E23D68491D6F4"
+	secMSGECVersion    = "1-131.0.2903.99"
 )
 
 // EdgeTTSClient Edge TTS WebSocket客户端
 type EdgeTTSClient struct {
-	config *config.EdgeTTSConfig
+	config  *config.EdgeTTSConfig
+	catalog *VoiceCatalog
 }
 
 // NewEdgeTTSClient 创建新的Edge TTS客户端
-func NewEdgeTTSClient(cfg *config.EdgeTTSConfig) *EdgeTTSClient {
+func NewEdgeTTSClient(cfg *config.EdgeTTSConfig, catalog *VoiceCatalog) *EdgeTTSClient {
 	return &EdgeTTSClient{
-		config: cfg,
+		config:  cfg,
+		catalog: catalog,
+	}
+}
+
+// Start 启动语音目录的定时刷新
+func (c *EdgeTTSClient) Start(ctx context.Context) {
+	c.catalog.Start(ctx)
+}
+
+// Name 引擎名称
+func (c *EdgeTTSClient) Name() string {
+	return EngineEdge
+}
+
+// SupportedFormats 支持的音频格式
+func (c *EdgeTTSClient) SupportedFormats() []string {
+	return []string{"mp3", "wav", "ogg"}
+}
+
+// Capabilities 引擎能力
+func (c *EdgeTTSClient) Capabilities() Capabilities {
+	return Capabilities{
+		Streaming:  true,
+		Boundaries: true,
+		SSML:       true,
+		Online:     true,
+		Contour:    true,
+		Prosody: ProsodyRange{
+			Rate:   [2]float64{0.5, 2},
+			Pitch:  [2]float64{0.5, 1.5},
+			Volume: [2]float64{0, 2},
+		},
 	}
 }
 
+// ListVoices 获取可用语音列表，来自语音目录
+func (c *EdgeTTSClient) ListVoices(ctx context.Context) ([]models.Voice, error) {
+	return c.catalog.Voices(c.SupportedFormats()), nil
+}
+
 // generateURL 生成动态WebSocket URL
 func (c *EdgeTTSClient) generateURL() (string, error) {
 	connectionID := strings.ReplaceAll(uuid.New().String(), "-", "")
-	trustedClientToken := "6A5AA1D4EAFF4E9FB37E23D68491D6F4"
-	secMSGECVersion := "1-131.0.2903.99"
 
-	// 生成Sec-MS-GEC
+	url := fmt.Sprintf("wss://speech.platform.bing.com/consumer/speech/synthesize/readaloud/edge/v1?TrustedClientToken=%s&Sec-MS-GEC=%s&Sec-MS-GEC-Version=%s&ConnectionId=%s",
+		trustedClientToken, secMSGEC(time.Now()), secMSGECVersion, connectionID)
+
+	return url, nil
+}
+
+// secMSGEC 生成Sec-MS-GEC，WebSocket连接和语音列表请求都需要
+func secMSGEC(now time.Time) string {
 	winEpoch := int64(11644473600) // 从Windows纪元到Unix纪元的偏移量(秒)
-	currentTimestamp := time.Now().Unix()
-	adjustedSeconds := currentTimestamp + winEpoch
+	adjustedSeconds := now.Unix() + winEpoch
 	adjustedSeconds -= adjustedSeconds % 300 // 调整到最近5分钟边界
 
 	// 转换为Windows文件时间(100纳秒单位)
@@ -42,16 +115,11 @@ func (c *EdgeTTSClient) generateURL() (string, error) {
 	// 计算SHA-256
 	hashInput := fmt.Sprintf("%d%s", winFileTime, trustedClientToken)
 	hash := sha256.Sum256([]byte(hashInput))
-	secMSGEC := fmt.Sprintf("%X", hash)
-
-	url := fmt.Sprintf("wss://speech.platform.bing.com/consumer/speech/synthesize/readaloud/edge/v1?TrustedClientToken=%s&Sec-MS-GEC=%s&Sec-MS-GEC-Version=%s&ConnectionId=%s",
-		trustedClientToken, secMSGEC, secMSGECVersion, connectionID)
-
-	return url, nil
+	return fmt.Sprintf("%X", hash)
 }
 
-// Synthesize 执行语音合成
-func (c *EdgeTTSClient) Synthesize(text, voice, format string, speed float64, pitch int) ([]byte, error) {
+// Synthesize 执行语音合成，每收到一帧音频立即写入w
+func (c *EdgeTTSClient) Synthesize(ctx context.Context, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error) {
 	// 建立WebSocket连接
 	conn, err := c.connect()
 	if err != nil {
@@ -59,27 +127,63 @@ func (c *EdgeTTSClient) Synthesize(text, voice, format string, speed float64, pi
 	}
 	defer conn.Close()
 
+	// 调用方取消时关闭连接，中断阻塞的读取
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
 	// 生成请求ID
 	requestID := strings.ReplaceAll(uuid.New().String(), "-", "")
 
 	// 发送配置消息
-	if err := c.sendConfig(conn, requestID, format); err != nil {
+	if err := c.sendConfig(conn, requestID, req); err != nil {
 		return nil, fmt.Errorf("发送配置失败: %w", err)
 	}
 
-	// 发送SSML文本
-	ssml := utils.GenerateSSML(text, voice, speed, pitch)
-	if err := c.sendSSML(conn, requestID, ssml); err != nil {
+	// 发送SSML文本，用户提供的SSML已在NormalizeRequest中校验，原样转发
+	// 纯文本经XML编码器转义，词典中的词语改写为 <sub>/<phoneme>
+	document := req.Text
+	if !req.SSML {
+		var err error
+		document, err = ssml.Build(ssml.Speech{
+			Voice: req.Voice,
+			Prosody: ssml.Prosody{
+				Rate:    req.Rate,
+				Pitch:   string(req.Pitch),
+				Volume:  string(req.Volume),
+				Contour: contourString(req.Contour),
+			},
+			Style:       req.Style,
+			StyleDegree: req.StyleDegree,
+			Role:        req.Role,
+			Body: func(b *ssml.Builder) {
+				lexicon.Write(b, req.Text, req.Lexicon)
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := c.sendSSML(conn, requestID, document); err != nil {
 		return nil, fmt.Errorf("发送SSML失败: %w", err)
 	}
 
 	// 接收音频数据
-	audioData, err := c.receiveAudio(conn, requestID)
+	result, err := c.receiveAudio(conn, requestID, w)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, fmt.Errorf("接收音频数据失败: %w", err)
 	}
 
-	return audioData, nil
+	return result, nil
 }
 
 // connect 建立WebSocket连接
@@ -102,7 +206,7 @@ func (c *EdgeTTSClient) connect() (*websocket.Conn, error) {
 }
 
 // sendConfig 发送音频配置
-func (c *EdgeTTSClient) sendConfig(conn *websocket.Conn, requestID, format string) error {
+func (c *EdgeTTSClient) sendConfig(conn *websocket.Conn, requestID string, req *models.TTSRequest) error {
 	// 音频格式映射
 	formatMap := map[string]string{
 		"mp3": "audio-24khz-48kbitrate-mono-mp3",
@@ -110,13 +214,14 @@ func (c *EdgeTTSClient) sendConfig(conn *websocket.Conn, requestID, format strin
 		"ogg": "ogg-24khz-16bit-mono-opus",
 	}
 
-	audioFormat, exists := formatMap[format]
+	// 其他格式由调用方合成WAV后转码，不能静默退回MP3
+	audioFormat, exists := formatMap[req.Format]
 	if !exists {
-		audioFormat = "audio-24khz-48kbitrate-mono-mp3" // 默认MP3
+		return fmt.Errorf("Edge TTS不支持的音频格式: %s", req.Format)
 	}
 
-	config := fmt.Sprintf("X-Timestamp:%s\r\nContent-Type:application/json; charset=utf-8\r\nPath:speech.config\r\n\r\n{\"context\":{\"synthesis\":{\"audio\":{\"metadataoptions\":{\"sentenceBoundaryEnabled\":\"false\",\"wordBoundaryEnabled\":\"false\"},\"outputFormat\":\"%s\"}}}}",
-		time.Now().Format("Mon Jan 02 2006 15:04:05 GMT-0700 (MST)"), audioFormat)
+	config := fmt.Sprintf("X-Timestamp:%s\r\nContent-Type:application/json; charset=utf-8\r\nPath:speech.config\r\n\r\n{\"context\":{\"synthesis\":{\"audio\":{\"metadataoptions\":{\"sentenceBoundaryEnabled\":\"%t\",\"wordBoundaryEnabled\":\"%t\"},\"outputFormat\":\"%s\"}}}}",
+		time.Now().Format("Mon Jan 02 2006 15:04:05 GMT-0700 (MST)"), req.SentenceBoundary, req.WordBoundary, audioFormat)
 
 	return conn.WriteMessage(websocket.TextMessage, []byte(config))
 }
@@ -129,14 +234,14 @@ func (c *EdgeTTSClient) sendSSML(conn *websocket.Conn, requestID, ssml string) e
 	return conn.WriteMessage(websocket.TextMessage, []byte(message))
 }
 
-// receiveAudio 接收音频数据  
-func (c *EdgeTTSClient) receiveAudio(conn *websocket.Conn, requestID string) ([]byte, error) {
-	audioChunks := [][]byte{}
-	
-	// 设置读取超时
-	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+// receiveAudio 接收音频数据
+func (c *EdgeTTSClient) receiveAudio(conn *websocket.Conn, requestID string, w io.Writer) (*SynthesisResult, error) {
+	var size int64
+	boundaries := []models.Boundary{}
 
 	for {
+		// 每条消息单独设置读取超时，长文本合成只要持续有数据就不会超时
+		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
 		messageType, message, err := conn.ReadMessage()
 		if err != nil {
 			return nil, err
@@ -148,12 +253,22 @@ func (c *EdgeTTSClient) receiveAudio(conn *websocket.Conn, requestID string) ([]
 			if strings.Contains(messageStr, "Path:turn.start") {
 				// 开始接收音频
 				continue
+			} else if strings.Contains(messageStr, "Path:audio.metadata") {
+				// 词/句边界元数据
+				events, err := c.parseMetadata(messageStr)
+				if err != nil {
+					return nil, fmt.Errorf("解析边界元数据失败: %w", err)
+				}
+				boundaries = append(boundaries, events...)
 			} else if strings.Contains(messageStr, "Path:turn.end") {
 				// 音频接收完成
-				if len(audioChunks) == 0 {
+				if size == 0 {
 					return nil, fmt.Errorf("未收到音频数据")
 				}
-				return c.concatenateAudio(audioChunks), nil
+				return &SynthesisResult{
+					Size:       size,
+					Boundaries: boundaries,
+				}, nil
 			}
 
 		case websocket.BinaryMessage:
@@ -162,28 +277,45 @@ func (c *EdgeTTSClient) receiveAudio(conn *websocket.Conn, requestID string) ([]
 			if idx := c.indexOf(message, audioSeparator); idx >= 0 {
 				audioData := message[idx+len(audioSeparator):]
 				if len(audioData) > 0 {
-					audioChunks = append(audioChunks, audioData)
+					n, err := w.Write(audioData)
+					size += int64(n)
+					if err != nil {
+						return nil, fmt.Errorf("写入音频数据失败: %w", err)
+					}
 				}
 			}
 		}
 	}
 }
 
-// concatenateAudio 合并音频数据
-func (c *EdgeTTSClient) concatenateAudio(chunks [][]byte) []byte {
-	totalLength := 0
-	for _, chunk := range chunks {
-		totalLength += len(chunk)
+// parseMetadata 解析audio.metadata消息中的边界事件
+func (c *EdgeTTSClient) parseMetadata(message string) ([]models.Boundary, error) {
+	idx := strings.Index(message, "\r\n\r\n")
+	if idx < 0 {
+		return nil, fmt.Errorf("消息缺少消息体")
 	}
 
-	result := make([]byte, totalLength)
-	offset := 0
-	for _, chunk := range chunks {
-		copy(result[offset:], chunk)
-		offset += len(chunk)
+	var meta edgeMetadata
+	if err := json.Unmarshal([]byte(message[idx+4:]), &meta); err != nil {
+		return nil, err
 	}
 
-	return result
+	events := make([]models.Boundary, 0, len(meta.Metadata))
+	for _, item := range meta.Metadata {
+		if item.Type != models.WordBoundary && item.Type != models.SentenceBoundary {
+			// 忽略SessionEnd等其他元数据
+			continue
+		}
+		// Edge返回的时间单位为100纳秒
+		events = append(events, models.Boundary{
+			Type:     item.Type,
+			Offset:   item.Data.Offset / 10000,
+			Duration: item.Data.Duration / 10000,
+			Text:     item.Data.Text.Text,
+		})
+	}
+
+	return events, nil
 }
 
 // indexOf 查找字节序列位置
@@ -198,4 +330,4 @@ func (c *EdgeTTSClient) indexOf(data, separator []byte) int {
 		}
 	}
 	return -1
-}
\ No newline at end of file
+}
@@ -0,0 +1,236 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"tts-service/internal/audio"
+	"tts-service/internal/config"
+	"tts-service/internal/models"
+)
+
+// Engine TTS引擎接口
+type Engine interface {
+	// Name 引擎名称，对应配置中 tts.engines 的名称
+	Name() string
+	// Synthesize 执行语音合成，音频数据写入w
+	Synthesize(ctx context.Context, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error)
+	// ListVoices 获取引擎支持的语音列表
+	ListVoices(ctx context.Context) ([]models.Voice, error)
+	// SupportedFormats 引擎支持的音频格式
+	SupportedFormats() []string
+	// Capabilities 引擎能力描述
+	Capabilities() Capabilities
+}
+
+// Starter 需要后台任务的引擎，例如定时刷新在线语音目录
+type Starter interface {
+	Start(ctx context.Context)
+}
+
+// Capabilities 引擎能力
+type Capabilities struct {
+	Streaming  bool         `json:"streaming"`  // 是否边合成边输出
+	Boundaries bool         `json:"boundaries"` // 是否支持词/句边界元数据
+	SSML       bool         `json:"ssml"`       // 是否支持SSML
+	Online     bool         `json:"online"`     // 是否依赖外部服务
+	Contour    bool         `json:"contour"`    // 是否支持音调曲线
+	Prosody    ProsodyRange `json:"prosody"`    // 语速、音调、音量的范围
+}
+
+// 内置引擎名称
+const (
+	EngineEdge    = "edge"
+	EngineOffline = "offline"
+)
+
+// EngineRegistry 引擎注册表，按配置顺序保存
+type EngineRegistry struct {
+	engines  map[string]Engine
+	breakers map[string]*CircuitBreaker
+	order    []string
+	breaker  config.BreakerConfig
+}
+
+// NewEngineRegistry 根据配置创建引擎注册表
+func NewEngineRegistry(cfg *config.Config) *EngineRegistry {
+	registry := &EngineRegistry{
+		engines:  make(map[string]Engine),
+		breakers: make(map[string]*CircuitBreaker),
+		breaker:  cfg.TTS.Breaker,
+	}
+
+	names := cfg.TTS.Engines
+	if len(names) == 0 {
+		names = []string{EngineEdge}
+	}
+
+	for _, name := range names {
+		switch name {
+		case EngineEdge:
+			registry.Register(NewEdgeTTSClient(&cfg.EdgeTTS, NewVoiceCatalog(cfg)))
+		case EngineOffline:
+			registry.Register(NewOfflineEngine())
+		default:
+			fmt.Printf("未知的TTS引擎，已忽略: %s\n", name)
+		}
+	}
+
+	return registry
+}
+
+// Register 注册引擎，同名引擎会被替换
+func (r *EngineRegistry) Register(engine Engine) {
+	name := engine.Name()
+	if _, exists := r.engines[name]; !exists {
+		r.order = append(r.order, name)
+	}
+	r.engines[name] = engine
+	r.breakers[name] = NewCircuitBreaker(name,
+		r.breaker.FailureThreshold,
+		time.Duration(r.breaker.CooldownSeconds)*time.Second,
+		r.breaker.HalfOpenRequests)
+}
+
+// Get 按名称获取引擎
+func (r *EngineRegistry) Get(name string) (Engine, bool) {
+	engine, ok := r.engines[name]
+	return engine, ok
+}
+
+// Engines 按配置顺序返回所有引擎
+func (r *EngineRegistry) Engines() []Engine {
+	engines := make([]Engine, 0, len(r.order))
+	for _, name := range r.order {
+		engines = append(engines, r.engines[name])
+	}
+	return engines
+}
+
+// Breaker 获取引擎的熔断器
+func (r *EngineRegistry) Breaker(name string) *CircuitBreaker {
+	return r.breakers[name]
+}
+
+// Candidates 为请求选择候选引擎
+// 请求指定了engine或使用带引擎前缀的语音（如 offline-tone、offline:tone）时只使用该引擎，
+// 否则按配置顺序返回所有支持该格式的引擎，用于故障转移
+func (r *EngineRegistry) Candidates(req *models.TTSRequest) ([]Engine, error) {
+	if req.Engine == "" {
+		for _, name := range r.order {
+			if strings.HasPrefix(req.Voice, name+":") || strings.HasPrefix(req.Voice, name+"-") {
+				req.Engine = name
+				break
+			}
+		}
+	}
+
+	if req.Engine != "" {
+		engine, ok := r.engines[req.Engine]
+		if !ok {
+			return nil, &ParamError{Param: "engine", Value: req.Engine, Message: "TTS引擎不存在或未启用", Supported: r.order}
+		}
+		req.Voice = strings.TrimPrefix(req.Voice, req.Engine+":")
+		if !supportsFormat(engine, req.Format) {
+			return nil, &ParamError{Param: "format", Value: req.Format, Message: "引擎 " + engine.Name() + " 不支持该音频格式", Supported: EngineFormats(engine)}
+		}
+		if needsSSML(req) && !engine.Capabilities().SSML {
+			return nil, &ParamError{Param: "engine", Value: engine.Name(), Message: "引擎不支持SSML"}
+		}
+		if err := checkProsody(engine, req); err != nil {
+			return nil, err
+		}
+		return []Engine{engine}, nil
+	}
+
+	var candidates []Engine
+	var prosodyErr error
+	for _, name := range r.order {
+		if needsSSML(req) && !r.engines[name].Capabilities().SSML {
+			continue
+		}
+		if !supportsFormat(r.engines[name], req.Format) {
+			continue
+		}
+		// 韵律参数超出范围的引擎不参与故障转移
+		if err := checkProsody(r.engines[name], req); err != nil {
+			if prosodyErr == nil {
+				prosodyErr = err
+			}
+			continue
+		}
+		candidates = append(candidates, r.engines[name])
+	}
+	if len(candidates) == 0 && prosodyErr != nil {
+		return nil, prosodyErr
+	}
+	if len(candidates) == 0 {
+		return nil, &ParamError{Param: "format", Value: req.Format, Message: "没有支持该音频格式的TTS引擎"}
+	}
+	return candidates, nil
+}
+
+// RetryAfter 候选引擎中最早恢复的熔断器剩余冷却时间，没有熔断中的引擎时返回0
+func (r *EngineRegistry) RetryAfter(engines []Engine) time.Duration {
+	var retryAfter time.Duration
+	for _, engine := range engines {
+		status := r.breakers[engine.Name()].Status()
+		if status.State != BreakerOpen {
+			continue
+		}
+		wait := time.Duration(status.RetryAfterSeconds) * time.Second
+		if wait <= 0 {
+			wait = time.Second
+		}
+		retryAfter = shorterWait(retryAfter, wait)
+	}
+	return retryAfter
+}
+
+// shorterWait 返回两个等待时间中较短的一个，0表示没有等待
+func shorterWait(a, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// needsSSML 用户提供的SSML和混合语言模式生成的文档都只能交给支持SSML的引擎
+func needsSSML(req *models.TTSRequest) bool {
+	return req.SSML || len(req.Documents) > 0
+}
+
+// supportsFormat 检查引擎是否支持指定格式，能输出WAV的引擎还支持可由WAV转码得到的格式
+func supportsFormat(engine Engine, format string) bool {
+	return sourceFormat(engine, format) != ""
+}
+
+// sourceFormat 合成指定格式时向引擎请求的格式，引擎不直接支持时为wav，无法支持时返回空字符串
+func sourceFormat(engine Engine, format string) string {
+	hasWAV := false
+	for _, f := range engine.SupportedFormats() {
+		if f == format {
+			return format
+		}
+		if f == "wav" {
+			hasWAV = true
+		}
+	}
+	if hasWAV && audio.CanTranscode(format) {
+		return "wav"
+	}
+	return ""
+}
+
+// EngineFormats 引擎可以输出的所有格式，包括由WAV转码得到的格式
+func EngineFormats(engine Engine) []string {
+	formats := append([]string{}, engine.SupportedFormats()...)
+	for _, format := range []string{"pcm", "flac", "aac"} {
+		if sourceFormat(engine, format) == "wav" {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
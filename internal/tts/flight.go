@@ -0,0 +1,181 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"tts-service/internal/models"
+	"tts-service/internal/utils"
+)
+
+// synthesisLockTTL Redis合成锁的过期时间，持有期间每过三分之一续期一次，实例崩溃后锁自动失效
+// 定义为变量以便测试缩短续期间隔
+var synthesisLockTTL = 30 * time.Second
+
+// synthesisLockPoll 其他实例持有合成锁时重新查询缓存和尝试加锁的间隔
+const synthesisLockPoll = 200 * time.Millisecond
+
+// flight 进程内一次进行中的合成
+type flight struct {
+	done chan struct{}
+	err  error // 合成失败的原因，为nil时等待的请求重新查询缓存
+}
+
+// flightGroup 按缓存键合并进程内相同的合成请求
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// join 加入textHash的合成，没有进行中的合成时由调用方负责合成，返回true
+func (g *flightGroup) join(textHash string) (*flight, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if f, ok := g.flights[textHash]; ok {
+		return f, false
+	}
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	f := &flight{done: make(chan struct{})}
+	g.flights[textHash] = f
+	return f, true
+}
+
+// finish 结束textHash的合成并唤醒等待的请求
+func (g *flightGroup) finish(textHash string, f *flight, err error) {
+	g.mu.Lock()
+	delete(g.flights, textHash)
+	g.mu.Unlock()
+
+	f.err = err
+	close(f.done)
+}
+
+// flightError 等待的相同请求合成失败，引擎的熔断计数已由合成的请求记录
+type flightError struct {
+	Err error
+}
+
+func (e *flightError) Error() string {
+	return fmt.Sprintf("相同请求合成失败: %v", e.Err)
+}
+
+func (e *flightError) Unwrap() error {
+	return e.Err
+}
+
+// synthesisLock 持有期间其他相同的请求等待合成结果，而不是重复调用引擎
+type synthesisLock struct {
+	s        *TTSService
+	textHash string
+	flight   *flight
+	token    string        // Redis锁的值，为空时没有持有Redis锁
+	stop     chan struct{} // 停止续期
+}
+
+// redisLockKey Redis中的合成锁
+func redisLockKey(textHash string) string {
+	return fmt.Sprintf("tts:lock:v%d:%s", CacheKeyVersion, textHash)
+}
+
+// lockSynthesis 获取textHash的合成锁，先在进程内合并，配置了Redis时再用分布式锁在实例之间合并
+// 其他请求正在合成时等待其结束后重新查询缓存，命中时返回缓存的对象键和响应数据；
+// 对方合成失败时返回flightError，对方因客户端断开等自身原因放弃时由当前请求继续尝试
+func (s *TTSService) lockSynthesis(ctx context.Context, req *models.TTSRequest, textHash, cacheKey string) (*synthesisLock, string, *models.TTSData, error) {
+	for {
+		f, leader := s.flights.join(textHash)
+		if !leader {
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return nil, "", nil, ctx.Err()
+			}
+			if f.err != nil {
+				return nil, "", nil, &flightError{Err: f.err}
+			}
+			// 对方没有写入缓存，或缓存中没有当前请求需要的边界元数据，由当前请求合成
+			if key, data, ok := s.lookupCache(ctx, req, textHash, cacheKey); ok {
+				return nil, key, data, nil
+			}
+			continue
+		}
+
+		lock := &synthesisLock{s: s, textHash: textHash, flight: f}
+		key, data, ok, err := s.lockRedis(ctx, lock, req, cacheKey)
+		if err != nil || ok {
+			lock.release(nil)
+			return nil, key, data, err
+		}
+		return lock, "", nil, nil
+	}
+}
+
+// lockRedis 获取Redis合成锁，其他实例持有锁时轮询缓存，直到缓存可用或锁被释放
+// 加锁前后都会重新查询缓存，避免前一个请求刚写入缓存时重复合成；Redis不可用时只在进程内合并
+func (s *TTSService) lockRedis(ctx context.Context, lock *synthesisLock, req *models.TTSRequest, cacheKey string) (string, *models.TTSData, bool, error) {
+	lockKey := redisLockKey(lock.textHash)
+	token := utils.GenerateRequestID()
+	for {
+		if key, data, ok := s.lookupCache(ctx, req, lock.textHash, cacheKey); ok {
+			return key, data, true, nil
+		}
+		if s.redis == nil {
+			return "", nil, false, nil
+		}
+
+		acquired, err := s.redis.SetNX(lockKey, token, synthesisLockTTL)
+		if err != nil {
+			fmt.Printf("获取合成锁失败，只在进程内合并相同请求: %v\n", err)
+			return "", nil, false, nil
+		}
+		if acquired {
+			lock.token = token
+			lock.stop = make(chan struct{})
+			go lock.renew(lockKey)
+			return "", nil, false, nil
+		}
+
+		// 其他实例正在合成
+		timer := time.NewTimer(synthesisLockPoll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", nil, false, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// renew 定时续期Redis合成锁，直到释放
+func (l *synthesisLock) renew(lockKey string) {
+	ticker := time.NewTicker(synthesisLockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ok, err := l.s.redis.ExpireIfValue(lockKey, l.token, synthesisLockTTL)
+			if err != nil {
+				fmt.Printf("合成锁续期失败: %v\n", err)
+			} else if !ok {
+				fmt.Printf("合成锁已失效: %s\n", l.textHash)
+				return
+			}
+		}
+	}
+}
+
+// release 释放合成锁，err不为nil时等待的请求直接返回该错误，否则重新查询缓存
+func (l *synthesisLock) release(err error) {
+	if l.token != "" {
+		close(l.stop)
+		if _, err := l.s.redis.DeleteIfValue(redisLockKey(l.textHash), l.token); err != nil {
+			fmt.Printf("释放合成锁失败: %v\n", err)
+		}
+	}
+	l.s.flights.finish(l.textHash, l.flight, err)
+}
@@ -0,0 +1,368 @@
+package tts
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"tts-service/internal/cache"
+	"tts-service/internal/config"
+	"tts-service/internal/models"
+)
+
+func TestFlightGroup(t *testing.T) {
+	var g flightGroup
+	errEngine := errors.New("引擎故障")
+
+	f, leader := g.join("a")
+	if !leader {
+		t.Fatal("第一个请求应负责合成")
+	}
+	if other, leader := g.join("b"); !leader || other == f {
+		t.Fatal("不同的缓存键不应合并")
+	}
+	same, leader := g.join("a")
+	if leader || same != f {
+		t.Fatal("相同的缓存键应等待进行中的合成")
+	}
+
+	g.finish("a", f, errEngine)
+	select {
+	case <-same.done:
+	default:
+		t.Fatal("合成结束后应唤醒等待的请求")
+	}
+	if same.err != errEngine {
+		t.Fatalf("等待的请求得到 %v, 期望 %v", same.err, errEngine)
+	}
+	if next, leader := g.join("a"); !leader || next == f {
+		t.Fatal("合成结束后相同的请求应重新合成")
+	}
+}
+
+func TestProcessDedupe(t *testing.T) {
+	release := make(chan struct{})
+	engine := &fakeEngine{
+		name: "a",
+		synthesize: func(ctx context.Context, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error) {
+			<-release
+			return writeSilence(w)
+		},
+	}
+	s := newTestService(t, config.BreakerConfig{}, engine)
+
+	const n = 5
+	results := make([]*models.TTSData, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = s.process(context.Background(), &models.TTSRequest{Text: "你好", Voice: testVoice, Format: "wav"}, nil)
+		}(i)
+	}
+	// 晚到的请求会直接命中缓存，无论是否赶上合并引擎都只调用一次
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := engine.callCount(); got != 1 {
+		t.Fatalf("引擎调用 %d 次, 期望 1 次", got)
+	}
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("请求 %d 失败: %v", i, errs[i])
+		}
+		if results[i].AudioURL != results[0].AudioURL {
+			t.Fatalf("请求 %d 的音频 %s 与 %s 不同", i, results[i].AudioURL, results[0].AudioURL)
+		}
+	}
+}
+
+func TestLockSynthesisWaiterError(t *testing.T) {
+	s := newTestService(t, config.BreakerConfig{}, &fakeEngine{name: "a"})
+	req := &models.TTSRequest{Text: "你好", Voice: testVoice, Format: "wav"}
+	ctx := context.Background()
+
+	lock, _, _, err := s.lockSynthesis(ctx, req, "hash", redisCacheKey("hash"))
+	if err != nil || lock == nil {
+		t.Fatalf("lockSynthesis = %v, %v, 期望获得锁", lock, err)
+	}
+
+	// 等待中的请求在ctx结束时返回
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, _, err := s.lockSynthesis(timeout, req, "hash", redisCacheKey("hash")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, 期望 context.DeadlineExceeded", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, _, err := s.lockSynthesis(ctx, req, "hash", redisCacheKey("hash"))
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	errEngine := errors.New("引擎故障")
+	lock.release(errEngine)
+
+	var flightErr *flightError
+	if err := <-done; !errors.As(err, &flightErr) || !errors.Is(err, errEngine) {
+		t.Fatalf("err = %v, 期望包装引擎错误的 flightError", err)
+	}
+}
+
+func TestSynthesisLockRedis(t *testing.T) {
+	ttl := synthesisLockTTL
+	synthesisLockTTL = 150 * time.Millisecond
+	t.Cleanup(func() { synthesisLockTTL = ttl })
+
+	req := &models.TTSRequest{Text: "你好", Voice: testVoice, Format: "wav"}
+	lockKey := redisLockKey("hash")
+
+	tests := []struct {
+		name string
+		// held 其他实例持有锁，测试开始后多久释放，0表示没有其他实例
+		held time.Duration
+		// lost 持有期间锁过期并被其他实例取得
+		lost bool
+	}{
+		{name: "renew and release"},
+		{name: "wait for other instance", held: 300 * time.Millisecond},
+		{name: "lost lock is not released", lost: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, config.BreakerConfig{}, &fakeEngine{name: "a"})
+			fake := newFakeRedis(t)
+			s.redis = fake.client(t)
+
+			start := time.Now()
+			if tt.held > 0 {
+				fake.set(lockKey, "other", time.Minute)
+				time.AfterFunc(tt.held, func() { fake.del(lockKey) })
+			}
+
+			lock, _, _, err := s.lockSynthesis(context.Background(), req, "hash", redisCacheKey("hash"))
+			if err != nil || lock == nil {
+				t.Fatalf("lockSynthesis = %v, %v, 期望获得锁", lock, err)
+			}
+			if waited := time.Since(start); waited < tt.held {
+				t.Fatalf("其他实例持有锁时等待了 %v, 期望至少 %v", waited, tt.held)
+			}
+			if value, ok := fake.get(lockKey); !ok || value != lock.token {
+				t.Fatalf("Redis中的锁为 %q, 期望 %q", value, lock.token)
+			}
+
+			if tt.lost {
+				fake.set(lockKey, "other", time.Minute)
+			}
+			// 超过过期时间后锁仍然存在，说明已续期
+			time.Sleep(3 * synthesisLockTTL)
+			value, ok := fake.get(lockKey)
+			if tt.lost {
+				if value != "other" {
+					t.Fatalf("Redis中的锁为 %q, 不应覆盖其他实例的锁", value)
+				}
+			} else if !ok || value != lock.token {
+				t.Fatalf("持有期间锁已过期: %q", value)
+			}
+
+			lock.release(nil)
+			value, ok = fake.get(lockKey)
+			if tt.lost && value != "other" {
+				t.Fatalf("释放后Redis中的锁为 %q, 不应删除其他实例的锁", value)
+			}
+			if !tt.lost && ok {
+				t.Fatalf("释放后锁仍然存在: %q", value)
+			}
+		})
+	}
+}
+
+// fakeRedis 只实现合成锁和缓存用到的命令的Redis服务端
+type fakeRedis struct {
+	ln net.Listener
+
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{ln: ln, values: make(map[string]string), expires: make(map[string]time.Time)}
+	t.Cleanup(func() { ln.Close() })
+	go r.serve()
+	return r
+}
+
+// client 连接到fakeRedis的客户端
+func (r *fakeRedis) client(t *testing.T) *cache.RedisClient {
+	t.Helper()
+	client, err := cache.NewRedisClient(&config.RedisConfig{Addr: r.ln.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func (r *fakeRedis) serve() {
+	for {
+		conn, err := r.ln.Accept()
+		if err != nil {
+			return
+		}
+		go r.handle(conn)
+	}
+}
+
+func (r *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, r.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand 读取一条以RESP数组发送的命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("不支持的请求: %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// exec 执行命令并返回RESP格式的回复
+func (r *fakeRedis) exec(args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		if value, ok := r.get(args[1]); ok {
+			return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+		}
+		return "$-1\r\n"
+	case "SET":
+		var ttl time.Duration
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "EX", "PX":
+				n, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(n) * time.Millisecond
+				if strings.ToUpper(args[i]) == "EX" {
+					ttl = time.Duration(n) * time.Second
+				}
+				i++
+			}
+		}
+		if _, ok := r.get(args[1]); ok && nx {
+			return "$-1\r\n"
+		}
+		r.set(args[1], args[2], ttl)
+		return "+OK\r\n"
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if r.del(key) {
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "EVALSHA":
+		return "-NOSCRIPT No matching script\r\n"
+	case "EVAL":
+		// 只支持 DeleteIfValue 和 ExpireIfValue 的脚本
+		script, key, value := args[1], args[3], args[4]
+		if current, ok := r.get(key); !ok || current != value {
+			return ":0\r\n"
+		}
+		if strings.Contains(script, "PEXPIRE") {
+			ms, _ := strconv.Atoi(args[5])
+			r.set(key, value, time.Duration(ms)*time.Millisecond)
+			return ":1\r\n"
+		}
+		r.del(key)
+		return ":1\r\n"
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+func (r *fakeRedis) get(key string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if expires, ok := r.expires[key]; ok && time.Now().After(expires) {
+		delete(r.values, key)
+		delete(r.expires, key)
+	}
+	value, ok := r.values[key]
+	return value, ok
+}
+
+// set 设置键的值，ttl为0表示不过期
+func (r *fakeRedis) set(key, value string, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key] = value
+	delete(r.expires, key)
+	if ttl > 0 {
+		r.expires[key] = time.Now().Add(ttl)
+	}
+}
+
+func (r *fakeRedis) del(key string) bool {
+	if _, ok := r.get(key); !ok {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.values, key)
+	delete(r.expires, key)
+	return true
+}
@@ -0,0 +1,179 @@
+package tts
+
+import (
+	"sort"
+	"strings"
+	"tts-service/internal/models"
+	"unicode"
+	"unicode/utf8"
+)
+
+// instructionStyle 指令中的关键词对应的说话风格，按优先顺序列出候选风格，使用语音支持的第一个
+type instructionStyle struct {
+	keywords []string
+	styles   []string
+}
+
+// instructionStyles 英文关键词按单词前缀匹配，中文关键词按子串匹配
+var instructionStyles = []instructionStyle{
+	{[]string{"cheerful", "happy", "joyful", "upbeat", "开心", "高兴", "愉快", "欢快"}, []string{"cheerful", "excited"}},
+	{[]string{"sad", "sorrowful", "melanchol", "悲伤", "难过", "伤心"}, []string{"sad", "depressed"}},
+	{[]string{"angry", "furious", "生气", "愤怒"}, []string{"angry", "disgruntled"}},
+	{[]string{"excited", "enthusiastic", "energetic", "兴奋", "激动"}, []string{"excited", "cheerful"}},
+	{[]string{"friendly", "warm", "友好", "亲切", "热情"}, []string{"friendly", "cheerful"}},
+	{[]string{"calm", "soothing", "relaxed", "平静", "冷静", "舒缓"}, []string{"calm", "gentle"}},
+	{[]string{"gentle", "tender", "温柔", "轻柔"}, []string{"gentle", "affectionate", "calm"}},
+	{[]string{"serious", "formal", "严肃", "正式"}, []string{"serious"}},
+	{[]string{"whisper", "耳语", "悄悄"}, []string{"whispering"}},
+	{[]string{"shout", "yell", "喊"}, []string{"shouting"}},
+	{[]string{"scared", "afraid", "fearful", "terrified", "害怕", "恐惧"}, []string{"fearful", "terrified"}},
+	{[]string{"empathetic", "sympathetic", "compassionate", "同情", "体贴"}, []string{"empathetic"}},
+	{[]string{"hopeful", "充满希望"}, []string{"hopeful"}},
+	{[]string{"affectionate", "loving", "深情"}, []string{"affectionate", "gentle"}},
+	{[]string{"newscast", "news", "anchor", "新闻", "播报"}, []string{"newscast", "newscast-formal", "newscast-casual"}},
+	{[]string{"customer service", "客服"}, []string{"customerservice"}},
+	{[]string{"narrat", "storytell", "旁白", "讲故事", "叙述"}, []string{"narration-professional", "narration-relaxed", "story"}},
+	{[]string{"poem", "poetry", "诗"}, []string{"poetry-reading", "lyrical"}},
+	{[]string{"sports commentary", "体育解说"}, []string{"sports-commentary", "sports-commentary-excited"}},
+	{[]string{"assistant", "助手"}, []string{"assistant", "chat"}},
+	{[]string{"casual", "conversational", "chat", "聊天", "随意"}, []string{"chat"}},
+}
+
+// instructionProsody 指令中调整韵律的关键词
+var instructionProsody = []struct {
+	keywords []string
+	speed    float64 // 语速倍数，与请求的speed相乘
+	pitch    string
+	volume   string
+}{
+	{keywords: []string{"very slow", "语速很慢", "非常慢"}, speed: 0.7},
+	{keywords: []string{"slow", "unhurried", "语速慢", "慢一点", "放慢", "缓慢"}, speed: 0.85},
+	{keywords: []string{"very fast", "very quick", "语速很快", "非常快"}, speed: 1.4},
+	{keywords: []string{"fast", "quick", "rapid", "brisk", "语速快", "快一点", "加快", "快速"}, speed: 1.2},
+	{keywords: []string{"high-pitched", "high pitch", "higher pitch", "高音", "音调高"}, pitch: "+10%"},
+	{keywords: []string{"low-pitched", "low pitch", "lower pitch", "deep voice", "低沉", "音调低"}, pitch: "-10%"},
+	{keywords: []string{"loud", "大声", "响亮"}, volume: "+50%"},
+	{keywords: []string{"quiet", "softly", "小声", "轻声"}, volume: "-40%"},
+}
+
+// ApplyInstructions 把OpenAI接口的自然语言指令映射为说话风格和韵律参数
+// 关键词匹配只覆盖常见的描述：风格取最先出现且语音支持的一种，语音不支持任何匹配的风格时忽略；
+// 语速、音调、音量每类只取第一个匹配的关键词，语速与请求中的speed相乘，音调和音量覆盖语音别名的默认值
+func (s *TTSService) ApplyInstructions(req *models.TTSRequest, instructions string) {
+	text := strings.ToLower(strings.TrimSpace(instructions))
+	if text == "" {
+		return
+	}
+	words := instructionWords(text)
+
+	// 按关键词出现的先后排列候选风格
+	type match struct {
+		pos    int
+		styles []string
+	}
+	var matches []match
+	for _, rule := range instructionStyles {
+		if pos := matchKeywords(text, words, rule.keywords); pos >= 0 {
+			matches = append(matches, match{pos, rule.styles})
+		}
+	}
+	if len(matches) > 0 {
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].pos < matches[j].pos })
+		var candidates []string
+		for _, m := range matches {
+			candidates = append(candidates, m.styles...)
+		}
+		if voice, _, err := s.findVoice(req); err == nil && voice != nil {
+			if style, ok := supportedStyle(voice, candidates); ok {
+				req.Style = style
+			}
+		}
+	}
+
+	var speedSet, pitchSet, volumeSet bool
+	for _, rule := range instructionProsody {
+		if matchKeywords(text, words, rule.keywords) < 0 {
+			continue
+		}
+		switch {
+		case rule.speed != 0 && !speedSet:
+			if req.Speed == 0 {
+				req.Speed = 1
+			}
+			req.Speed *= rule.speed
+			speedSet = true
+		case rule.pitch != "" && !pitchSet:
+			req.Pitch = models.ProsodyValue(rule.pitch)
+			pitchSet = true
+		case rule.volume != "" && !volumeSet:
+			req.Volume = models.ProsodyValue(rule.volume)
+			volumeSet = true
+		}
+	}
+}
+
+// supportedStyle 返回候选风格中语音支持的第一个，使用目录中的写法
+func supportedStyle(voice *models.Voice, candidates []string) (string, bool) {
+	for _, style := range candidates {
+		for _, supported := range voice.Styles {
+			if strings.EqualFold(style, supported) {
+				return supported, true
+			}
+		}
+	}
+	return "", false
+}
+
+// instructionWords 英文单词在文本中的起始位置
+func instructionWords(text string) map[int]bool {
+	words := make(map[int]bool)
+	start := -1
+	for i, r := range text + " " {
+		isWord := r < unicode.MaxASCII && (unicode.IsLetter(r) || r == '-')
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			words[start] = true
+			start = -1
+		}
+	}
+	return words
+}
+
+// matchKeywords 返回关键词在文本中最早出现的位置，没有匹配时返回-1
+// 含非ASCII字符的关键词按子串匹配，英文关键词要求从单词开头匹配，避免 sad 匹配到 crusade
+func matchKeywords(text string, words map[int]bool, keywords []string) int {
+	best := -1
+	for _, keyword := range keywords {
+		pos := -1
+		if !isASCII(keyword) {
+			pos = strings.Index(text, keyword)
+		} else {
+			for offset := 0; offset < len(text); {
+				i := strings.Index(text[offset:], keyword)
+				if i < 0 {
+					break
+				}
+				if words[offset+i] {
+					pos = offset + i
+					break
+				}
+				offset += i + 1
+			}
+		}
+		if pos >= 0 && (best < 0 || pos < best) {
+			best = pos
+		}
+	}
+	return best
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"tts-service/internal/language"
+	"tts-service/internal/lexicon"
+	"tts-service/internal/models"
+	"tts-service/internal/ssml"
+)
+
+// defaultLanguageVoices 内置的语言默认语音，配置 tts.language.voices 中的同名语言优先
+var defaultLanguageVoices = map[string]string{
+	"zh": "zh-CN-XiaoxiaoNeural",
+	"en": "en-US-JennyNeural",
+	"ja": "ja-JP-NanamiNeural",
+	"ko": "ko-KR-SunHiNeural",
+	"fr": "fr-FR-DeniseNeural",
+	"de": "de-DE-KatjaNeural",
+	"es": "es-ES-ElviraNeural",
+	"ru": "ru-RU-SvetlanaNeural",
+}
+
+// selectVoice 未指定语音时按文本的主要语言选择语音，没有对应语音时使用默认语音
+func (s *TTSService) selectVoice(req *models.TTSRequest) error {
+	voice, err := s.languageVoice(language.Detect(req.Text), req.Engine)
+	if err != nil {
+		return err
+	}
+	if voice != nil {
+		req.Voice = voice.Name
+	} else {
+		req.Voice = s.config.TTS.DefaultVoice
+	}
+	return nil
+}
+
+// languageVoice 查找语言对应的语音：先查配置和内置映射，再取语音目录中第一个该语言的语音
+// 只返回已启用引擎中存在的语音，找不到时返回nil
+func (s *TTSService) languageVoice(lang, engine string) (*models.Voice, error) {
+	if lang == "" {
+		return nil, nil
+	}
+
+	for _, name := range []string{s.config.TTS.Language.Voices[lang], defaultLanguageVoices[lang]} {
+		if name == "" {
+			continue
+		}
+		voice, _, err := s.findVoice(&models.TTSRequest{Voice: name, Engine: engine})
+		if err != nil || voice != nil {
+			return voice, err
+		}
+	}
+
+	filter := VoiceFilter{Locale: lang}
+	for _, e := range s.engines.Engines() {
+		if engine != "" && e.Name() != engine {
+			continue
+		}
+		voices, err := e.ListVoices(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("获取%s语音列表失败: %w", e.Name(), err)
+		}
+		for i := range voices {
+			if filter.Match(voices[i]) {
+				return &voices[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// mixLanguages 混合语言模式：按语言切分文本，每段放进对应语言的 <voice>，为请求生成SSML文档
+// 配置了多语言语音时改为在同一个 <voice> 中用 <lang> 标注各段的语言；只有一种语言时保持原样
+// 纯文本先按长度分段，每段生成一个独立的 <speak> 文档，长文本同样分段并发合成；请求本身仍是纯文本请求
+// 语速、音调和音量写入每段的 <prosody>，转换后的请求不再单独携带这些参数
+// 发音词典按每段的语言改写后直接写入SSML，缓存键由文档覆盖
+func (s *TTSService) mixLanguages(req *models.TTSRequest, entries []models.LexiconEntry) error {
+	req.Documents = nil
+	if req.Style != "" || req.Role != "" || req.StyleDegree != 0 {
+		return &ParamError{Param: "mixed_language", Message: "混合语言模式不支持说话风格和角色"}
+	}
+	if len(req.Contour) > 0 {
+		return &ParamError{Param: "mixed_language", Message: "混合语言模式不支持音调曲线"}
+	}
+
+	runs := language.Split(req.Text)
+	if len(runs) < 2 {
+		return nil
+	}
+
+	primary, _, err := s.findVoice(req)
+	if err != nil {
+		return err
+	}
+	if primary == nil {
+		return &ParamError{Param: "voice", Value: req.Voice, Message: "未知的语音，可用语音见 /api/v1/voices"}
+	}
+
+	// 每种语言的语音和区域，与主语音语言相同的段使用主语音，找不到对应语音的段也使用主语音
+	type segment struct {
+		text   string
+		voice  string
+		locale string
+	}
+	targets := make(map[string]segment)
+	target := func(run language.Run) (segment, error) {
+		seg, ok := targets[run.Language]
+		if !ok {
+			seg = segment{voice: primary.Name, locale: primary.Language}
+			if run.Language != "" && !sameLanguage(primary.Language, run.Language) {
+				voice, err := s.languageVoice(run.Language, req.Engine)
+				if err != nil {
+					return seg, err
+				}
+				if voice != nil {
+					seg.voice, seg.locale = voice.Name, voice.Language
+				}
+			}
+			targets[run.Language] = seg
+		}
+		seg.text = run.Text
+		return seg, nil
+	}
+
+	distinct := false
+	for _, run := range runs {
+		seg, err := target(run)
+		if err != nil {
+			return err
+		}
+		distinct = distinct || seg.voice != primary.Name
+	}
+	if !distinct {
+		return nil
+	}
+
+	// 语速、音调和音量为空时不写出 <prosody>
+	prosody := []string{"rate", req.Rate, "pitch", string(req.Pitch), "volume", string(req.Volume)}
+	hasProsody := req.Rate != "" || req.Pitch != "" || req.Volume != ""
+	body := func(b *ssml.Builder, text, locale string) {
+		if hasProsody {
+			b.Start("prosody", prosody...)
+		}
+		lexicon.Write(b, text, lexicon.Filter(entries, locale, text))
+		if hasProsody {
+			b.End()
+		}
+	}
+
+	var documents []string
+	for _, chunk := range SplitText(req.Text, s.chunkMaxChars()) {
+		var segments []segment
+		for _, run := range language.Split(chunk) {
+			seg, err := target(run)
+			if err != nil {
+				return err
+			}
+			segments = append(segments, seg)
+		}
+
+		b := ssml.NewBuilder()
+		b.Start("speak", "version", "1.0", "xmlns", ssml.NamespaceSynthesis, "xml:lang", primary.Language)
+		if multilingual := s.config.TTS.Language.MultilingualVoice; multilingual != "" {
+			b.Start("voice", "name", multilingual)
+			for _, seg := range segments {
+				b.Start("lang", "xml:lang", seg.locale)
+				body(b, seg.text, seg.locale)
+				b.End()
+			}
+			b.End()
+		} else {
+			// 相邻的同一语音合并为一个 <voice>
+			for i := 0; i < len(segments); {
+				j := i
+				var text strings.Builder
+				for ; j < len(segments) && segments[j].voice == segments[i].voice; j++ {
+					text.WriteString(segments[j].text)
+				}
+				b.Start("voice", "name", segments[i].voice)
+				body(b, text.String(), segments[i].locale)
+				b.End()
+				i = j
+			}
+		}
+		b.End()
+		document, err := b.String()
+		if err != nil {
+			return err
+		}
+		documents = append(documents, document)
+	}
+
+	req.Documents = documents
+	req.Speed = 1
+	req.Rate, req.Pitch, req.Volume = "", "", ""
+	return nil
+}
+
+// sameLanguage 区域(zh-CN)是否属于该语言(zh)
+func sameLanguage(locale, lang string) bool {
+	return strings.EqualFold(locale, lang) || strings.HasPrefix(strings.ToLower(locale), strings.ToLower(lang)+"-")
+}
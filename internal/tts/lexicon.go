@@ -0,0 +1,50 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+	"tts-service/internal/lexicon"
+	"tts-service/internal/models"
+)
+
+// lexiconEntries 加载请求所属用户的发音词典中在文本里出现的条目
+// 没有用户的请求(如命令行工具)和SSML请求不使用词典
+func (s *TTSService) lexiconEntries(req *models.TTSRequest) ([]models.LexiconEntry, error) {
+	if req.UserID == 0 || req.SSML || s.db == nil {
+		return nil, nil
+	}
+
+	entries, err := s.db.ListLexiconEntries(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("加载发音词典失败: %w", err)
+	}
+	used := entries[:0]
+	for _, entry := range entries {
+		if strings.Contains(req.Text, entry.Word) {
+			used = append(used, entry)
+		}
+	}
+	return used, nil
+}
+
+// attachLexicon 把适用于请求语音的条目附加到请求上，由支持SSML的引擎在生成SSML时改写
+// 词典版本写入缓存键，修改用到的条目后重新合成
+func (s *TTSService) attachLexicon(req *models.TTSRequest, entries []models.LexiconEntry) error {
+	req.Lexicon, req.LexiconVersion = nil, ""
+	if len(entries) == 0 {
+		return nil
+	}
+
+	voice, _, err := s.findVoice(req)
+	if err != nil {
+		return err
+	}
+	locale := ""
+	if voice != nil {
+		locale = voice.Language
+	}
+
+	req.Lexicon = lexicon.Filter(entries, locale, req.Text)
+	req.LexiconVersion = lexicon.Version(req.Lexicon)
+	return nil
+}
@@ -0,0 +1,116 @@
+package tts
+
+import (
+	"strings"
+	"tts-service/internal/language"
+	"tts-service/internal/models"
+	"tts-service/internal/normalize"
+)
+
+// normalizeText 合成前把数字、日期、货币等转换成朗读形式，并按策略处理Markdown、网址和表情
+// SSML请求由调用方自行控制读法，不做处理；每个请求只规范化一次，见 models.TTSRequest.Normalized
+// 文本为空的请求(如有声书的章节参数)只校验其余参数
+func (s *TTSService) normalizeText(req *models.TTSRequest) error {
+	if req.SSML || req.Text == "" {
+		return nil
+	}
+	opts, err := s.normalizeOptions(req.Normalize)
+	if err != nil {
+		return err
+	}
+
+	text, _ := s.normalizer.Normalize(req.Text, s.textLanguage(req.Voice, req.Engine, req.Text), opts)
+	if strings.TrimSpace(text) == "" {
+		return &ParamError{Param: "text", Message: "规范化后没有可朗读的文字"}
+	}
+	req.Text = text
+	return nil
+}
+
+// NormalizeText 返回规范化后的文本和每条规则的执行结果，用于调试读法
+func (s *TTSService) NormalizeText(req *models.NormalizeRequest) (*models.NormalizeResult, error) {
+	opts, err := s.normalizeOptions(req.Normalize)
+	if err != nil {
+		return nil, err
+	}
+
+	lang := strings.ToLower(req.Language)
+	if lang == "" {
+		lang = s.textLanguage(req.Voice, req.Engine, req.Text)
+	}
+	text, steps := s.normalizer.Normalize(req.Text, lang, opts)
+
+	result := &models.NormalizeResult{Language: lang, Voice: req.Voice, Text: text, Steps: []models.NormalizeStep{}}
+	for _, step := range steps {
+		result.Steps = append(result.Steps, models.NormalizeStep{Rule: step.Rule, Text: step.Text})
+	}
+	return result, nil
+}
+
+// normalizeOptions 合并配置和请求中的规范化选项，请求中的设置优先
+func (s *TTSService) normalizeOptions(opts *models.NormalizeOptions) (normalize.Options, error) {
+	cfg := s.config.TTS.Normalize
+	rules := s.normalizer.Rules()
+	result := normalize.Options{Disabled: make(map[string]bool), URL: cfg.URL, Emoji: cfg.Emoji}
+
+	// 规范化需要显式开启，默认原文直接交给引擎
+	apply := func(enable, disable bool, switches map[string]bool) error {
+		for _, rule := range rules {
+			switch {
+			case disable:
+				result.Disabled[rule] = true
+			case enable:
+				result.Disabled[rule] = false
+			}
+		}
+		for rule, enabled := range switches {
+			name := rule
+			if !containsFold(rules, &name) {
+				return &ParamError{Param: "normalize.rules", Value: rule, Message: "未知的规则", Supported: rules}
+			}
+			result.Disabled[name] = !enabled
+		}
+		return nil
+	}
+
+	if err := apply(false, !cfg.Enable, cfg.Rules); err != nil {
+		return result, err
+	}
+	if opts != nil {
+		if err := apply(opts.Enable, opts.Disable, opts.Rules); err != nil {
+			return result, err
+		}
+		if opts.URL != "" {
+			result.URL = opts.URL
+		}
+		if opts.Emoji != "" {
+			result.Emoji = opts.Emoji
+		}
+	}
+
+	for _, policy := range []struct {
+		param string
+		value *string
+	}{{"normalize.url", &result.URL}, {"normalize.emoji", &result.Emoji}} {
+		if *policy.value == "" {
+			*policy.value = normalize.PolicyKeep
+			continue
+		}
+		if !containsFold(normalize.Policies, policy.value) {
+			return result, &ParamError{Param: policy.param, Value: *policy.value, Message: "未知的处理方式", Supported: normalize.Policies}
+		}
+	}
+	return result, nil
+}
+
+// textLanguage 文本的语言，优先取语音所属的语言，语音不带语言信息时按文本检测
+func (s *TTSService) textLanguage(voice, engine, text string) string {
+	if voice != "" {
+		v, _, err := s.findVoice(&models.TTSRequest{Voice: voice, Engine: engine})
+		if err == nil && v != nil && v.Language != "" && v.Language != "und" {
+			lang, _, _ := strings.Cut(strings.ToLower(v.Language), "-")
+			return lang
+		}
+	}
+	return language.Detect(text)
+}
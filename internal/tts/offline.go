@@ -0,0 +1,208 @@
+package tts
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"tts-service/internal/audio"
+	"tts-service/internal/models"
+	"tts-service/internal/ssml"
+	"unicode"
+)
+
+// 离线引擎语音
+const (
+	OfflineVoiceTone    = "offline-tone"
+	OfflineVoiceSilence = "offline-silence"
+)
+
+const (
+	offlineRuneDuration = 80 * time.Millisecond // 每个字符对应的时长
+	offlineToneHz       = 440.0                 // 基准音高
+	offlineAmplitude    = 0.3                   // 音量
+)
+
+// OfflineEngine 离线确定性引擎，根据文本长度生成提示音或静音
+// 不依赖任何外部服务，用于测试和无法访问外网的部署
+type OfflineEngine struct{}
+
+// NewOfflineEngine 创建离线引擎
+func NewOfflineEngine() *OfflineEngine {
+	return &OfflineEngine{}
+}
+
+// Name 引擎名称
+func (e *OfflineEngine) Name() string {
+	return EngineOffline
+}
+
+// SupportedFormats 支持的音频格式，mp3只能输出静音帧
+func (e *OfflineEngine) SupportedFormats() []string {
+	return []string{"wav", "pcm", "mp3"}
+}
+
+// Capabilities 引擎能力
+func (e *OfflineEngine) Capabilities() Capabilities {
+	return Capabilities{
+		Streaming:  false,
+		Boundaries: true,
+		SSML:       false,
+		Online:     false,
+		Prosody: ProsodyRange{
+			Rate:   [2]float64{0.25, 4},
+			Pitch:  [2]float64{0.25, 4},
+			Volume: [2]float64{0, 2},
+		},
+	}
+}
+
+// ListVoices 获取语音列表
+func (e *OfflineEngine) ListVoices(ctx context.Context) ([]models.Voice, error) {
+	return []models.Voice{
+		{
+			Name:        OfflineVoiceTone,
+			Language:    "und",
+			Gender:      "neutral",
+			Description: "离线提示音",
+			Engine:      EngineOffline,
+			Formats:     e.SupportedFormats(),
+		},
+		{
+			Name:        OfflineVoiceSilence,
+			Language:    "und",
+			Gender:      "neutral",
+			Description: "离线静音",
+			Engine:      EngineOffline,
+			Formats:     e.SupportedFormats(),
+		},
+	}, nil
+}
+
+// Synthesize 根据文本生成确定性的音频，相同请求总是得到相同的字节
+func (e *OfflineEngine) Synthesize(ctx context.Context, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	speed := req.Speed
+	if speed <= 0 {
+		speed = 1.0
+	}
+
+	boundaries, total := offlineBoundaries(req.Text, time.Duration(float64(offlineRuneDuration)/speed))
+	if total == 0 {
+		return nil, fmt.Errorf("文本内容为空")
+	}
+
+	var data []byte
+	switch req.Format {
+	case "mp3":
+		data = audio.MP3SilenceFrames(total)
+	case "wav", "pcm":
+		pcm := e.renderPCM(req, total)
+		if req.Format == "wav" {
+			data = append(audio.WAVHeader(uint32(len(pcm)), audio.SampleRate, audio.Channels, audio.BitsPerSample), pcm...)
+		} else {
+			data = pcm
+		}
+	default:
+		return nil, fmt.Errorf("离线引擎不支持的音频格式: %s", req.Format)
+	}
+
+	n, err := w.Write(data)
+	if err != nil {
+		return nil, fmt.Errorf("写入音频数据失败: %w", err)
+	}
+
+	return &SynthesisResult{
+		Size:       int64(n),
+		Boundaries: boundaries,
+	}, nil
+}
+
+// renderPCM 生成16位PCM数据，提示音在每个字符之间留出短暂停顿
+func (e *OfflineEngine) renderPCM(req *models.TTSRequest, total time.Duration) []byte {
+	pcm := make([]byte, audio.PCMBytes(total))
+	if req.Voice == OfflineVoiceSilence {
+		return pcm
+	}
+
+	// 音调和音量已在NormalizeRequest中规范化，这里只需换算成频率和振幅倍数
+	freq := offlineToneHz
+	if pitch, err := ssml.ParsePitch(orDefault(req.Pitch)); err == nil {
+		freq = pitch.Apply(offlineToneHz)
+	}
+	if freq < 20 {
+		freq = 20
+	}
+	volume := 1.0
+	if v, err := ssml.ParseVolume(orDefault(req.Volume)); err == nil {
+		volume = v.Factor()
+	}
+
+	samples := len(pcm) / 2
+	period := audio.SampleRate / 10 // 每100ms一个音节
+	for i := 0; i < samples; i++ {
+		if i%period > period*3/4 {
+			continue
+		}
+		v := math.Sin(2*math.Pi*freq*float64(i)/audio.SampleRate) * offlineAmplitude * volume
+		v = math.Max(-1, math.Min(1, v))
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(v*math.MaxInt16)))
+	}
+	return pcm
+}
+
+// offlineBoundaries 按字符数平均分配时间，生成词和句边界
+// 中日韩文字每个字为一个词，其他文字按空白分词
+func offlineBoundaries(text string, perRune time.Duration) ([]models.Boundary, time.Duration) {
+	var words []models.Boundary
+	var offset time.Duration
+	var current []rune
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		d := perRune * time.Duration(len(current))
+		words = append(words, models.Boundary{
+			Type:     models.WordBoundary,
+			Offset:   offset.Milliseconds(),
+			Duration: d.Milliseconds(),
+			Text:     string(current),
+		})
+		offset += d
+		current = nil
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flush()
+			current = []rune{r}
+			flush()
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+
+	if offset == 0 {
+		return nil, 0
+	}
+
+	boundaries := []models.Boundary{{
+		Type:     models.SentenceBoundary,
+		Offset:   0,
+		Duration: offset.Milliseconds(),
+		Text:     strings.TrimSpace(text),
+	}}
+	return append(boundaries, words...), offset
+}
@@ -0,0 +1,155 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+	"tts-service/internal/models"
+	"tts-service/internal/ssml"
+)
+
+// ProsodyRange 引擎支持的韵律范围，以相对默认值的倍数表示，上限为0时不限制
+type ProsodyRange struct {
+	Rate   [2]float64 `json:"rate"`
+	Pitch  [2]float64 `json:"pitch"`
+	Volume [2]float64 `json:"volume"`
+}
+
+// normalizeProsody 解析语速、音调、音量和音调曲线，统一成SSML中的规范写法
+// 默认值统一为空字符串，Speed更新为实际的语速倍数，保证相同效果的请求得到相同的缓存键
+func normalizeProsody(req *models.TTSRequest) error {
+	if req.SSML {
+		if req.Rate != "" || req.Speed != 1 || req.Pitch != "" || req.Volume != "" || len(req.Contour) > 0 {
+			return &ParamError{Param: "prosody", Message: "SSML请求请在文档中使用 <prosody>"}
+		}
+		return nil
+	}
+
+	var (
+		rate ssml.Value
+		err  error
+	)
+	if req.Rate != "" {
+		if rate, err = ssml.ParseRate(req.Rate); err != nil {
+			return &ParamError{Param: "rate", Value: req.Rate, Message: err.Error()}
+		}
+	} else {
+		if req.Speed < 0 {
+			return &ParamError{Param: "speed", Value: fmt.Sprint(req.Speed), Message: "语速倍数必须大于0"}
+		}
+		rate = ssml.Value{Number: req.Speed, Unit: ssml.UnitMultiplier}
+	}
+	req.Speed = rate.Factor()
+	req.Rate = canonical(relative(rate))
+
+	pitch, err := ssml.ParsePitch(orDefault(req.Pitch))
+	if err != nil {
+		return &ParamError{Param: "pitch", Value: string(req.Pitch), Message: err.Error()}
+	}
+	req.Pitch = models.ProsodyValue(canonical(pitch))
+
+	volume, err := ssml.ParseVolume(orDefault(req.Volume))
+	if err != nil {
+		return &ParamError{Param: "volume", Value: string(req.Volume), Message: err.Error()}
+	}
+	req.Volume = models.ProsodyValue(canonical(relative(volume)))
+
+	if len(req.Contour) > 0 {
+		points, err := parseContour(req.Contour)
+		if err != nil {
+			return &ParamError{Param: "contour", Message: err.Error()}
+		}
+		for i := range req.Contour {
+			req.Contour[i].Pitch = models.ProsodyValue(points[i].Pitch.String())
+		}
+	}
+	return nil
+}
+
+// checkProsody 检查请求的韵律参数是否在引擎支持的范围内
+func checkProsody(engine Engine, req *models.TTSRequest) error {
+	caps := engine.Capabilities()
+	if len(req.Contour) > 0 && !caps.Contour {
+		return &ParamError{Param: "contour", Message: fmt.Sprintf("引擎 %s 不支持音调曲线", engine.Name())}
+	}
+
+	check := func(param, value string, parse func(string) (ssml.Value, error), limits [2]float64) error {
+		if value == "" || limits[1] == 0 {
+			return nil
+		}
+		v, err := parse(value)
+		if err != nil {
+			return &ParamError{Param: param, Value: value, Message: err.Error()}
+		}
+		if factor := v.Factor(); factor < limits[0] || factor > limits[1] {
+			return &ParamError{
+				Param:   param,
+				Value:   value,
+				Message: fmt.Sprintf("超出引擎 %s 支持的范围 (%g-%g 倍)", engine.Name(), limits[0], limits[1]),
+			}
+		}
+		return nil
+	}
+
+	if err := check("rate", req.Rate, ssml.ParseRate, caps.Prosody.Rate); err != nil {
+		return err
+	}
+	if err := check("pitch", string(req.Pitch), ssml.ParsePitch, caps.Prosody.Pitch); err != nil {
+		return err
+	}
+	if err := check("volume", string(req.Volume), ssml.ParseVolume, caps.Prosody.Volume); err != nil {
+		return err
+	}
+	for _, point := range req.Contour {
+		if err := check("contour", string(point.Pitch), ssml.ParsePitch, caps.Prosody.Pitch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contourString 音调曲线的SSML写法，请求已经过normalizeProsody规范化
+func contourString(contour []models.ContourPoint) string {
+	points, err := parseContour(contour)
+	if err != nil {
+		return ""
+	}
+	return ssml.FormatContour(points)
+}
+
+func parseContour(contour []models.ContourPoint) ([]ssml.ContourPoint, error) {
+	points := make([]ssml.ContourPoint, len(contour))
+	for i, point := range contour {
+		pitch, err := ssml.ParsePitch(string(point.Pitch))
+		if err != nil {
+			return nil, err
+		}
+		points[i] = ssml.ContourPoint{Position: point.Position, Pitch: pitch}
+	}
+	if err := ssml.CheckContour(points); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// relative 倍数换算成相对百分比，SSML中不带单位的数字含义因属性而异
+func relative(v ssml.Value) ssml.Value {
+	if v.Unit == ssml.UnitMultiplier {
+		return ssml.Value{Number: (v.Number - 1) * 100, Unit: ssml.UnitPercent}
+	}
+	return v
+}
+
+// canonical 规范写法，默认值为空字符串
+func canonical(v ssml.Value) string {
+	if v.IsDefault() {
+		return ""
+	}
+	return v.String()
+}
+
+func orDefault(v models.ProsodyValue) string {
+	if strings.TrimSpace(string(v)) == "" {
+		return "default"
+	}
+	return string(v)
+}
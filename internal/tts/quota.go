@@ -0,0 +1,232 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"tts-service/internal/models"
+	"tts-service/internal/utils"
+)
+
+const (
+	// evictBatchSize 每次从数据库取出的待淘汰缓存记录数
+	evictBatchSize = 100
+	// evictBackoff 没有可淘汰的缓存时，等待这段时间后才再次尝试淘汰
+	evictBackoff = time.Minute
+)
+
+// storageQuota 存储目录的容量限制和当前占用的字节数
+// 超限后由后台的runQuota淘汰缓存，写入文件的请求不等待淘汰完成
+type storageQuota struct {
+	mu    sync.Mutex
+	limit int64 // 0表示不限制
+	used  int64
+	keep  string        // 最近写入的音频对象键，不参与淘汰
+	wake  chan struct{} // 通知后台淘汰，多次通知合并为一次
+}
+
+// StorageStatus 存储目录的占用情况
+type StorageStatus struct {
+	UsedBytes int64 `json:"used_bytes"`
+	MaxBytes  int64 `json:"max_bytes"` // 0表示不限制
+}
+
+// newStorageQuota 按 storage.max_size 创建容量限制，配置无效时不限制容量
+func newStorageQuota(maxSize string) *storageQuota {
+	limit, err := utils.ParseSize(maxSize)
+	if err != nil {
+		fmt.Printf("storage.max_size 配置无效，不限制存储容量: %v\n", err)
+	}
+	return &storageQuota{limit: limit, wake: make(chan struct{}, 1)}
+}
+
+// notify 通知后台淘汰，已有未处理的通知时直接返回
+func (q *storageQuota) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// exceeded 当前占用是否超过限制
+func (q *storageQuota) exceeded() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.limit > 0 && q.used > q.limit
+}
+
+// StorageStatus 获取存储目录的占用情况
+func (s *TTSService) StorageStatus() StorageStatus {
+	s.quota.mu.Lock()
+	defer s.quota.mu.Unlock()
+	return StorageStatus{UsedBytes: s.quota.used, MaxBytes: s.quota.limit}
+}
+
+// scanStorage 统计存储中全部对象的大小，包括不参与淘汰的有声书文件
+func (s *TTSService) scanStorage(ctx context.Context) (int64, error) {
+	objects, err := s.store.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("统计存储占用失败: %w", err)
+	}
+	var total int64
+	for _, obj := range objects {
+		total += obj.Size
+	}
+	return total, nil
+}
+
+// addStorage 新写入的对象计入存储占用，超过限制时通知后台淘汰
+// keep为新写入的音频对象键，在下一个音频写入前不参与淘汰；边界元数据等附属文件传空字符串
+func (s *TTSService) addStorage(keep string, size int64) {
+	s.quota.mu.Lock()
+	s.quota.used += size
+	if keep != "" {
+		s.quota.keep = keep
+	}
+	s.quota.mu.Unlock()
+
+	if s.quota.exceeded() {
+		s.quota.notify()
+	}
+}
+
+// releaseStorage 删除的文件从存储占用中扣除
+func (s *TTSService) releaseStorage(size int64) {
+	s.quota.mu.Lock()
+	s.quota.used -= size
+	if s.quota.used < 0 {
+		s.quota.used = 0
+	}
+	s.quota.mu.Unlock()
+}
+
+// runQuota 统计存储占用，之后每次收到超限通知时淘汰缓存，直到ctx结束
+// 没有可淘汰的缓存时等待evictBackoff再处理下一次通知，避免每次写入都重新统计整个存储
+func (s *TTSService) runQuota(ctx context.Context) {
+	// 文件较多时统计耗时较长，不阻塞启动；启动前已超限时立即淘汰
+	used, err := s.scanStorage(ctx)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+	} else {
+		s.quota.mu.Lock()
+		s.quota.used = used
+		s.quota.mu.Unlock()
+		if s.quota.exceeded() {
+			s.quota.notify()
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.quota.wake:
+		}
+		if s.enforceQuota(ctx) {
+			continue
+		}
+
+		timer := time.NewTimer(evictBackoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// enforceQuota 存储占用超过 storage.max_size 时按最近访问时间淘汰缓存，直到回到限制以内
+// 最近写入的音频、固定的缓存和有声书文件不会被淘汰，只淘汰这些仍超限时记录日志并返回false
+// 统计和删除文件期间不持有quota.mu，写入文件的请求只在更新占用时短暂加锁
+func (s *TTSService) enforceQuota(ctx context.Context) bool {
+	if !s.quota.exceeded() {
+		return true
+	}
+
+	// 有声书等其他模块写入的文件不经过addStorage，淘汰前重新统计一次
+	used, err := s.scanStorage(ctx)
+	if err != nil {
+		fmt.Printf("淘汰缓存失败: %v\n", err)
+		return false
+	}
+	s.quota.mu.Lock()
+	s.quota.used = used
+	keep := s.quota.keep
+	s.quota.mu.Unlock()
+
+	evicted := 0
+	defer func() {
+		if evicted > 0 {
+			fmt.Printf("存储容量超限，淘汰了 %d 条缓存，当前占用 %d 字节\n", evicted, s.StorageStatus().UsedBytes)
+		}
+	}()
+	for s.quota.exceeded() {
+		caches, err := s.db.ListEvictableCache(keep, evictBatchSize)
+		if err != nil {
+			fmt.Printf("淘汰缓存失败: %v\n", err)
+			return false
+		}
+		if len(caches) == 0 {
+			status := s.StorageStatus()
+			fmt.Printf("存储占用 %d 字节超过限制 %d 字节，但没有可淘汰的缓存\n", status.UsedBytes, status.MaxBytes)
+			return false
+		}
+
+		for _, cache := range caches {
+			freed, err := s.evictCache(ctx, cache)
+			if err != nil {
+				fmt.Printf("淘汰缓存失败: %v\n", err)
+				return false
+			}
+			s.releaseStorage(freed)
+			evicted++
+			if !s.quota.exceeded() {
+				break
+			}
+		}
+	}
+	return true
+}
+
+// evictCache 删除缓存的音频、边界元数据和字幕文件以及缓存记录，返回释放的字节数
+func (s *TTSService) evictCache(ctx context.Context, cache *models.TTSCache) (int64, error) {
+	var freed int64
+	if cache.AudioPath != "" {
+		var err error
+		if freed, err = s.removeCacheObjects(ctx, objectKey(cache.AudioPath)); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.db.DeleteTTSCache(cache.ID); err != nil {
+		return freed, err
+	}
+	if s.redis != nil {
+		s.redis.Delete(redisCacheKey(cache.TextHash))
+	}
+	return freed, nil
+}
+
+// cacheHashFromFilename 从音频或字幕文件名中取出缓存键哈希
+func cacheHashFromFilename(filename string) string {
+	hash, _, _ := strings.Cut(filepath.Base(filename), ".")
+	return hash
+}
+
+// RecordAudioAccess 记录一次音频或字幕文件的下载，更新对应缓存的最近访问时间和命中次数
+func (s *TTSService) RecordAudioAccess(filename string) {
+	s.touchCache(cacheHashFromFilename(filename))
+}
+
+// PinCache 固定或取消固定音频文件对应的缓存，name可以是音频文件名或缓存键哈希，缓存不存在时返回nil
+func (s *TTSService) PinCache(name string, pinned bool) (*models.TTSCache, error) {
+	hash := cacheHashFromFilename(name)
+	found, err := s.db.SetTTSCachePinned(hash, pinned)
+	if err != nil || !found {
+		return nil, err
+	}
+	return s.db.FindTTSCache(hash)
+}
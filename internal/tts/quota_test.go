@@ -0,0 +1,91 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"tts-service/internal/config"
+	"tts-service/internal/models"
+	"tts-service/internal/storage"
+)
+
+func TestEnforceQuota(t *testing.T) {
+	// 每条缓存的音频100字节，按最近访问时间从早到晚为 keep、a、pinned、b、c
+	// keep是最近写入的音频，pinned已固定，两者都不参与淘汰
+	entries := []struct {
+		name     string
+		accessed string
+		pinned   bool
+	}{
+		{name: "keep", accessed: "2024-01-01 00:00:00"},
+		{name: "a", accessed: "2024-01-02 00:00:00"},
+		{name: "pinned", accessed: "2024-01-03 00:00:00", pinned: true},
+		{name: "b", accessed: "2024-01-04 00:00:00"},
+		{name: "c", accessed: "2024-01-05 00:00:00"},
+	}
+
+	tests := []struct {
+		name        string
+		limit       int64
+		touch       string // 淘汰前访问一次的缓存
+		want        bool
+		wantEvicted []string
+	}{
+		{name: "within limit", limit: 500, want: true},
+		{name: "oldest first", limit: 450, want: true, wantEvicted: []string{"a"}},
+		{name: "until within limit", limit: 350, want: true, wantEvicted: []string{"a", "b"}},
+		{name: "touched moves to newest", limit: 350, touch: "a", want: true, wantEvicted: []string{"b", "c"}},
+		{name: "nothing left to evict", limit: 150, want: false, wantEvicted: []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, config.BreakerConfig{}, &fakeEngine{name: "a"})
+			ctx := context.Background()
+
+			for _, e := range entries {
+				key := e.name + ".wav"
+				if err := s.store.Put(ctx, key, strings.NewReader(strings.Repeat("x", 100)), 100); err != nil {
+					t.Fatal(err)
+				}
+				cache := &models.TTSCache{TextHash: e.name, Voice: testVoice, Format: "wav", AudioPath: key, KeyVersion: CacheKeyVersion}
+				if err := s.db.CreateTTSCache(cache); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := s.db.Exec(`UPDATE tts_cache SET last_accessed_at = ?, pinned = ? WHERE text_hash = ?`, e.accessed, e.pinned, e.name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.touch != "" {
+				s.RecordAudioAccess(tt.touch + ".wav")
+			}
+
+			s.quota.limit = tt.limit
+			s.quota.used = 500
+			s.quota.keep = "keep.wav"
+			if got := s.enforceQuota(ctx); got != tt.want {
+				t.Fatalf("enforceQuota = %v, 期望 %v", got, tt.want)
+			}
+
+			evicted := map[string]bool{}
+			for _, name := range tt.wantEvicted {
+				evicted[name] = true
+			}
+			for _, e := range entries {
+				record, err := s.db.FindTTSCache(e.name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = s.store.Stat(ctx, e.name+".wav")
+				fileExists := !errors.Is(err, storage.ErrNotExist)
+				if (record == nil) != evicted[e.name] || fileExists == evicted[e.name] {
+					t.Errorf("缓存 %s: 记录存在 %v, 文件存在 %v, 期望淘汰: %v", e.name, record != nil, fileExists, evicted[e.name])
+				}
+			}
+			if used := s.StorageStatus().UsedBytes; used != 500-int64(len(tt.wantEvicted))*100 {
+				t.Errorf("淘汰后占用 %d 字节", used)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+	"tts-service/internal/models"
+)
+
+// 说话风格强度范围，与 mstts:express-as 的 styledegree 一致
+const (
+	minStyleDegree = 0.01
+	maxStyleDegree = 2.0
+)
+
+// ParamError 请求参数无效，Supported为可选值
+type ParamError struct {
+	Param     string   `json:"param"`
+	Value     string   `json:"value,omitempty"`
+	Message   string   `json:"message"`
+	Supported []string `json:"supported,omitempty"`
+}
+
+func (e *ParamError) Error() string {
+	if len(e.Supported) > 0 {
+		return fmt.Sprintf("参数 %s 无效: %s，可选值: %s", e.Param, e.Message, strings.Join(e.Supported, ", "))
+	}
+	return fmt.Sprintf("参数 %s 无效: %s", e.Param, e.Message)
+}
+
+// validateStyle 根据语音目录校验说话风格、风格强度和角色
+// 只有目录中声明了风格的语音才能使用，未指定引擎时固定为提供该语音的引擎，避免故障转移后丢失风格
+func (s *TTSService) validateStyle(req *models.TTSRequest) error {
+	// OpenAI兼容接口使用"default"表示不指定风格
+	if req.Style == "default" {
+		req.Style = ""
+	}
+	if req.Style == "" && req.Role == "" && req.StyleDegree == 0 {
+		return nil
+	}
+
+	if req.SSML {
+		return &ParamError{Param: "style", Message: "SSML请求请在文档中使用 <mstts:express-as>"}
+	}
+	if req.StyleDegree != 0 {
+		if req.Style == "" {
+			return &ParamError{Param: "styledegree", Message: "需要同时指定 style"}
+		}
+		if req.StyleDegree < minStyleDegree || req.StyleDegree > maxStyleDegree {
+			return &ParamError{
+				Param:   "styledegree",
+				Value:   fmt.Sprint(req.StyleDegree),
+				Message: fmt.Sprintf("取值范围为 %g-%g", minStyleDegree, maxStyleDegree),
+			}
+		}
+		// 强度1与默认值相同，统一为0使两者共用缓存
+		if req.StyleDegree == 1 {
+			req.StyleDegree = 0
+		}
+	}
+
+	voice, engine, err := s.findVoice(req)
+	if err != nil {
+		return err
+	}
+	if voice == nil {
+		return &ParamError{Param: "voice", Value: req.Voice, Message: "语音不支持说话风格和角色"}
+	}
+
+	if req.Style != "" && !containsFold(voice.Styles, &req.Style) {
+		return &ParamError{Param: "style", Value: req.Style, Message: "语音 " + voice.Name + " 不支持该风格", Supported: voice.Styles}
+	}
+	if req.Role != "" && !containsFold(voice.Roles, &req.Role) {
+		return &ParamError{Param: "role", Value: req.Role, Message: "语音 " + voice.Name + " 不支持该角色", Supported: voice.Roles}
+	}
+
+	if req.Engine == "" {
+		req.Engine = engine
+	}
+	return nil
+}
+
+// containsFold 忽略大小写查找，找到时将value替换为目录中的写法
+func containsFold(list []string, value *string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, *value) {
+			*value = item
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,26 @@
 package tts
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
+	"tts-service/internal/audio"
 	"tts-service/internal/cache"
 	"tts-service/internal/config"
 	"tts-service/internal/db"
 	"tts-service/internal/models"
+	"tts-service/internal/normalize"
+	"tts-service/internal/ssml"
+	"tts-service/internal/storage"
+	"tts-service/internal/subtitle"
 	"tts-service/internal/utils"
 )
 
@@ -15,14 +28,19 @@ import (
 type TTSService struct {
 	db         *db.DB
 	config     *config.Config
-	edgeClient *EdgeTTSClient
+	engines    *EngineRegistry
 	redis      *cache.RedisClient
+	normalizer *normalize.Normalizer
+	store      storage.Storage
+	quota      *storageQuota
+	cleanup    cleanupState
+	flights    flightGroup
 }
 
-// NewTTSService 创建新的TTS服务
-func NewTTSService(database *db.DB, cfg *config.Config) *TTSService {
-	edgeClient := NewEdgeTTSClient(&cfg.EdgeTTS)
-	
+// NewTTSService 创建新的TTS服务，缓存的音频、边界元数据和字幕保存在store中
+func NewTTSService(database *db.DB, cfg *config.Config, store storage.Storage) *TTSService {
+	engines := NewEngineRegistry(cfg)
+
 	// 初始化Redis客户端（可选）
 	var redisClient *cache.RedisClient
 	if cfg.Redis.Addr != "" {
@@ -32,20 +50,40 @@ func NewTTSService(database *db.DB, cfg *config.Config) *TTSService {
 			fmt.Printf("Redis初始化失败，将使用SQLite缓存: %v\n", err)
 		}
 	}
-	
+
 	return &TTSService{
 		db:         database,
 		config:     cfg,
-		edgeClient: edgeClient,
+		engines:    engines,
 		redis:      redisClient,
+		normalizer: normalize.New(),
+		store:      store,
+		quota:      newStorageQuota(cfg.Storage.MaxSize),
 	}
 }
 
 // ProcessTTSRequest 处理TTS请求
 func (s *TTSService) ProcessTTSRequest(req *models.TTSRequest) (*models.TTSData, error) {
-	// 设置默认值
-	if req.Voice == "" {
-		req.Voice = s.config.TTS.DefaultVoice
+	return s.process(context.Background(), req, nil)
+}
+
+// StreamTTSRequest 处理TTS请求，音频数据边合成边写入w，同时写入缓存文件
+func (s *TTSService) StreamTTSRequest(ctx context.Context, req *models.TTSRequest, w io.Writer) (*models.TTSData, error) {
+	return s.process(ctx, req, w)
+}
+
+// NormalizeRequest 设置请求默认值并校验参数，已经规范化的请求直接返回
+func (s *TTSService) NormalizeRequest(req *models.TTSRequest) error {
+	if req.Normalized {
+		return nil
+	}
+	if req.Voice == "" && !req.SSML {
+		if err := s.selectVoice(req); err != nil {
+			return err
+		}
+	}
+	if err := s.normalizeText(req); err != nil {
+		return err
 	}
 	if req.Format == "" {
 		req.Format = s.config.TTS.DefaultFormat
@@ -53,130 +91,621 @@ func (s *TTSService) ProcessTTSRequest(req *models.TTSRequest) (*models.TTSData,
 	if req.Speed == 0 {
 		req.Speed = 1.0
 	}
-	if req.Volume == 0 {
-		req.Volume = 1.0
+	if req.Subtitles != "" && !subtitle.IsSupported(req.Subtitles) {
+		return &ParamError{
+			Param:     "subtitles",
+			Value:     req.Subtitles,
+			Message:   "不支持的字幕格式",
+			Supported: []string{subtitle.FormatSRT, subtitle.FormatVTT},
+		}
 	}
-
-	// 生成文本哈希用于缓存
-	textHash := utils.GenerateTextHash(req.Text, req.Voice, req.Format)
-	cacheKey := fmt.Sprintf("tts:%s", textHash)
-	
-	// 首先检查Redis缓存
-	if s.redis != nil {
-		if audioPath, err := s.redis.Get(cacheKey); err == nil && audioPath != "" {
-			// 检查文件是否存在
-			if _, err := os.Stat(audioPath); err == nil {
-				// Redis缓存命中
-				return &models.TTSData{
-					AudioURL: s.getAudioURL(audioPath),
-					TaskID:   utils.GenerateRequestID(),
-				}, nil
-			} else {
-				// 文件不存在，删除Redis缓存
-				s.redis.Delete(cacheKey)
+	if err := normalizeProsody(req); err != nil {
+		return err
+	}
+	if !req.SSML {
+		entries, err := s.lexiconEntries(req)
+		if err != nil {
+			return err
+		}
+		if req.MixedLanguage {
+			if err := s.mixLanguages(req, entries); err != nil {
+				return err
 			}
 		}
-	}
-	
-	// 检查SQLite缓存
-	if cache, err := s.db.GetTTSCache(textHash, req.Voice, req.Format); err == nil && cache != nil {
-		// 检查文件是否存在
-		if _, err := os.Stat(cache.AudioPath); err == nil {
-			// SQLite缓存命中，同时更新Redis缓存
-			if s.redis != nil {
-				s.redis.SetWithTTL(cacheKey, cache.AudioPath, 3600) // 1小时TTL
+		if len(req.Documents) == 0 {
+			if err := s.attachLexicon(req, entries); err != nil {
+				return err
 			}
-			return &models.TTSData{
-				AudioURL: s.getAudioURL(cache.AudioPath),
-				TaskID:   utils.GenerateRequestID(),
-			}, nil
-		} else {
-			// 文件不存在，删除缓存记录
-			// 这里可以添加删除缓存记录的逻辑
 		}
 	}
+	if req.SSML || len(req.Documents) > 0 {
+		if err := s.validateSSML(req); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.engines.Candidates(req); err != nil {
+		return err
+	}
+	if err := s.validateVoice(req); err != nil {
+		return err
+	}
+	if err := s.validateStyle(req); err != nil {
+		return err
+	}
+	req.Normalized = true
+	return nil
+}
+
+// validateSSML 校验用户提供的SSML或混合语言模式生成的各段文档，引用的语音必须属于支持SSML的引擎
+// 请求的语音和引擎以第一个文档中的第一个语音为准，用于选择引擎和生成缓存键
+func (s *TTSService) validateSSML(req *models.TTSRequest) error {
+	voices := make(map[string]string)
+	for _, engine := range s.engines.Engines() {
+		if !engine.Capabilities().SSML {
+			continue
+		}
+		list, err := engine.ListVoices(context.Background())
+		if err != nil {
+			return fmt.Errorf("获取%s语音列表失败: %w", engine.Name(), err)
+		}
+		for _, voice := range list {
+			voices[voice.Name] = engine.Name()
+		}
+	}
+
+	documents := req.Documents
+	if req.SSML {
+		documents = []string{req.Text}
+	}
+	for i, document := range documents {
+		doc, err := ssml.Validate(document, func(name string) bool {
+			_, ok := voices[name]
+			return ok
+		})
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			req.Voice = doc.Voices[0]
+		}
+	}
+
+	if req.Engine == "" {
+		req.Engine = voices[req.Voice]
+	}
+	return nil
+}
+
+// process 处理TTS请求，w不为nil时将音频数据写入w
+// 未指定引擎时按 tts.engines 顺序尝试，跳过熔断中的引擎，失败后转移到下一个引擎
+// 缓存未命中时相同的请求只合成一次，其他请求等待合成结束后读取缓存
+func (s *TTSService) process(ctx context.Context, req *models.TTSRequest, w io.Writer) (*models.TTSData, error) {
+	if err := s.NormalizeRequest(req); err != nil {
+		return nil, err
+	}
 
-	// 调用Edge TTS进行语音合成
-	audioData, err := s.edgeClient.Synthesize(req.Text, req.Voice, req.Format, req.Speed, req.Pitch)
+	candidates, err := s.engines.Candidates(req)
 	if err != nil {
-		return nil, fmt.Errorf("语音合成失败: %w", err)
+		return nil, err
+	}
+
+	var lastErr error
+	var retryAfter time.Duration // 熔断器拒绝时返回的最短等待时间，包括半开状态下探测请求已满的情况
+	for _, engine := range candidates {
+		textHash := cacheHash(req, engine.Name())
+		cacheKey := redisCacheKey(textHash)
+
+		if key, data, ok := s.lookupCache(ctx, req, textHash, cacheKey); ok {
+			return s.serveCached(ctx, req, data, key, w)
+		}
+
+		lock, key, data, err := s.lockSynthesis(ctx, req, textHash, cacheKey)
+		if err != nil {
+			var flightErr *flightError
+			if errors.As(err, &flightErr) {
+				lastErr = flightErr.Err
+				continue
+			}
+			return nil, err
+		}
+		if lock == nil {
+			return s.serveCached(ctx, req, data, key, w)
+		}
+
+		breaker := s.engines.Breaker(engine.Name())
+		if ok, wait := breaker.Allow(); !ok {
+			lastErr = fmt.Errorf("引擎 %s 熔断中", engine.Name())
+			retryAfter = shorterWait(retryAfter, wait)
+			lock.release(nil)
+			continue
+		}
+
+		out := &trackingWriter{w: w}
+		data, err = s.synthesize(ctx, engine, req, textHash, cacheKey, out)
+		if err == nil {
+			breaker.Success()
+			lock.release(nil)
+			return data, nil
+		}
+
+		// 客户端断开不是引擎的问题，不计入失败，等待的请求自行合成
+		if ctx.Err() != nil || out.err != nil {
+			breaker.Release()
+			lock.release(nil)
+			return nil, err
+		}
+
+		breaker.Failure(err)
+		lock.release(err)
+		fmt.Printf("引擎 %s 合成失败: %v\n", engine.Name(), err)
+
+		// 已经向客户端输出了部分音频，无法再切换引擎；没有客户端时临时文件已丢弃，可以继续转移
+		if w != nil && out.n > 0 {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	if retryAfter = shorterWait(retryAfter, s.engines.RetryAfter(candidates)); retryAfter > 0 {
+		return nil, &UnavailableError{RetryAfter: retryAfter, Err: lastErr}
+	}
+	return nil, lastErr
+}
+
+// serveCached 输出缓存命中的音频，按需生成字幕
+func (s *TTSService) serveCached(ctx context.Context, req *models.TTSRequest, data *models.TTSData, key string, w io.Writer) (*models.TTSData, error) {
+	if w != nil {
+		if err := s.copyObject(ctx, w, key); err != nil {
+			return nil, fmt.Errorf("输出缓存音频失败: %w", err)
+		}
 	}
+	return s.withSubtitles(ctx, req, data, key, nil)
+}
+
+// synthesize 调用引擎合成语音并写入缓存
+func (s *TTSService) synthesize(ctx context.Context, engine Engine, req *models.TTSRequest, textHash, cacheKey string, w io.Writer) (*models.TTSData, error) {
+	// 需要边界元数据时同时获取词和句边界，缓存文件可服务于后续任意组合的请求
+	synthReq := *req
+	if wantsBoundaries(req) {
+		synthReq.WordBoundary = true
+		synthReq.SentenceBoundary = true
+	}
+
+	// 音频数据同时写入w和临时文件，合成中断时不会留下不完整的缓存
+	var result *SynthesisResult
+	key, duration, err := s.saveAudioFile(ctx, textHash, req.Format, func(file io.Writer) error {
+		out := io.MultiWriter(w, file)
 
-	// 保存音频文件
-	audioPath, err := s.saveAudioFile(audioData, textHash, req.Format)
+		// 引擎不直接支持的格式先合成WAV再转码
+		synthReq.Format = sourceFormat(engine, req.Format)
+		if synthReq.Format == req.Format {
+			var err error
+			result, err = s.synthesizeChunked(ctx, engine, &synthReq, out)
+			if err != nil {
+				return fmt.Errorf("语音合成失败: %w", err)
+			}
+			return nil
+		}
+
+		transcoder, err := audio.NewTranscoder(req.Format, out)
+		if err != nil {
+			return err
+		}
+		result, err = s.synthesizeChunked(ctx, engine, &synthReq, transcoder)
+		if err != nil {
+			transcoder.Abort()
+			return fmt.Errorf("语音合成失败: %w", err)
+		}
+		if err := transcoder.Close(); err != nil {
+			return fmt.Errorf("音频转码失败: %w", err)
+		}
+		result.Size = transcoder.Size()
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("保存音频文件失败: %w", err)
+		return nil, err
+	}
+
+	// 保存边界元数据，与音频文件放在一起
+	if wantsBoundaries(req) {
+		if err := s.saveBoundaries(ctx, key, result.Boundaries); err != nil {
+			fmt.Printf("保存边界元数据失败: %v\n", err)
+		}
 	}
 
 	// 保存SQLite缓存记录
 	cache := &models.TTSCache{
-		TextHash:  textHash,
-		Voice:     req.Voice,
-		Format:    req.Format,
-		AudioPath: audioPath,
+		TextHash:   textHash,
+		Voice:      req.Voice,
+		Format:     req.Format,
+		AudioPath:  key,
+		KeyVersion: CacheKeyVersion,
 	}
 	if err := s.db.CreateTTSCache(cache); err != nil {
 		// 缓存保存失败不影响主流程，只记录日志
 		fmt.Printf("保存SQLite缓存失败: %v\n", err)
 	}
-	
+
 	// 保存Redis缓存
 	if s.redis != nil {
-		if err := s.redis.SetWithTTL(cacheKey, audioPath, 3600); err != nil {
+		if err := s.redis.SetWithTTL(cacheKey, key, 3600); err != nil {
 			fmt.Printf("保存Redis缓存失败: %v\n", err)
 		}
 	}
 
-	return &models.TTSData{
-		AudioURL: s.getAudioURL(audioPath),
-		Size:     int64(len(audioData)),
+	data := &models.TTSData{
+		AudioURL:   s.getAudioURL(key),
+		Duration:   duration,
+		Size:       result.Size,
+		TaskID:     utils.GenerateRequestID(),
+		Boundaries: filterBoundaries(req, result.Boundaries),
+	}
+	return s.withSubtitles(ctx, req, data, key, result.Boundaries)
+}
+
+// audioDuration 计算音频文件时长（秒），无法计算时返回0
+func (s *TTSService) audioDuration(format, audioPath string) float64 {
+	duration, err := audio.FileDuration(format, audioPath)
+	if err != nil {
+		return 0
+	}
+	return duration.Seconds()
+}
+
+// BreakerStatus 获取所有引擎的熔断器状态
+func (s *TTSService) BreakerStatus() []BreakerStatus {
+	statuses := []BreakerStatus{}
+	for _, engine := range s.engines.Engines() {
+		statuses = append(statuses, s.engines.Breaker(engine.Name()).Status())
+	}
+	return statuses
+}
+
+// trackingWriter 记录写入客户端的字节数和错误，w为nil时只统计合成的字节数
+type trackingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	if t.w == nil {
+		t.n += int64(len(p))
+		return len(p), nil
+	}
+	n, err := t.w.Write(p)
+	t.n += int64(n)
+	if err != nil {
+		t.err = err
+	}
+	return n, err
+}
+
+// ListVoices 汇总所有引擎中满足筛选条件的语音
+func (s *TTSService) ListVoices(ctx context.Context, filter VoiceFilter) ([]models.Voice, error) {
+	voices := []models.Voice{}
+	for _, engine := range s.engines.Engines() {
+		list, err := engine.ListVoices(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取%s语音列表失败: %w", engine.Name(), err)
+		}
+		for _, voice := range list {
+			if filter.Match(voice) {
+				voices = append(voices, voice)
+			}
+		}
+	}
+	return voices, nil
+}
+
+// Start 启动引擎的后台任务，如语音目录的定时刷新，启动存储容量的后台淘汰和定时清理
+func (s *TTSService) Start(ctx context.Context) {
+	for _, engine := range s.engines.Engines() {
+		if starter, ok := engine.(Starter); ok {
+			starter.Start(ctx)
+		}
+	}
+
+	go s.runQuota(ctx)
+	s.startCleanup(ctx)
+}
+
+// Engines 获取已启用的引擎
+func (s *TTSService) Engines() []Engine {
+	return s.engines.Engines()
+}
+
+// lookupCache 依次查询Redis和SQLite缓存，命中时返回音频的对象键和响应数据
+// 对象不存在时删除失效的缓存；存储暂时不可用时不删除，按未命中处理
+func (s *TTSService) lookupCache(ctx context.Context, req *models.TTSRequest, textHash, cacheKey string) (string, *models.TTSData, bool) {
+	// 首先检查Redis缓存
+	if s.redis != nil {
+		if value, err := s.redis.Get(cacheKey); err == nil && value != "" {
+			key := objectKey(value)
+			// 检查对象是否存在
+			if info, err := s.store.Stat(ctx, key); err == nil {
+				// Redis缓存命中
+				if data, ok := s.cachedTTSData(ctx, req, key, info.Size); ok {
+					s.touchCache(textHash)
+					return key, data, true
+				}
+			} else if errors.Is(err, storage.ErrNotExist) {
+				// 对象不存在，删除Redis缓存
+				s.redis.Delete(cacheKey)
+			}
+		}
+	}
+
+	// 检查SQLite缓存
+	if cache, err := s.db.GetTTSCache(textHash, req.Voice, req.Format); err == nil && cache != nil {
+		key := objectKey(cache.AudioPath)
+		// 检查对象是否存在
+		if info, err := s.store.Stat(ctx, key); err == nil {
+			// SQLite缓存命中，同时更新Redis缓存
+			if s.redis != nil {
+				s.redis.SetWithTTL(cacheKey, key, 3600) // 1小时TTL
+			}
+			if data, ok := s.cachedTTSData(ctx, req, key, info.Size); ok {
+				s.touchCache(textHash)
+				return key, data, true
+			}
+		} else if errors.Is(err, storage.ErrNotExist) {
+			// 对象不存在，删除缓存记录，重新合成后写入新记录
+			if _, err := s.evictCache(ctx, cache); err != nil {
+				fmt.Printf("删除失效缓存失败: %v\n", err)
+			}
+		}
+	}
+
+	return "", nil, false
+}
+
+// touchCache 记录一次缓存命中，失败时只记录日志
+func (s *TTSService) touchCache(textHash string) {
+	if err := s.db.TouchTTSCache(textHash); err != nil {
+		fmt.Printf("记录缓存访问失败: %v\n", err)
+	}
+}
+
+// objectKey 缓存记录和Redis中保存的对象键，兼容旧版本保存的本地文件路径
+func objectKey(audioPath string) string {
+	return path.Base(filepath.ToSlash(audioPath))
+}
+
+// copyObject 将存储中的对象写入w
+func (s *TTSService) copyObject(ctx context.Context, w io.Writer, key string) error {
+	r, err := s.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// withSubtitles 按需生成字幕文件并填充字幕URL，boundaries为nil时从缓存读取
+func (s *TTSService) withSubtitles(ctx context.Context, req *models.TTSRequest, data *models.TTSData, key string, boundaries []models.Boundary) (*models.TTSData, error) {
+	if req.Subtitles == "" {
+		return data, nil
+	}
+
+	// 字幕文件与音频共用同一个text_hash
+	subtitleKey := s.subtitleKey(key, req.Subtitles)
+	if _, err := s.store.Stat(ctx, subtitleKey); err != nil {
+		if boundaries == nil {
+			loaded, err := s.loadBoundaries(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("读取边界元数据失败: %w", err)
+			}
+			boundaries = loaded
+		}
+
+		cues := subtitle.BuildCues(boundaries, s.subtitleOptions())
+		content, err := subtitle.Render(req.Subtitles, cues)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.putObject(ctx, subtitleKey, content); err != nil {
+			return nil, fmt.Errorf("保存字幕文件失败: %w", err)
+		}
+	}
+
+	data.SubtitleURL = s.getAudioURL(subtitleKey)
+	return data, nil
+}
+
+// subtitleOptions 字幕切分选项，未配置时使用默认值
+func (s *TTSService) subtitleOptions() subtitle.Options {
+	opts := subtitle.Options{
+		MaxLineLength: s.config.TTS.Subtitle.MaxLineLength,
+		MaxDuration:   time.Duration(s.config.TTS.Subtitle.MaxDurationMs) * time.Millisecond,
+	}
+	if opts.MaxLineLength <= 0 {
+		opts.MaxLineLength = 40
+	}
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = 5 * time.Second
+	}
+	return opts
+}
+
+// cachedTTSData 根据缓存的音频构造响应，需要边界元数据但缓存中没有时返回false
+func (s *TTSService) cachedTTSData(ctx context.Context, req *models.TTSRequest, key string, size int64) (*models.TTSData, bool) {
+	data := &models.TTSData{
+		AudioURL: s.getAudioURL(key),
+		Size:     size,
 		TaskID:   utils.GenerateRequestID(),
-	}, nil
+	}
+
+	if wantsBoundaries(req) {
+		boundaries, err := s.loadBoundaries(ctx, key)
+		if err != nil {
+			return nil, false
+		}
+		data.Boundaries = filterBoundaries(req, boundaries)
+	}
+
+	return data, true
+}
+
+// sidecarKey 与音频共用text_hash的边界元数据、字幕等文件的对象键
+func sidecarKey(key, suffix string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + suffix
+}
+
+// subtitleKey 字幕文件的对象键，包含字幕切分选项，修改 tts.subtitle 配置后重新生成而不是沿用旧的字幕
+func (s *TTSService) subtitleKey(key, format string) string {
+	opts := s.subtitleOptions()
+	return sidecarKey(key, fmt.Sprintf(".%dc%dms.%s", opts.MaxLineLength, opts.MaxDuration.Milliseconds(), format))
+}
+
+// boundariesKey 边界元数据的对象键
+func boundariesKey(key string) string {
+	return sidecarKey(key, ".boundaries.json")
+}
+
+// saveBoundaries 保存边界元数据
+func (s *TTSService) saveBoundaries(ctx context.Context, key string, boundaries []models.Boundary) error {
+	data, err := json.Marshal(boundaries)
+	if err != nil {
+		return err
+	}
+	return s.putObject(ctx, boundariesKey(key), data)
+}
+
+// loadBoundaries 读取边界元数据
+func (s *TTSService) loadBoundaries(ctx context.Context, key string) ([]models.Boundary, error) {
+	data, err := storage.ReadAll(ctx, s.store, boundariesKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var boundaries []models.Boundary
+	if err := json.Unmarshal(data, &boundaries); err != nil {
+		return nil, err
+	}
+	return boundaries, nil
 }
 
-// saveAudioFile 保存音频文件
-func (s *TTSService) saveAudioFile(audioData []byte, hash, format string) (string, error) {
+// wantsBoundaries 请求是否需要边界元数据，生成字幕同样依赖边界元数据
+func wantsBoundaries(req *models.TTSRequest) bool {
+	return req.WordBoundary || req.SentenceBoundary || req.Subtitles != ""
+}
+
+// filterBoundaries 只保留请求的边界类型
+func filterBoundaries(req *models.TTSRequest, boundaries []models.Boundary) []models.Boundary {
+	filtered := make([]models.Boundary, 0, len(boundaries))
+	for _, b := range boundaries {
+		if (b.Type == models.WordBoundary && req.WordBoundary) ||
+			(b.Type == models.SentenceBoundary && req.SentenceBoundary) {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered
+}
+
+// saveAudioFile 将write产生的音频写入 storage.path 下的临时文件，成功后计算时长并写入存储
+// 文件系统存储直接原子重命名为缓存文件，对象存储上传完成后删除临时文件，返回对象键和时长（秒）
+func (s *TTSService) saveAudioFile(ctx context.Context, hash, format string, write func(io.Writer) error) (string, float64, error) {
 	// 确保存储目录存在
 	if err := os.MkdirAll(s.config.Storage.Path, 0755); err != nil {
-		return "", err
+		return "", 0, fmt.Errorf("保存音频文件失败: %w", err)
 	}
 
 	// 生成文件名
-	filename := hash + utils.GetFileExtension(format)
-	audioPath := filepath.Join(s.config.Storage.Path, filename)
+	key := hash + utils.GetFileExtension(format)
 
-	// 写入文件
-	if err := os.WriteFile(audioPath, audioData, 0644); err != nil {
-		return "", err
+	tmp, err := os.CreateTemp(s.config.Storage.Path, key+".*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("保存音频文件失败: %w", err)
 	}
 
-	return audioPath, nil
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", 0, err
+	}
+	// 分段拼接的WAV边合成边输出，头部长度未知，写入缓存前按实际长度修正
+	if format == "wav" {
+		if err := fixWAVFile(tmp); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return "", 0, fmt.Errorf("保存音频文件失败: %w", err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("保存音频文件失败: %w", err)
+	}
+
+	duration := s.audioDuration(format, tmp.Name())
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("保存音频文件失败: %w", err)
+	}
+	if err := storage.PutFile(ctx, s.store, key, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("保存音频文件失败: %w", err)
+	}
+	s.addStorage(key, info.Size())
+
+	return key, duration, nil
+}
+
+// fixWAVFile 修正WAV文件头中的长度
+func fixWAVFile(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return audio.FixWAVHeader(f, info.Size())
+}
+
+// putObject 写入边界元数据、字幕等小文件并计入存储占用
+func (s *TTSService) putObject(ctx context.Context, key string, data []byte) error {
+	if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data))); err != nil {
+		return err
+	}
+	s.addStorage("", int64(len(data)))
+	return nil
 }
 
 // getAudioURL 生成音频访问URL
-func (s *TTSService) getAudioURL(audioPath string) string {
-	filename := filepath.Base(audioPath)
-	return fmt.Sprintf("/api/v1/audio/%s", filename)
+func (s *TTSService) getAudioURL(key string) string {
+	return fmt.Sprintf("/api/v1/audio/%s", objectKey(key))
 }
 
-// GetAudioFilePath 根据文件名获取音频文件路径
-func (s *TTSService) GetAudioFilePath(filename string) string {
+// AudioKey 根据下载地址中的文件名获取对象键
+func (s *TTSService) AudioKey(filename string) string {
 	// 安全检查，防止路径遍历
-	cleanFilename := utils.SanitizeFileName(filename)
-	return filepath.Join(s.config.Storage.Path, cleanFilename)
+	return utils.SanitizeFileName(filename)
 }
 
-// CleanupExpiredCache 清理过期缓存
-func (s *TTSService) CleanupExpiredCache() error {
-	// 删除数据库中的过期记录
-	deleted, err := s.db.DeleteExpiredCache(s.config.Storage.CleanupHours)
-	if err != nil {
-		return fmt.Errorf("清理数据库缓存失败: %w", err)
-	}
-
-	fmt.Printf("清理了 %d 条过期缓存记录\n", deleted)
+// Storage 缓存音频所在的存储后端
+func (s *TTSService) Storage() storage.Storage {
+	return s.store
+}
 
-	// TODO: 清理对应的音频文件（可以添加文件系统清理逻辑）
+// ReadAudio 读取合成结果的音频，filename为下载地址中的文件名
+func (s *TTSService) ReadAudio(ctx context.Context, filename string) ([]byte, error) {
+	return storage.ReadAll(ctx, s.store, s.AudioKey(filename))
+}
 
-	return nil
-}
\ No newline at end of file
+// AudioRedirectURL 配置了 storage.s3.redirect 时返回对象的预签名下载地址，否则返回空字符串，由服务转发
+func (s *TTSService) AudioRedirectURL(ctx context.Context, key string) (string, error) {
+	if !s.config.Storage.S3.Redirect {
+		return "", nil
+	}
+	expires := time.Duration(s.config.Storage.S3.PresignSeconds) * time.Second
+	if expires <= 0 {
+		expires = time.Hour
+	}
+	url, err := s.store.Presign(ctx, key, expires)
+	if errors.Is(err, storage.ErrPresignUnsupported) {
+		return "", nil
+	}
+	return url, err
+}
@@ -0,0 +1,232 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"tts-service/internal/audio"
+	"tts-service/internal/config"
+	"tts-service/internal/db"
+	"tts-service/internal/models"
+	"tts-service/internal/storage"
+)
+
+const testVoice = "test-voice"
+
+// synthFunc 测试引擎的合成实现
+type synthFunc func(ctx context.Context, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error)
+
+// fakeEngine 测试用引擎，synthesize为nil时输出一段静音WAV
+// voices为空时只有一个语言未知的测试语音
+type fakeEngine struct {
+	name       string
+	synthesize synthFunc
+	voices     []models.Voice
+	ssml       bool
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *fakeEngine) Name() string               { return e.name }
+func (e *fakeEngine) SupportedFormats() []string { return []string{"wav"} }
+func (e *fakeEngine) Capabilities() Capabilities { return Capabilities{SSML: e.ssml} }
+
+func (e *fakeEngine) ListVoices(ctx context.Context) ([]models.Voice, error) {
+	if len(e.voices) > 0 {
+		return e.voices, nil
+	}
+	return []models.Voice{{Name: testVoice, Language: "und", Engine: e.name, Formats: e.SupportedFormats()}}, nil
+}
+
+func (e *fakeEngine) Synthesize(ctx context.Context, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	if e.synthesize != nil {
+		return e.synthesize(ctx, req, w)
+	}
+	return writeSilence(w)
+}
+
+func (e *fakeEngine) callCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+// writeSilence 输出100ms静音WAV
+func writeSilence(w io.Writer) (*SynthesisResult, error) {
+	pcm := make([]byte, audio.PCMBytes(100*time.Millisecond))
+	data := append(audio.WAVHeader(uint32(len(pcm)), audio.SampleRate, audio.Channels, audio.BitsPerSample), pcm...)
+	n, err := w.Write(data)
+	return &SynthesisResult{Size: int64(n)}, err
+}
+
+// newTestService 创建只包含指定引擎的服务，数据库和存储位于临时目录
+func newTestService(t *testing.T, breaker config.BreakerConfig, engines ...Engine) *TTSService {
+	t.Helper()
+	dir := t.TempDir()
+	database, err := db.Init(filepath.Join(dir, "tts.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	cfg := &config.Config{}
+	cfg.Storage.Path = filepath.Join(dir, "storage")
+	cfg.TTS.Engines = []string{EngineOffline}
+	cfg.TTS.DefaultFormat = "wav"
+	cfg.TTS.Breaker = breaker
+
+	s := NewTTSService(database, cfg, storage.NewFS(cfg.Storage.Path))
+	s.engines = &EngineRegistry{
+		engines:  make(map[string]Engine),
+		breakers: make(map[string]*CircuitBreaker),
+		breaker:  breaker,
+	}
+	for _, engine := range engines {
+		s.engines.Register(engine)
+	}
+	return s
+}
+
+func TestProcessFailover(t *testing.T) {
+	errEngine := errors.New("引擎故障")
+	failing := func(ctx context.Context, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error) {
+		return nil, errEngine
+	}
+	// 输出部分音频后失败
+	partial := func(ctx context.Context, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error) {
+		w.Write([]byte("RIFF"))
+		return nil, errEngine
+	}
+
+	tests := []struct {
+		name      string
+		engines   []synthFunc
+		stream    bool
+		wantErr   bool
+		wantCalls []int
+	}{
+		{name: "first succeeds", engines: []synthFunc{nil, nil}, wantCalls: []int{1, 0}},
+		{name: "in order", engines: []synthFunc{failing, failing, nil}, wantCalls: []int{1, 1, 1}},
+		{name: "partial without client", engines: []synthFunc{partial, nil}, wantCalls: []int{1, 1}},
+		{name: "partial streamed to client", engines: []synthFunc{partial, nil}, stream: true, wantErr: true, wantCalls: []int{1, 0}},
+		{name: "all fail", engines: []synthFunc{failing, failing}, wantErr: true, wantCalls: []int{1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var engines []Engine
+			var fakes []*fakeEngine
+			for i, fn := range tt.engines {
+				e := &fakeEngine{name: string(rune('a' + i)), synthesize: fn}
+				engines = append(engines, e)
+				fakes = append(fakes, e)
+			}
+			s := newTestService(t, config.BreakerConfig{}, engines...)
+
+			var w io.Writer
+			var client bytes.Buffer
+			if tt.stream {
+				w = &client
+			}
+			_, err := s.process(context.Background(), &models.TTSRequest{Text: "你好", Voice: testVoice, Format: "wav"}, w)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, e := range fakes {
+				if got := e.callCount(); got != tt.wantCalls[i] {
+					t.Errorf("引擎 %s 调用 %d 次, 期望 %d 次", e.name, got, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
+
+func TestProcessSkipsOpenBreaker(t *testing.T) {
+	a := &fakeEngine{name: "a"}
+	b := &fakeEngine{name: "b"}
+	s := newTestService(t, config.BreakerConfig{FailureThreshold: 1, CooldownSeconds: 60}, a, b)
+	s.engines.Breaker("a").Failure(errors.New("引擎故障"))
+
+	if _, err := s.process(context.Background(), &models.TTSRequest{Text: "你好", Voice: testVoice, Format: "wav"}, nil); err != nil {
+		t.Fatalf("故障转移失败: %v", err)
+	}
+	if a.callCount() != 0 || b.callCount() != 1 {
+		t.Fatalf("熔断中的引擎不应被调用: a=%d b=%d", a.callCount(), b.callCount())
+	}
+
+	// 所有引擎都熔断时返回带等待时间的UnavailableError
+	s.engines.Breaker("b").Failure(errors.New("引擎故障"))
+	_, err := s.process(context.Background(), &models.TTSRequest{Text: "再见", Voice: testVoice, Format: "wav"}, nil)
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("err = %v, 期望 UnavailableError", err)
+	}
+	if unavailable.RetryAfter <= 0 || unavailable.RetryAfter > time.Minute {
+		t.Fatalf("RetryAfter = %v, 期望在冷却时间以内", unavailable.RetryAfter)
+	}
+}
+
+func TestMixedLanguageChunks(t *testing.T) {
+	var mu sync.Mutex
+	var documents []string
+	engine := &fakeEngine{
+		name: "a",
+		ssml: true,
+		voices: []models.Voice{
+			{Name: "zh-CN-XiaoxiaoNeural", Language: "zh-CN", Engine: "a", Formats: []string{"wav"}},
+			{Name: "en-US-JennyNeural", Language: "en-US", Engine: "a", Formats: []string{"wav"}},
+		},
+		synthesize: func(ctx context.Context, req *models.TTSRequest, w io.Writer) (*SynthesisResult, error) {
+			if !req.SSML {
+				return nil, errors.New("混合语言的片段应作为SSML合成")
+			}
+			mu.Lock()
+			documents = append(documents, req.Text)
+			mu.Unlock()
+			return writeSilence(w)
+		},
+	}
+	s := newTestService(t, config.BreakerConfig{}, engine)
+	s.config.TTS.Chunk.MaxChars = 30
+
+	req := &models.TTSRequest{
+		Text:          "今天我们学习 Machine Learning 的基本概念。明天我们复习 Deep Learning 的主要内容。",
+		Voice:         "zh-CN-XiaoxiaoNeural",
+		Format:        "wav",
+		Rate:          "+20%",
+		MixedLanguage: true,
+	}
+	if err := s.NormalizeRequest(req); err != nil {
+		t.Fatalf("规范化失败: %v", err)
+	}
+	if req.SSML || strings.HasPrefix(req.Text, "<speak") {
+		t.Fatalf("请求应保持为纯文本请求: SSML=%v text=%q", req.SSML, req.Text)
+	}
+	if len(req.Documents) != 2 || req.Rate != "" {
+		t.Fatalf("生成 %d 个文档, rate=%q, 期望按句子生成2个文档且语速写入文档", len(req.Documents), req.Rate)
+	}
+
+	if _, err := s.process(context.Background(), req, nil); err != nil {
+		t.Fatalf("合成失败: %v", err)
+	}
+	if len(documents) != 2 {
+		t.Fatalf("引擎收到 %d 个文档, 期望 2 个", len(documents))
+	}
+	for _, doc := range documents {
+		for _, want := range []string{"<speak", "zh-CN-XiaoxiaoNeural", "en-US-JennyNeural", `rate="+20%"`} {
+			if !strings.Contains(doc, want) {
+				t.Errorf("文档缺少 %s: %s", want, doc)
+			}
+		}
+	}
+}
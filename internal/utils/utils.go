@@ -1,52 +1,17 @@
 package utils
 
 import (
-	"crypto/md5"
 	"fmt"
 	"github.com/google/uuid"
+	"strconv"
 	"strings"
 )
 
-// GenerateTextHash 生成文本哈希用于缓存
-func GenerateTextHash(text, voice, format string) string {
-	combined := fmt.Sprintf("%s|%s|%s", text, voice, format)
-	hash := md5.Sum([]byte(combined))
-	return fmt.Sprintf("%x", hash)
-}
-
 // GenerateRequestID 生成请求ID
 func GenerateRequestID() string {
 	return strings.ReplaceAll(uuid.New().String(), "-", "")
 }
 
-// GenerateSSML 生成SSML格式的文本
-func GenerateSSML(text, voice string, speed float64, pitch int) string {
-	// 处理语音参数
-	speedStr := fmt.Sprintf("%.1f", speed)
-	if speed == 1.0 {
-		speedStr = "default"
-	}
-
-	pitchStr := "default"
-	if pitch != 0 {
-		if pitch > 0 {
-			pitchStr = fmt.Sprintf("+%dHz", pitch)
-		} else {
-			pitchStr = fmt.Sprintf("%dHz", pitch)
-		}
-	}
-
-	ssml := fmt.Sprintf(`<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="en-US">
-		<voice name="%s">
-			<prosody rate="%s" pitch="%s">
-				%s
-			</prosody>
-		</voice>
-	</speak>`, voice, speedStr, pitchStr, text)
-
-	return ssml
-}
-
 // SanitizeFileName 清理文件名
 func SanitizeFileName(name string) string {
 	// 替换不安全的字符
@@ -76,9 +41,53 @@ func GetFileExtension(format string) string {
 		return ".ogg"
 	case "m4a":
 		return ".m4a"
+	case "aac":
+		return ".aac"
 	case "flac":
 		return ".flac"
+	case "pcm":
+		return ".pcm"
 	default:
 		return ".mp3"
 	}
-}
\ No newline at end of file
+}
+
+// sizeUnits 容量单位，按1024进位，KB与KiB含义相同
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1 << 40,
+	"tib": 1 << 40,
+}
+
+// ParseSize 解析 "3GB"、"500 MB"、"1.5g" 这类容量写法，返回字节数，空字符串返回0
+func ParseSize(s string) (int64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	unit, ok := sizeUnits[strings.TrimSpace(s[i:])]
+	if !ok {
+		return 0, fmt.Errorf("无法识别的容量单位: %q", s[i:])
+	}
+	value, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("无效的容量: %q", s)
+	}
+	return int64(value * float64(unit)), nil
+}
@@ -0,0 +1,41 @@
+package utils
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "  ", want: 0},
+		{in: "0", want: 0},
+		{in: "1024", want: 1024},
+		{in: "512b", want: 512},
+		{in: "1k", want: 1 << 10},
+		{in: "2KB", want: 2 << 10},
+		{in: "2KiB", want: 2 << 10},
+		{in: "500 MB", want: 500 << 20},
+		{in: "1.5g", want: 3 << 29},
+		{in: "3GB", want: 3 << 30},
+		{in: " 1 TiB ", want: 1 << 40},
+		{in: ".5m", want: 1 << 19},
+		{in: "10xb", wantErr: true},
+		{in: "GB", wantErr: true},
+		{in: "-1GB", wantErr: true},
+		{in: "1.2.3mb", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSize(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) 错误 = %v, 期望出错: %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseSize(%q) = %d, 期望 %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
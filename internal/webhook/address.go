@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenAddress 回调地址指向本机或内网，拒绝投递，防止通过回调访问内部服务
+var ErrForbiddenAddress = errors.New("回调地址不能指向本机、链路本地或内网地址")
+
+// forbiddenIP 环回、链路本地(含云厂商的元数据地址169.254.169.254)、内网、未指定和组播地址
+func forbiddenIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// checkDialAddress 作为net.Dialer.Control在建立连接前检查实际连接的地址
+// 域名解析结果在校验之后变化(DNS重绑定)或重定向到内网时同样会被拒绝
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || forbiddenIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return nil
+}
+
+// ValidateURL 提交任务时校验回调地址：必须是http(s)的绝对地址，
+// 未开启 webhook.allow_private_networks 时域名解析出的全部地址都不能是本机或内网地址
+func (d *Dispatcher) ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("callback_url must be an absolute http(s) URL")
+	}
+	if d.allowPrivate {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("解析回调地址失败: %w", err)
+	}
+	for _, addr := range addrs {
+		if forbiddenIP(addr.IP) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr.IP)
+		}
+	}
+	return nil
+}
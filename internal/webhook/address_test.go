@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"tts-service/internal/config"
+)
+
+func TestCheckDialAddress(t *testing.T) {
+	tests := []struct {
+		address   string
+		forbidden bool
+	}{
+		{"93.184.216.34:80", false},
+		{"[2606:4700::1111]:443", false},
+		{"127.0.0.1:80", true},
+		{"127.10.0.1:80", true},
+		{"[::1]:443", true},
+		{"[::ffff:127.0.0.1]:80", true},
+		{"10.0.0.1:80", true},
+		{"172.16.5.4:80", true},
+		{"192.168.1.1:80", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"[fd00::1]:80", true},
+		{"0.0.0.0:80", true},
+		{"[::]:80", true},
+		{"224.0.0.1:80", true},
+		{"[ff02::1]:80", true},
+		// 拨号时地址已经解析，不是IP时无法判断，同样拒绝
+		{"localhost:80", true},
+	}
+	for _, tt := range tests {
+		err := checkDialAddress("tcp", tt.address, nil)
+		if forbidden := errors.Is(err, ErrForbiddenAddress); forbidden != tt.forbidden || (!tt.forbidden && err != nil) {
+			t.Errorf("checkDialAddress(%q) = %v, 期望拒绝: %v", tt.address, err, tt.forbidden)
+		}
+	}
+
+	if err := checkDialAddress("tcp", "127.0.0.1", nil); err == nil || errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("缺少端口时应返回地址格式错误, 得到 %v", err)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      bool
+		forbidden    bool
+	}{
+		{url: "https://93.184.216.34/hook"},
+		{url: "http://127.0.0.1:8080/hook", wantErr: true, forbidden: true},
+		{url: "http://[::1]/hook", wantErr: true, forbidden: true},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: true, forbidden: true},
+		{url: "http://127.0.0.1:8080/hook", allowPrivate: true},
+		{url: "ftp://93.184.216.34/hook", wantErr: true},
+		{url: "/hook", wantErr: true},
+		{url: "http:///hook", wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.Webhook.AllowPrivateNetworks = tt.allowPrivate
+		err := NewDispatcher(nil, cfg).ValidateURL(context.Background(), tt.url)
+		if (err != nil) != tt.wantErr || errors.Is(err, ErrForbiddenAddress) != tt.forbidden {
+			t.Errorf("ValidateURL(%q, allowPrivate=%v) = %v", tt.url, tt.allowPrivate, err)
+		}
+	}
+}
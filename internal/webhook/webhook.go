@@ -0,0 +1,321 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tts-service/internal/config"
+	"tts-service/internal/db"
+	"tts-service/internal/models"
+)
+
+// 请求头
+const (
+	HeaderSignature = "X-TTS-Signature"
+	HeaderTimestamp = "X-TTS-Timestamp"
+	HeaderDelivery  = "X-TTS-Delivery"
+)
+
+// 默认投递参数
+const (
+	defaultMaxAttempts = 8
+	defaultBaseDelay   = 10 * time.Second
+	defaultWorkers     = 4
+	maxDelay           = time.Hour
+	pollInterval       = 5 * time.Second
+	batchSize          = 20
+)
+
+// Dispatcher Webhook投递器
+// 投递记录先写入数据库再异步发送，失败后按指数退避重试，服务重启后继续投递
+// 最多workers个投递同时进行，个别回调地址响应慢时不影响其他投递
+type Dispatcher struct {
+	db           *db.DB
+	client       *http.Client
+	publicURL    string
+	maxAttempts  int
+	baseDelay    time.Duration
+	workers      int
+	allowPrivate bool
+	notify       chan struct{}
+
+	mu       sync.Mutex
+	inflight map[int]bool // 正在投递的记录，发送完成前仍是待投递状态，不能重复取出
+}
+
+// NewDispatcher 创建Webhook投递器
+func NewDispatcher(database *db.DB, cfg *config.Config) *Dispatcher {
+	maxAttempts := cfg.Webhook.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	baseDelay := time.Duration(cfg.Webhook.BaseDelaySeconds) * time.Second
+	if baseDelay <= 0 {
+		baseDelay = defaultBaseDelay
+	}
+	timeout := time.Duration(cfg.Webhook.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	workers := cfg.Webhook.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	// 连接前检查实际的目标地址，重定向和DNS重绑定也无法绕过；经过代理时检查不到目标地址，因此不使用代理
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.Webhook.AllowPrivateNetworks {
+		dialer.Control = checkDialAddress
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		db:           database,
+		client:       &http.Client{Timeout: timeout, Transport: transport},
+		publicURL:    strings.TrimRight(cfg.Server.PublicURL, "/"),
+		maxAttempts:  maxAttempts,
+		baseDelay:    baseDelay,
+		workers:      workers,
+		allowPrivate: cfg.Webhook.AllowPrivateNetworks,
+		notify:       make(chan struct{}, 1),
+		inflight:     make(map[int]bool),
+	}
+}
+
+// Start 启动投递循环
+func (d *Dispatcher) Start(ctx context.Context) {
+	go d.loop(ctx)
+}
+
+// JobFinished 任务结束时创建投递记录，实现job.Notifier接口
+func (d *Dispatcher) JobFinished(job *models.Job) {
+	if job.CallbackURL == "" {
+		return
+	}
+
+	payload := models.WebhookPayload{
+		Event:  "job.finished",
+		JobID:  job.ID,
+		Status: job.Status,
+		Error:  job.Error,
+	}
+	if job.Result != nil {
+		payload.AudioURL = d.publicURL + job.Result.AudioURL
+		payload.Duration = job.Result.Duration
+		payload.Size = job.Result.Size
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Printf("序列化Webhook内容失败: %v\n", err)
+		return
+	}
+
+	delivery := &models.WebhookDelivery{
+		JobID:   job.ID,
+		UserID:  job.UserID,
+		URL:     job.CallbackURL,
+		Payload: string(body),
+	}
+	if err := d.db.CreateDelivery(delivery); err != nil {
+		fmt.Printf("创建Webhook投递记录失败: %v\n", err)
+		return
+	}
+
+	d.wake()
+}
+
+// Replay 重新投递
+func (d *Dispatcher) Replay(id int) error {
+	if err := d.db.ResetDelivery(id); err != nil {
+		return err
+	}
+	d.wake()
+	return nil
+}
+
+// Secret 获取用户的签名密钥，尚未生成时自动生成
+func (d *Dispatcher) Secret(userID int) (string, error) {
+	secret, err := d.db.GetWebhookSecret(userID)
+	if err != nil || secret != "" {
+		return secret, err
+	}
+	return d.RotateSecret(userID)
+}
+
+// RotateSecret 生成并保存新的签名密钥，旧密钥立即失效
+func (d *Dispatcher) RotateSecret(userID int) (string, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := d.db.SetWebhookSecret(userID, secret); err != nil {
+		return "", fmt.Errorf("保存密钥失败: %w", err)
+	}
+	return secret, nil
+}
+
+// wake 通知投递循环
+func (d *Dispatcher) wake() {
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+}
+
+// loop 定期取出到期的记录交给工作协程投递，工作协程都在忙时等待
+func (d *Dispatcher) loop(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	slots := make(chan struct{}, d.workers)
+	// 结果中最多有workers条正在投递的记录，多取这些保证取满时还有新的记录
+	limit := batchSize + d.workers
+	for {
+		deliveries, err := d.db.ListDueDeliveries(limit)
+		if err != nil {
+			fmt.Printf("查询待投递Webhook失败: %v\n", err)
+		}
+		for _, delivery := range deliveries {
+			if !d.begin(delivery.ID) {
+				continue
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(delivery *models.WebhookDelivery) {
+				defer func() {
+					d.end(delivery.ID)
+					<-slots
+				}()
+				d.deliver(ctx, delivery)
+			}(delivery)
+		}
+		if len(deliveries) == limit {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// begin 标记记录正在投递，已在投递中时返回false
+func (d *Dispatcher) begin(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.inflight[id] {
+		return false
+	}
+	d.inflight[id] = true
+	return true
+}
+
+// end 投递结果已写入数据库，之后可以再次取出重试
+func (d *Dispatcher) end(id int) {
+	d.mu.Lock()
+	delete(d.inflight, id)
+	d.mu.Unlock()
+}
+
+// deliver 投递一次并记录结果
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	statusCode, err := d.send(ctx, delivery)
+	if err == nil {
+		if err := d.db.RecordDeliveryAttempt(delivery.ID, models.DeliverySucceeded, statusCode, "", time.Now()); err != nil {
+			fmt.Printf("更新Webhook投递记录失败: %v\n", err)
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	status := models.DeliveryPending
+	if attempts >= d.maxAttempts {
+		status = models.DeliveryFailed
+	}
+	next := time.Now().Add(d.backoff(attempts))
+	if err := d.db.RecordDeliveryAttempt(delivery.ID, status, statusCode, err.Error(), next); err != nil {
+		fmt.Printf("更新Webhook投递记录失败: %v\n", err)
+	}
+}
+
+// backoff 第attempts次失败后的等待时间，按指数增长
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.baseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return delay
+}
+
+// send 发送带签名的请求，2xx视为成功
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	secret, err := d.Secret(delivery.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TTS-Service-Webhook/1.0")
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("回调地址返回状态码 %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign 计算签名：HMAC-SHA256(secret, timestamp + "." + body) 的十六进制编码
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret 生成新的签名密钥
+func GenerateSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("生成密钥失败: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
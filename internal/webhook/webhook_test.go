@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"tts-service/internal/config"
+	"tts-service/internal/db"
+	"tts-service/internal/models"
+)
+
+func TestSign(t *testing.T) {
+	// 期望值由 printf '%s' "$timestamp.$body" | openssl dgst -sha256 -hmac "$secret" 计算
+	tests := []struct {
+		secret, timestamp, body string
+		want                    string
+	}{
+		{"whsec_test", "1700000000", `{"event":"job.finished"}`, "9b0eafa54f4126899c27a965e58b455e0fda4caf0bb9b16e130511e5dc7b73bd"},
+		{"k", "0", "", "6b4a4b8b3c40f1e8f53a3d36682e5f99f7ad2ac1df1c93dfe336f329167641e7"},
+	}
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %q, %q) = %s, 期望 %s", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	const payload = `{"event":"job.finished","job_id":"j1"}`
+
+	tests := []struct {
+		name         string
+		allowPrivate bool
+		wantErr      error
+	}{
+		{name: "signed request", allowPrivate: true},
+		{name: "private address rejected", wantErr: ErrForbiddenAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				body, _ = io.ReadAll(r.Body)
+			}))
+			defer server.Close()
+
+			database, err := db.Init(filepath.Join(t.TempDir(), "tts.db"))
+			if err != nil {
+				t.Fatalf("初始化数据库失败: %v", err)
+			}
+			defer database.Close()
+			user := &models.User{APIKey: "k1", Name: "test"}
+			if err := database.CreateUser(user); err != nil {
+				t.Fatal(err)
+			}
+			if err := database.SetWebhookSecret(user.ID, "whsec_test"); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &config.Config{}
+			cfg.Webhook.AllowPrivateNetworks = tt.allowPrivate
+			d := NewDispatcher(database, cfg)
+
+			delivery := &models.WebhookDelivery{ID: 7, UserID: user.ID, URL: server.URL + "/hook", Payload: payload}
+			status, err := d.send(context.Background(), delivery)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || got != nil {
+					t.Fatalf("err = %v, 期望 %v 且不发出请求", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("send = %d, %v", status, err)
+			}
+
+			if string(body) != payload {
+				t.Fatalf("请求体为 %s, 期望 %s", body, payload)
+			}
+			if got.Header.Get(HeaderDelivery) != "7" {
+				t.Errorf("%s = %q, 期望 7", HeaderDelivery, got.Header.Get(HeaderDelivery))
+			}
+			// 接收方按文档验证签名：HMAC-SHA256(secret, timestamp + "." + body)
+			mac := hmac.New(sha256.New, []byte("whsec_test"))
+			mac.Write([]byte(got.Header.Get(HeaderTimestamp) + "." + string(body)))
+			signature := got.Header.Get(HeaderSignature)
+			if !strings.HasPrefix(signature, "sha256=") || signature != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+				t.Errorf("%s = %q, 签名不一致", HeaderSignature, signature)
+			}
+		})
+	}
+}
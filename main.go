@@ -7,6 +7,7 @@ import (
 	"tts-service/internal/config"
 	"tts-service/internal/db"
 	"tts-service/internal/server"
+	"tts-service/internal/storage"
 )
 
 func main() {
@@ -24,7 +25,7 @@ func main() {
 	if err := os.MkdirAll(cfg.Storage.Path, 0755); err != nil {
 		log.Fatalf("创建存储目录失败: %v", err)
 	}
-	
+
 	if err := os.MkdirAll("./logs", 0755); err != nil {
 		log.Fatalf("创建日志目录失败: %v", err)
 	}
@@ -36,9 +37,15 @@ func main() {
 	}
 	defer database.Close()
 
+	// 初始化缓存音频的存储后端
+	store, err := storage.New(&cfg.Storage)
+	if err != nil {
+		log.Fatalf("初始化存储失败: %v", err)
+	}
+
 	// 启动服务器
-	srv := server.New(cfg, database)
+	srv := server.New(cfg, database, store)
 	if err := srv.Start(); err != nil {
 		log.Fatalf("启动服务器失败: %v", err)
 	}
-}
\ No newline at end of file
+}